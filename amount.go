@@ -0,0 +1,327 @@
+package cryptomus
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Amount 是精确的十进制金额，用于所有请求与响应中的金额、余额、费用与汇率字段。
+//
+// Amount 的零值表示 0。Amount 是不可变的，所有运算都返回新的值，可以安全地在多个 goroutine 之间共享。
+//
+// JSON 编码为字符串（例如 "0.00123"），解码时同时接受字符串与数字，空字符串与 null 解码为 0。
+//
+// 示例：
+//
+//	amount := cryptomus.MustParseAmount("15.5")
+//	fee := amount.Mul(cryptomus.MustParseAmount("0.01")).RoundCurrency("USDT")
+//	log.Println(amount.Sub(fee)) // 15.345
+type Amount struct {
+	// value 是去掉小数点后的整数值，nil 表示 0。
+	value *big.Int
+	// scale 是小数位数，金额等于 value * 10^-scale。
+	scale int32
+}
+
+// maxAmountExponent 是 ParseAmount 接受的指数的最大绝对值，避免 "1e2000000000" 之类的输入分配巨大的整数。
+const maxAmountExponent = 100
+
+// ParseAmount 解析十进制金额，例如 "15"、"-0.5"、"0.00123" 或 "1e-8"。指数的绝对值不能超过 100。
+func ParseAmount(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Amount{}, fmt.Errorf("cryptomus: invalid amount %q", s)
+	}
+
+	mantissa, exponent := s, int64(0)
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		exp, err := strconv.ParseInt(s[i+1:], 10, 32)
+		if err != nil || exp < -maxAmountExponent || exp > maxAmountExponent {
+			return Amount{}, fmt.Errorf("cryptomus: invalid amount %q", s)
+		}
+		mantissa, exponent = s[:i], exp
+	}
+
+	negative := false
+	switch {
+	case strings.HasPrefix(mantissa, "-"):
+		negative = true
+		mantissa = mantissa[1:]
+	case strings.HasPrefix(mantissa, "+"):
+		mantissa = mantissa[1:]
+	}
+
+	integer, fraction, _ := strings.Cut(mantissa, ".")
+	digits := integer + fraction
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return Amount{}, fmt.Errorf("cryptomus: invalid amount %q", s)
+	}
+
+	value, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return Amount{}, fmt.Errorf("cryptomus: invalid amount %q", s)
+	}
+	if negative {
+		value.Neg(value)
+	}
+
+	scale := int64(len(fraction)) - exponent
+	if scale > math.MaxInt32 {
+		return Amount{}, fmt.Errorf("cryptomus: invalid amount %q", s)
+	}
+	if scale < 0 {
+		value.Mul(value, pow10(int32(-scale)))
+		scale = 0
+	}
+
+	return Amount{value: value, scale: int32(scale)}, nil
+}
+
+// MustParseAmount 与 ParseAmount 相同，但在解析失败时 panic，适用于常量金额。
+func MustParseAmount(s string) Amount {
+	amount, err := ParseAmount(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return amount
+}
+
+// NewAmount 返回 unscaled * 10^-scale，例如 NewAmount(150, 2) 表示 1.50。
+func NewAmount(unscaled int64, scale int32) Amount {
+	if scale < 0 {
+		return Amount{value: new(big.Int).Mul(big.NewInt(unscaled), pow10(-scale))}
+	}
+
+	return Amount{value: big.NewInt(unscaled), scale: scale}
+}
+
+// AmountFromInt 返回整数金额。
+func AmountFromInt(i int64) Amount {
+	return NewAmount(i, 0)
+}
+
+func (a Amount) int() *big.Int {
+	if a.value == nil {
+		return new(big.Int)
+	}
+
+	return a.value
+}
+
+// rescale 返回小数位数为 scale 的整数值，scale 必须不小于 a.scale。
+func (a Amount) rescale(scale int32) *big.Int {
+	if scale == a.scale {
+		return a.int()
+	}
+
+	return new(big.Int).Mul(a.int(), pow10(scale-a.scale))
+}
+
+// String 返回十进制表示，保留原始的小数位数，例如 "1.50"。
+func (a Amount) String() string {
+	value := a.int()
+
+	digits := new(big.Int).Abs(value).String()
+	if a.scale > 0 {
+		if pad := int(a.scale) + 1 - len(digits); pad > 0 {
+			digits = strings.Repeat("0", pad) + digits
+		}
+		digits = digits[:len(digits)-int(a.scale)] + "." + digits[len(digits)-int(a.scale):]
+	}
+
+	if value.Sign() < 0 {
+		return "-" + digits
+	}
+
+	return digits
+}
+
+// Scale 返回小数位数。
+func (a Amount) Scale() int32 {
+	return a.scale
+}
+
+// Sign 返回 -1、0 或 1。
+func (a Amount) Sign() int {
+	return a.int().Sign()
+}
+
+// IsZero 判断金额是否为 0。
+func (a Amount) IsZero() bool {
+	return a.Sign() == 0
+}
+
+// Cmp 比较两个金额，a < b 返回 -1，a == b 返回 0，a > b 返回 1。
+func (a Amount) Cmp(b Amount) int {
+	scale := max(a.scale, b.scale)
+	return a.rescale(scale).Cmp(b.rescale(scale))
+}
+
+// Equal 判断两个金额的数值是否相等，忽略小数位数的差异（1.5 与 1.50 相等）。
+func (a Amount) Equal(b Amount) bool {
+	return a.Cmp(b) == 0
+}
+
+// Add 返回 a + b。
+func (a Amount) Add(b Amount) Amount {
+	scale := max(a.scale, b.scale)
+	return Amount{value: new(big.Int).Add(a.rescale(scale), b.rescale(scale)), scale: scale}
+}
+
+// Sub 返回 a - b。
+func (a Amount) Sub(b Amount) Amount {
+	scale := max(a.scale, b.scale)
+	return Amount{value: new(big.Int).Sub(a.rescale(scale), b.rescale(scale)), scale: scale}
+}
+
+// Mul 返回 a * b，结果的小数位数为两者之和。
+func (a Amount) Mul(b Amount) Amount {
+	return Amount{value: new(big.Int).Mul(a.int(), b.int()), scale: a.scale + b.scale}
+}
+
+// Div 返回 a / b，结果四舍五入到 places 位小数。b 为 0 时 panic。
+func (a Amount) Div(b Amount, places int32) Amount {
+	if b.IsZero() {
+		panic("cryptomus: division by zero amount")
+	}
+
+	return roundRat(new(big.Rat).Quo(a.rat(), b.rat()), places, true)
+}
+
+// Neg 返回 -a。
+func (a Amount) Neg() Amount {
+	return Amount{value: new(big.Int).Neg(a.int()), scale: a.scale}
+}
+
+// Abs 返回 |a|。
+func (a Amount) Abs() Amount {
+	return Amount{value: new(big.Int).Abs(a.int()), scale: a.scale}
+}
+
+// Round 四舍五入（远离 0）到 places 位小数。小数位数不超过 places 时原样返回。
+func (a Amount) Round(places int32) Amount {
+	if a.scale <= places {
+		return a
+	}
+
+	return roundRat(a.rat(), places, true)
+}
+
+// Truncate 截断到 places 位小数。小数位数不超过 places 时原样返回。
+func (a Amount) Truncate(places int32) Amount {
+	if a.scale <= places {
+		return a
+	}
+
+	return roundRat(a.rat(), places, false)
+}
+
+// RoundCurrency 按货币的精度四舍五入，精度见 CurrencyDecimals。
+func (a Amount) RoundCurrency(currency string) Amount {
+	return a.Round(CurrencyDecimals(currency))
+}
+
+// Float64 返回最接近的 float64，仅用于展示，不要用于金额计算。
+func (a Amount) Float64() float64 {
+	f, _ := a.rat().Float64()
+	return f
+}
+
+func (a Amount) rat() *big.Rat {
+	return new(big.Rat).SetFrac(a.int(), pow10(a.scale))
+}
+
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
+
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		*a = Amount{}
+		return nil
+	}
+
+	s := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		if strings.TrimSpace(s) == "" {
+			*a = Amount{}
+			return nil
+		}
+	}
+
+	amount, err := ParseAmount(s)
+	if err != nil {
+		return err
+	}
+
+	*a = amount
+	return nil
+}
+
+func (a Amount) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+func (a *Amount) UnmarshalText(text []byte) error {
+	amount, err := ParseAmount(string(text))
+	if err != nil {
+		return err
+	}
+
+	*a = amount
+	return nil
+}
+
+// roundRat 将 r 舍入到 places 位小数；halfUp 为 false 时直接截断。
+func roundRat(r *big.Rat, places int32, halfUp bool) Amount {
+	if places < 0 {
+		places = 0
+	}
+
+	scaled := new(big.Rat).Mul(r, new(big.Rat).SetInt(pow10(places)))
+
+	quotient, remainder := new(big.Int).QuoRem(scaled.Num(), scaled.Denom(), new(big.Int))
+	if halfUp && remainder.Sign() != 0 {
+		twice := new(big.Int).Mul(new(big.Int).Abs(remainder), big.NewInt(2))
+		if twice.Cmp(scaled.Denom()) >= 0 {
+			quotient.Add(quotient, big.NewInt(int64(scaled.Sign())))
+		}
+	}
+
+	return Amount{value: quotient, scale: places}
+}
+
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// currencyDecimals 是常见货币的小数位数。
+var currencyDecimals = map[string]int32{
+	// 法币
+	"USD": 2, "EUR": 2, "GBP": 2, "RUB": 2, "UAH": 2, "KZT": 2, "TRY": 2,
+	"CNY": 2, "INR": 2, "BRL": 2, "IDR": 2, "VND": 0, "JPY": 0, "KRW": 0,
+
+	// 加密货币
+	"BTC": 8, "LTC": 8, "BCH": 8, "DOGE": 8, "DASH": 8,
+	"ETH": 18, "BNB": 18, "POL": 18, "MATIC": 18, "AVAX": 18, "DAI": 18, "SHIB": 18,
+	"USDT": 6, "USDC": 6, "TRX": 6, "SOL": 9, "TON": 9, "XMR": 12,
+}
+
+// CurrencyDecimals 返回货币的小数位数，例如 USD 为 2，BTC 为 8，未知货币默认为 8。
+func CurrencyDecimals(currency string) int32 {
+	if decimals, ok := currencyDecimals[strings.ToUpper(currency)]; ok {
+		return decimals
+	}
+
+	return 8
+}
@@ -0,0 +1,38 @@
+package cryptomus
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"15", "15"},
+		{"-0.5", "-0.5"},
+		{"+0.00123", "0.00123"},
+		{"1e-8", "0.00000001"},
+		{"1.5E2", "150"},
+		{"1e100", "1" + strings.Repeat("0", 100)},
+		{"1e-100", "0." + strings.Repeat("0", 99) + "1"},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseAmount(tt.in)
+		if err != nil {
+			t.Errorf("ParseAmount(%q): %v", tt.in, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("ParseAmount(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "abc", "1.2.3", "--1", "1e", "1e101", "1e-101", "1e2000000000", "1e-2147483648", "1e99999999999"} {
+		if got, err := ParseAmount(in); err == nil {
+			t.Errorf("ParseAmount(%q) = %s, want an error", in, got)
+		}
+	}
+}
@@ -1,9 +1,22 @@
 package cryptomus
 
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Endpoint 是相对于 API 地址的接口路径，路径参数使用 {name} 占位，例如 "/v1/exchange-rate/{currency}/list"。
 type Endpoint string
 
+// DefaultBaseURL 是 Cryptomus API 的默认地址。
+const DefaultBaseURL = "https://api.cryptomus.com"
+
 var (
-	Host = "https://api.cryptomus.com"
+	// Host 是新建 SDK 实例时使用的默认 API 地址。
+	//
+	// Deprecated: 修改全局变量会影响进程中所有新建的实例，并且与并发请求存在数据竞争。请使用 WithBaseURL 为每个实例单独设置。
+	Host = DefaultBaseURL
 )
 
 var (
@@ -45,7 +58,7 @@ var (
 
 	// 汇率相关
 
-	ExchangeRateListEndpoint Endpoint = "/v1/exchange-rate/%s/list"
+	ExchangeRateListEndpoint Endpoint = "/v1/exchange-rate/{currency}/list"
 
 	// 折扣支付相关
 
@@ -61,6 +74,87 @@ func (e Endpoint) String() string {
 	return string(e)
 }
 
+// endpointNames 是各接口的操作名称，用于链路追踪与指标。
+var endpointNames = map[Endpoint]string{
+	CreateInvoiceEndpoint:                 "payment.create",
+	GenerateQRCodeInvoiceEndpoint:         "payment.qr",
+	PaymentInformationEndpoint:            "payment.info",
+	RefundEndpoint:                        "payment.refund",
+	ResendWebhookEndpoint:                 "payment.resend",
+	PaymentListOfServicesEndpoint:         "payment.services",
+	PaymentHistoryEndpoint:                "payment.list",
+	ListOfDiscountsEndpoint:               "payment.discount.list",
+	SetDiscountToPaymentMethodEndpoint:    "payment.discount.set",
+	CreateStaticWalletEndpoint:            "wallet.create",
+	GenerateQRCodeWalletEndpoint:          "wallet.qr",
+	BlockStaticWalletEndpoint:             "wallet.block",
+	RefundPaymentOnBlockedAddressEndpoint: "wallet.refund_blocked",
+	TestingWebhookPaymentEndpoint:         "webhook.test_payment",
+	TestingWebhookPayoutEndpoint:          "webhook.test_payout",
+	TestingWebhookWalletEndpoint:          "webhook.test_wallet",
+	CreatePayoutEndpoint:                  "payout.create",
+	PayoutInformationEndpoint:             "payout.info",
+	PayoutHistoryEndpoint:                 "payout.list",
+	PayoutListOfServicesEndpoint:          "payout.services",
+	TransferToPersonalWalletEndpoint:      "transfer.to_personal",
+	TransferToBusinessWalletEndpoint:      "transfer.to_business",
+	CreateRecurringPaymentEndpoint:        "recurrence.create",
+	RecurringPaymentInformationEndpoint:   "recurrence.info",
+	ListRecurringPaymentsEndpoint:         "recurrence.list",
+	CancelRecurringPaymentEndpoint:        "recurrence.cancel",
+	ExchangeRateListEndpoint:              "exchange_rate.list",
+	BalanceEndpoint:                       "balance.get",
+}
+
+// Name 返回接口的操作名称，例如 CreateInvoiceEndpoint 为 "payment.create"。
+// 未知的接口使用去掉版本号的路径，以 "." 分隔。
+func (e Endpoint) Name() string {
+	if name, ok := endpointNames[e]; ok {
+		return name
+	}
+
+	path := strings.TrimPrefix(strings.Trim(e.String(), "/"), "v1/")
+	return strings.ReplaceAll(path, "/", ".")
+}
+
+// URL 返回基于 Host 的完整地址，不会替换路径参数。
+//
+// Deprecated: 请求地址由 SDK 实例根据 WithBaseURL 解析，不再依赖全局的 Host。
 func (e Endpoint) URL() string {
 	return Host + e.String()
 }
+
+// Path 使用 params 替换路径中的 {name} 占位符，参数值会经过 url.PathEscape 转义。
+//
+// 示例：
+//
+//	path, err := ExchangeRateListEndpoint.Path(map[string]string{"currency": "BTC"})
+//	// path == "/v1/exchange-rate/BTC/list"
+func (e Endpoint) Path(params map[string]string) (string, error) {
+	path := e.String()
+
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(path, '{')
+		if start < 0 {
+			b.WriteString(path)
+			return b.String(), nil
+		}
+
+		end := strings.IndexByte(path[start:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("cryptomus: malformed endpoint %q", e)
+		}
+		end += start
+
+		name := path[start+1 : end]
+		value := params[name]
+		if value == "" {
+			return "", fmt.Errorf("cryptomus: missing path parameter %q for endpoint %q", name, e)
+		}
+
+		b.WriteString(path[:start])
+		b.WriteString(url.PathEscape(value))
+		path = path[end+1:]
+	}
+}
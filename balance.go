@@ -1,6 +1,9 @@
 package cryptomus
 
-import "context"
+import (
+	"context"
+	"net/http"
+)
 
 type BalanceResponse struct {
 	*HTTPResponse
@@ -12,7 +15,7 @@ type Merchant struct {
 	// 钱包 UUID
 	UUID string `json:"uuid"`
 	// 商户钱包余额
-	Balance string `json:"balance"`
+	Balance Amount `json:"balance"`
 	// 钱包货币代码（例如 BTC、ETH、USDT 等）
 	CurrencyCode string `json:"currency_code"`
 }
@@ -22,7 +25,7 @@ type User struct {
 	// 钱包 UUID
 	UUID string `json:"uuid"`
 	// 个人钱包余额
-	Balance string `json:"balance"`
+	Balance Amount `json:"balance"`
 	// 钱包货币代码（例如 BTC、ETH、USDT 等）
 	CurrencyCode string `json:"currency_code"`
 }
@@ -66,14 +69,11 @@ func (sdk *Cryptomus) Balance() (*BalanceResponse, error) {
 func (sdk *Cryptomus) BalanceWithContext(ctx context.Context) (*BalanceResponse, error) {
 	var result BalanceResponse
 
-	req := sdk.HttpClient.NewRequest().
-		SetContext(ctx).
-		SetHeader("merchant", sdk.Merchant).
-		SetHeader("sign", Sign(sdk.PaymentToken, nil)).
-		SetSuccessResult(&result).
-		SetErrorResult(&result)
-
-	if _, err := req.Post(BalanceEndpoint.URL()); err != nil {
+	if err := sdk.send(ctx, &Request{
+		Method:   http.MethodPost,
+		Endpoint: BalanceEndpoint,
+		Token:    TokenPayment,
+	}, &result); err != nil {
 		return nil, err
 	}
 
@@ -0,0 +1,293 @@
+package cryptomus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen 表示接口的熔断器处于打开状态，请求没有发送。
+var ErrCircuitOpen = errors.New("cryptomus: circuit breaker is open")
+
+// CircuitOpenError 是熔断器打开时默认返回的错误，可以通过 errors.Is(err, ErrCircuitOpen) 判断。
+type CircuitOpenError struct {
+	Endpoint Endpoint
+	// RetryAt 是熔断器进入半开状态、允许试探请求的时间。
+	RetryAt time.Time
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("cryptomus: circuit breaker is open for %s until %s", e.Endpoint, e.RetryAt.Format(time.RFC3339))
+}
+
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// CircuitState 是熔断器的状态。
+type CircuitState int
+
+const (
+	CircuitClosed   CircuitState = iota // 正常发送请求
+	CircuitOpen                         // 拒绝所有请求
+	CircuitHalfOpen                     // 允许少量试探请求
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+
+	return fmt.Sprintf("CircuitState(%d)", int(s))
+}
+
+// CircuitBreaker 配置熔断器，每个接口单独计数。
+//
+// 连续 FailureThreshold 次失败后熔断器打开，在 OpenTimeout 内直接返回错误而不发送请求；
+// 之后进入半开状态，允许 HalfOpenMaxRequests 个试探请求，试探成功则关闭，失败则重新打开。
+type CircuitBreaker struct {
+	// FailureThreshold 是打开熔断器所需的连续失败次数，默认为 5。
+	FailureThreshold int
+	// OpenTimeout 是熔断器保持打开的时间，默认为 30 秒。
+	OpenTimeout time.Duration
+	// HalfOpenMaxRequests 是半开状态下同时允许的试探请求数，默认为 1。
+	HalfOpenMaxRequests int
+
+	// IsFailure 判断请求错误是否计为失败，默认只统计网络错误、超时与 HTTP 5xx，
+	// 参数错误、签名错误等 4xx 响应说明 Cryptomus 可以正常响应，不计为失败。
+	// 被 ctx 取消的请求既不计为失败也不计为成功。
+	IsFailure func(err error) bool
+
+	// Fallback 返回熔断器打开时的错误，默认返回 *CircuitOpenError。
+	// 返回的错误必须包装 ErrCircuitOpen，否则 RetryPolicy 会把它当作网络错误继续重试。
+	// Fallback 在释放熔断器的锁之后调用，可以读取 CircuitState 或调用 SDK。
+	Fallback func(endpoint Endpoint, retryAt time.Time) error
+
+	// OnStateChange 在熔断器状态变化时调用，例如在打开时隐藏加密货币支付方式。
+	// 回调在发起请求的 goroutine 中同步调用，不应阻塞。
+	OnStateChange func(endpoint Endpoint, from, to CircuitState)
+}
+
+// WithCircuitBreaker 为 SDK 实例启用熔断器。
+//
+// 熔断器在每次 HTTP 请求（包括重试）前检查，打开时立即返回 Fallback 的错误，不会继续重试。
+// 熔断器打开期间可以通过 errors.Is(err, ErrCircuitOpen) 或 Cryptomus.CircuitState 判断接口是否可用。
+//
+// 示例：
+//
+//	sdk := cryptomus.New(
+//		cryptomus.WithMerchant(merchantID),
+//		cryptomus.WithPaymentToken(paymentToken),
+//		cryptomus.WithCircuitBreaker(cryptomus.CircuitBreaker{
+//			OnStateChange: func(endpoint cryptomus.Endpoint, from, to cryptomus.CircuitState) {
+//				if endpoint == cryptomus.CreateInvoiceEndpoint {
+//					checkout.SetCryptoAvailable(to != cryptomus.CircuitOpen)
+//				}
+//			},
+//		}),
+//	)
+func WithCircuitBreaker(config CircuitBreaker) Option {
+	return func(c *Cryptomus) {
+		if config.FailureThreshold <= 0 {
+			config.FailureThreshold = 5
+		}
+		if config.OpenTimeout <= 0 {
+			config.OpenTimeout = 30 * time.Second
+		}
+		if config.HalfOpenMaxRequests <= 0 {
+			config.HalfOpenMaxRequests = 1
+		}
+		if config.IsFailure == nil {
+			config.IsFailure = isOutage
+		}
+
+		c.breaker = &breaker{config: config, circuits: map[Endpoint]*circuit{}, now: time.Now}
+	}
+}
+
+// CircuitState 返回接口的熔断器状态，没有启用熔断器时始终为 CircuitClosed。
+//
+// 打开状态超过 OpenTimeout 后，下一次请求才会把状态变为半开，在此之前仍返回 CircuitOpen。
+func (sdk *Cryptomus) CircuitState(endpoint Endpoint) CircuitState {
+	if sdk.breaker == nil {
+		return CircuitClosed
+	}
+
+	b := sdk.breaker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if c, ok := b.circuits[endpoint]; ok {
+		return c.state
+	}
+
+	return CircuitClosed
+}
+
+// isOutage 判断错误是否说明 Cryptomus 不可用：网络错误、超时或 HTTP 5xx。
+func isOutage(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	return true
+}
+
+// breaker 保存每个接口的熔断器，nil 表示不启用。
+type breaker struct {
+	config CircuitBreaker
+
+	mu       sync.Mutex
+	circuits map[Endpoint]*circuit
+
+	// now 返回当前时间，测试中可以替换。
+	now func() time.Time
+}
+
+type circuit struct {
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	// probes 是半开状态下正在进行的试探请求数。
+	probes int
+	// generation 在每次状态变化时递增，用于忽略状态变化之前发出的请求的结果。
+	generation uint64
+}
+
+type stateChange struct {
+	endpoint Endpoint
+	from, to CircuitState
+}
+
+// allow 检查是否可以发送请求。允许时返回记录请求结果的函数，每次请求结束后必须调用一次。
+func (b *breaker) allow(endpoint Endpoint) (func(err error), error) {
+	if b == nil {
+		return func(error) {}, nil
+	}
+
+	now := b.now()
+
+	b.mu.Lock()
+	c, ok := b.circuits[endpoint]
+	if !ok {
+		c = &circuit{}
+		b.circuits[endpoint] = c
+	}
+
+	var changes []stateChange
+	if c.state == CircuitOpen && now.Sub(c.openedAt) >= b.config.OpenTimeout {
+		changes = append(changes, b.transition(endpoint, c, CircuitHalfOpen, now))
+	}
+
+	var (
+		rejected bool
+		retryAt  time.Time
+	)
+	switch {
+	case c.state == CircuitOpen:
+		rejected, retryAt = true, c.openedAt.Add(b.config.OpenTimeout)
+	case c.state == CircuitHalfOpen && c.probes >= b.config.HalfOpenMaxRequests:
+		rejected, retryAt = true, now
+	case c.state == CircuitHalfOpen:
+		c.probes++
+	}
+	generation := c.generation
+	b.mu.Unlock()
+
+	// OnStateChange 与 Fallback 都是用户的回调，在释放锁之后调用。
+	b.notify(changes)
+
+	if rejected {
+		return nil, b.fallback(endpoint, retryAt)
+	}
+
+	return func(err error) {
+		b.record(endpoint, generation, err)
+	}, nil
+}
+
+func (b *breaker) record(endpoint Endpoint, generation uint64, err error) {
+	now := b.now()
+	canceled := errors.Is(err, context.Canceled)
+	failed := !canceled && b.config.IsFailure(err)
+
+	b.mu.Lock()
+	c := b.circuits[endpoint]
+	if c.generation != generation {
+		b.mu.Unlock()
+		return
+	}
+
+	var changes []stateChange
+	switch {
+	case canceled:
+		if c.state == CircuitHalfOpen {
+			c.probes--
+		}
+	case c.state == CircuitClosed:
+		if !failed {
+			c.failures = 0
+			break
+		}
+		c.failures++
+		if c.failures >= b.config.FailureThreshold {
+			changes = append(changes, b.transition(endpoint, c, CircuitOpen, now))
+		}
+	case c.state == CircuitHalfOpen:
+		c.probes--
+		if failed {
+			changes = append(changes, b.transition(endpoint, c, CircuitOpen, now))
+		} else {
+			changes = append(changes, b.transition(endpoint, c, CircuitClosed, now))
+		}
+	}
+	b.mu.Unlock()
+
+	b.notify(changes)
+}
+
+func (b *breaker) transition(endpoint Endpoint, c *circuit, to CircuitState, now time.Time) stateChange {
+	change := stateChange{endpoint: endpoint, from: c.state, to: to}
+
+	c.state = to
+	c.failures = 0
+	c.probes = 0
+	c.generation++
+	if to == CircuitOpen {
+		c.openedAt = now
+	}
+
+	return change
+}
+
+func (b *breaker) fallback(endpoint Endpoint, retryAt time.Time) error {
+	if b.config.Fallback != nil {
+		return b.config.Fallback(endpoint, retryAt)
+	}
+
+	return &CircuitOpenError{Endpoint: endpoint, RetryAt: retryAt}
+}
+
+// notify 在释放锁之后调用 OnStateChange。
+func (b *breaker) notify(changes []stateChange) {
+	if b.config.OnStateChange == nil {
+		return
+	}
+
+	for _, change := range changes {
+		b.config.OnStateChange(change.endpoint, change.from, change.to)
+	}
+}
@@ -0,0 +1,204 @@
+package cryptomus
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// testClock 是可以手动推进的时钟。
+type testClock struct{ t time.Time }
+
+func (c *testClock) now() time.Time          { return c.t }
+func (c *testClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestBreaker(t *testing.T, config CircuitBreaker) (*Cryptomus, *testClock, *[]string) {
+	t.Helper()
+
+	var changes []string
+	config.OnStateChange = func(endpoint Endpoint, from, to CircuitState) {
+		changes = append(changes, from.String()+"->"+to.String())
+	}
+
+	sdk := New(WithCircuitBreaker(config))
+	clock := &testClock{t: time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)}
+	sdk.breaker.now = clock.now
+
+	return sdk, clock, &changes
+}
+
+var (
+	errOutage   = errors.New("connection refused")
+	errServer   = &APIError{StatusCode: http.StatusBadGateway}
+	errRejected = &APIError{StatusCode: http.StatusUnprocessableEntity}
+)
+
+// call 发送一次模拟请求，返回熔断器的拒绝错误。
+func call(t *testing.T, b *breaker, result error) error {
+	t.Helper()
+
+	done, err := b.allow(CreateInvoiceEndpoint)
+	if err != nil {
+		return err
+	}
+	done(result)
+
+	return nil
+}
+
+func TestCircuitBreakerOpensAfterConsecutiveFailures(t *testing.T) {
+	sdk, clock, changes := newTestBreaker(t, CircuitBreaker{FailureThreshold: 3, OpenTimeout: time.Minute})
+	b := sdk.breaker
+
+	// 成功与 4xx 响应会重置连续失败次数。
+	for _, result := range []error{errOutage, errServer, nil, errOutage, errRejected, errOutage, errOutage} {
+		if err := call(t, b, result); err != nil {
+			t.Fatalf("request rejected while closed: %v", err)
+		}
+	}
+	if got := sdk.CircuitState(CreateInvoiceEndpoint); got != CircuitClosed {
+		t.Fatalf("state = %s, want closed", got)
+	}
+
+	if err := call(t, b, errServer); err != nil {
+		t.Fatal(err)
+	}
+	if got := sdk.CircuitState(CreateInvoiceEndpoint); got != CircuitOpen {
+		t.Fatalf("state = %s, want open", got)
+	}
+
+	err := call(t, b, nil)
+	var openErr *CircuitOpenError
+	if !errors.Is(err, ErrCircuitOpen) || !errors.As(err, &openErr) {
+		t.Fatalf("error = %v, want *CircuitOpenError", err)
+	}
+	if want := clock.t.Add(time.Minute); !openErr.RetryAt.Equal(want) || openErr.Endpoint != CreateInvoiceEndpoint {
+		t.Errorf("CircuitOpenError = %+v, want RetryAt %s", openErr, want)
+	}
+
+	// 其他接口不受影响。
+	if done, err := b.allow(PaymentInformationEndpoint); err != nil {
+		t.Errorf("other endpoint rejected: %v", err)
+	} else {
+		done(nil)
+	}
+
+	if got := *changes; len(got) != 1 || got[0] != "closed->open" {
+		t.Errorf("state changes = %v", got)
+	}
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	sdk, clock, changes := newTestBreaker(t, CircuitBreaker{FailureThreshold: 1, OpenTimeout: time.Minute, HalfOpenMaxRequests: 2})
+	b := sdk.breaker
+
+	_ = call(t, b, errOutage)
+	clock.advance(time.Minute - time.Second)
+	if err := call(t, b, nil); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("error before OpenTimeout = %v, want ErrCircuitOpen", err)
+	}
+
+	clock.advance(time.Second)
+	first, err := b.allow(CreateInvoiceEndpoint)
+	if err != nil {
+		t.Fatalf("first probe rejected: %v", err)
+	}
+	second, err := b.allow(CreateInvoiceEndpoint)
+	if err != nil {
+		t.Fatalf("second probe rejected: %v", err)
+	}
+	if _, err := b.allow(CreateInvoiceEndpoint); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("third probe error = %v, want ErrCircuitOpen", err)
+	}
+	if got := sdk.CircuitState(CreateInvoiceEndpoint); got != CircuitHalfOpen {
+		t.Fatalf("state = %s, want half-open", got)
+	}
+
+	// 取消的试探请求释放名额，不改变状态。
+	first(context.Canceled)
+	third, err := b.allow(CreateInvoiceEndpoint)
+	if err != nil {
+		t.Fatalf("probe after a canceled probe rejected: %v", err)
+	}
+
+	// 一个试探失败后重新打开，之后到达的旧试探结果被忽略。
+	second(errOutage)
+	if got := sdk.CircuitState(CreateInvoiceEndpoint); got != CircuitOpen {
+		t.Fatalf("state after a failed probe = %s, want open", got)
+	}
+	third(nil)
+	if got := sdk.CircuitState(CreateInvoiceEndpoint); got != CircuitOpen {
+		t.Fatalf("state after a stale successful probe = %s, want open", got)
+	}
+
+	clock.advance(time.Minute)
+	if err := call(t, b, nil); err != nil {
+		t.Fatalf("probe rejected: %v", err)
+	}
+	if got := sdk.CircuitState(CreateInvoiceEndpoint); got != CircuitClosed {
+		t.Fatalf("state after a successful probe = %s, want closed", got)
+	}
+
+	want := []string{"closed->open", "open->half-open", "half-open->open", "open->half-open", "half-open->closed"}
+	if got := *changes; len(got) != len(want) {
+		t.Errorf("state changes = %v, want %v", got, want)
+	} else {
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("state changes = %v, want %v", got, want)
+				break
+			}
+		}
+	}
+}
+
+func TestCircuitBreakerIgnoresStaleResults(t *testing.T) {
+	sdk, _, _ := newTestBreaker(t, CircuitBreaker{FailureThreshold: 2, OpenTimeout: time.Minute})
+	b := sdk.breaker
+
+	// slow 在熔断器打开之前发出，打开之后才返回成功，不能关闭熔断器。
+	slow, err := b.allow(CreateInvoiceEndpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = call(t, b, errOutage)
+	_ = call(t, b, errOutage)
+	slow(nil)
+
+	if got := sdk.CircuitState(CreateInvoiceEndpoint); got != CircuitOpen {
+		t.Errorf("state = %s, want open", got)
+	}
+}
+
+func TestCircuitBreakerFallbackCanReadState(t *testing.T) {
+	var sdk *Cryptomus
+	var state CircuitState
+	sdk, _, _ = newTestBreaker(t, CircuitBreaker{
+		FailureThreshold: 1,
+		OpenTimeout:      time.Minute,
+		Fallback: func(endpoint Endpoint, retryAt time.Time) error {
+			// 在持有锁时调用会死锁。
+			state = sdk.CircuitState(endpoint)
+			return ErrCircuitOpen
+		},
+	})
+
+	_ = call(t, sdk.breaker, errOutage)
+
+	done := make(chan error, 1)
+	go func() { done <- call(t, sdk.breaker, nil) }()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrCircuitOpen) {
+			t.Errorf("error = %v, want ErrCircuitOpen", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Fallback deadlocked")
+	}
+	if state != CircuitOpen {
+		t.Errorf("state seen by Fallback = %s, want open", state)
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	cryptomus "github.com/difyz9/cryptomus-sdk-go"
+)
+
+var balanceCommand = &command{
+	name:  "balance",
+	usage: "show the merchant and personal balances",
+	run:   balance,
+}
+
+var ratesCommand = &command{
+	name:  "rates",
+	usage: "show the exchange rates of a currency, e.g. 'rates USDT'",
+	run:   rates,
+}
+
+var discountCommand = &command{
+	name:  "discount",
+	usage: "manage payment method discounts",
+	sub: []*command{
+		{name: "list", usage: "list discounts", run: discountList},
+		{name: "set", usage: "set the discount of a payment method", run: discountSet},
+	},
+}
+
+// balanceRow 是余额表格的一行。
+type balanceRow struct {
+	Wallet   string           `json:"wallet"`
+	Currency string           `json:"currency_code"`
+	Balance  cryptomus.Amount `json:"balance"`
+	UUID     string           `json:"uuid"`
+}
+
+func balance(ctx context.Context, app *app, args []string) error {
+	var nonZero bool
+
+	fs := app.flags("cryptomus balance")
+	fs.BoolVar(&nonZero, "non-zero", false, "hide empty balances")
+	if err := app.parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	sdk, err := app.client()
+	if err != nil {
+		return err
+	}
+
+	result, err := sdk.BalanceWithContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	rows := []balanceRow{}
+	add := func(row balanceRow) {
+		if !nonZero || row.Balance.Sign() != 0 {
+			rows = append(rows, row)
+		}
+	}
+	for _, item := range result.Result {
+		for _, m := range item.Balance.Merchant {
+			add(balanceRow{Wallet: "merchant", Currency: m.CurrencyCode, Balance: m.Balance, UUID: m.UUID})
+		}
+		for _, u := range item.Balance.User {
+			add(balanceRow{Wallet: "user", Currency: u.CurrencyCode, Balance: u.Balance, UUID: u.UUID})
+		}
+	}
+
+	return app.print(rows)
+}
+
+func rates(ctx context.Context, app *app, args []string) error {
+	var currency string
+
+	fs := app.flags("cryptomus rates")
+	fs.StringVar(&currency, "currency", "", "source `currency`, can also be given as the argument")
+	if err := app.parse(fs, args, 1); err != nil {
+		return err
+	}
+	if fs.NArg() == 1 {
+		currency = fs.Arg(0)
+	}
+	if currency == "" {
+		fmt.Fprintf(fs.Output(), "%s: missing currency\n", fs.Name())
+		fs.Usage()
+		return errUsage
+	}
+
+	sdk, err := app.client()
+	if err != nil {
+		return err
+	}
+
+	result, err := sdk.ExchangeRateListWithContext(ctx, strings.ToUpper(currency))
+	if err != nil {
+		return err
+	}
+
+	return app.print(result.Result)
+}
+
+func discountList(ctx context.Context, app *app, args []string) error {
+	fs := app.flags("cryptomus discount list")
+	if err := app.parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	sdk, err := app.client()
+	if err != nil {
+		return err
+	}
+
+	result, err := sdk.ListOfDiscountWithContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	return app.print(result.Result)
+}
+
+func discountSet(ctx context.Context, app *app, args []string) error {
+	var payload cryptomus.SetDiscountToPaymentMethodRequest
+
+	fs := app.flags("cryptomus discount set")
+	fs.StringVar(&payload.Currency, "currency", "", "payment method `currency` (required)")
+	fs.StringVar(&payload.Network, "network", "", "payment method `network` (required)")
+	fs.IntVar(&payload.Discount, "percent", 0, "discount in `percent`, negative for an extra charge (required)")
+	if err := app.parse(fs, args, 0); err != nil {
+		return err
+	}
+	if err := requireFlags(fs, "currency", "network", "percent"); err != nil {
+		return err
+	}
+
+	sdk, err := app.client()
+	if err != nil {
+		return err
+	}
+
+	result, err := sdk.SetDiscountToPaymentMethodWithContext(ctx, payload)
+	if err != nil {
+		return err
+	}
+
+	return app.print(result.Result)
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// 环境变量，设置后覆盖配置文件中的同名字段。
+const (
+	envConfig       = "CRYPTOMUS_CONFIG"
+	envProfile      = "CRYPTOMUS_PROFILE"
+	envMerchant     = "CRYPTOMUS_MERCHANT"
+	envPaymentToken = "CRYPTOMUS_PAYMENT_TOKEN"
+	envPayoutToken  = "CRYPTOMUS_PAYOUT_TOKEN"
+	envBaseURL      = "CRYPTOMUS_BASE_URL"
+)
+
+// defaultProfile 是配置文件没有指定 default_profile 时使用的 profile。
+const defaultProfile = "default"
+
+// credentials 是一个 profile 中的商户凭证。
+type credentials struct {
+	Merchant     string `json:"merchant"`
+	PaymentToken string `json:"payment_token"`
+	PayoutToken  string `json:"payout_token"`
+	BaseURL      string `json:"base_url,omitempty"`
+}
+
+// config 是配置文件的内容，例如：
+//
+//	{
+//		"default_profile": "prod",
+//		"profiles": {
+//			"prod": {"merchant": "...", "payment_token": "...", "payout_token": "..."},
+//			"test": {"merchant": "...", "payment_token": "...", "base_url": "http://localhost:8080"}
+//		}
+//	}
+type config struct {
+	DefaultProfile string                 `json:"default_profile"`
+	Profiles       map[string]credentials `json:"profiles"`
+}
+
+// configPath 返回配置文件路径：-config 参数、CRYPTOMUS_CONFIG，或用户配置目录下的 cryptomus/config.json。
+func configPath(path string) (string, error) {
+	if path != "" {
+		return path, nil
+	}
+
+	if path := os.Getenv(envConfig); path != "" {
+		return path, nil
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "cryptomus", "config.json"), nil
+}
+
+// loadCredentials 读取 profile 的凭证，再用环境变量覆盖。
+//
+// 配置文件不存在时只使用环境变量；显式指定的 profile 不存在时返回错误。
+func loadCredentials(path, profile string) (credentials, error) {
+	var creds credentials
+
+	cfg, path, err := loadConfig(path)
+	if err != nil {
+		return creds, err
+	}
+
+	explicit := true
+	if profile == "" {
+		profile = os.Getenv(envProfile)
+	}
+	if profile == "" {
+		profile, explicit = cfg.DefaultProfile, cfg.DefaultProfile != ""
+	}
+	if profile == "" {
+		profile = defaultProfile
+	}
+
+	creds, ok := cfg.Profiles[profile]
+	if !ok && explicit {
+		return creds, fmt.Errorf("cryptomus: profile %q not found in %s", profile, path)
+	}
+
+	override(&creds.Merchant, envMerchant)
+	override(&creds.PaymentToken, envPaymentToken)
+	override(&creds.PayoutToken, envPayoutToken)
+	override(&creds.BaseURL, envBaseURL)
+
+	return creds, nil
+}
+
+// loadConfig 读取配置文件，文件不存在时返回空配置。
+func loadConfig(path string) (config, string, error) {
+	var cfg config
+
+	path, err := configPath(path)
+	if err != nil {
+		return cfg, path, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, path, nil
+	}
+	if err != nil {
+		return cfg, path, err
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, path, fmt.Errorf("cryptomus: invalid config file %s: %w", path, err)
+	}
+
+	return cfg, path, nil
+}
+
+func override(field *string, env string) {
+	if value := os.Getenv(env); value != "" {
+		*field = value
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadCredentials(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "config.json")
+	writeConfig(t, config, `{
+		"default_profile": "prod",
+		"profiles": {
+			"prod": {"merchant": "prod-merchant", "payment_token": "prod-payment", "payout_token": "prod-payout"},
+			"test": {"merchant": "test-merchant", "payment_token": "test-payment", "base_url": "http://localhost:8080"}
+		}
+	}`)
+	noDefault := filepath.Join(dir, "no-default.json")
+	writeConfig(t, noDefault, `{"profiles": {"default": {"merchant": "default-merchant"}}}`)
+
+	tests := []struct {
+		name    string
+		path    string
+		profile string
+		env     map[string]string
+		want    credentials
+		wantErr bool
+	}{
+		{
+			name: "default_profile",
+			path: config,
+			want: credentials{Merchant: "prod-merchant", PaymentToken: "prod-payment", PayoutToken: "prod-payout"},
+		},
+		{
+			name: "profile from the environment",
+			path: config,
+			env:  map[string]string{envProfile: "test"},
+			want: credentials{Merchant: "test-merchant", PaymentToken: "test-payment", BaseURL: "http://localhost:8080"},
+		},
+		{
+			name:    "profile flag wins over the environment",
+			path:    config,
+			profile: "prod",
+			env:     map[string]string{envProfile: "test"},
+			want:    credentials{Merchant: "prod-merchant", PaymentToken: "prod-payment", PayoutToken: "prod-payout"},
+		},
+		{
+			name:    "environment overrides profile fields",
+			path:    config,
+			profile: "test",
+			env:     map[string]string{envPaymentToken: "env-payment", envBaseURL: "http://env"},
+			want:    credentials{Merchant: "test-merchant", PaymentToken: "env-payment", BaseURL: "http://env"},
+		},
+		{
+			name: "config from the environment",
+			env:  map[string]string{envConfig: noDefault},
+			want: credentials{Merchant: "default-merchant"},
+		},
+		{
+			name: "missing config file",
+			path: filepath.Join(dir, "missing.json"),
+			env:  map[string]string{envMerchant: "env-merchant", envPayoutToken: "env-payout"},
+			want: credentials{Merchant: "env-merchant", PayoutToken: "env-payout"},
+		},
+		{name: "unknown profile", path: config, profile: "staging", wantErr: true},
+		{name: "unknown profile from the environment", path: config, env: map[string]string{envProfile: "staging"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, env := range []string{envConfig, envProfile, envMerchant, envPaymentToken, envPayoutToken, envBaseURL} {
+				t.Setenv(env, tt.env[env])
+			}
+
+			got, err := loadCredentials(tt.path, tt.profile)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("credentials = %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("credentials = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	cryptomus "github.com/difyz9/cryptomus-sdk-go"
+)
+
+var invoiceCommand = &command{
+	name:  "invoice",
+	usage: "create, inspect and refund invoices",
+	sub: []*command{
+		{name: "create", usage: "create an invoice", run: invoiceCreate},
+		{name: "info", usage: "show an invoice by -uuid or -order-id", run: invoiceInfo},
+		{name: "history", usage: "list invoices", run: invoiceHistory},
+		{name: "refund", usage: "refund a paid invoice", run: invoiceRefund},
+		{name: "qr", usage: "generate the QR code of an invoice", run: invoiceQR},
+		{name: "services", usage: "list payment services", run: invoiceServices},
+	},
+}
+
+func invoiceCreate(ctx context.Context, app *app, args []string) error {
+	var payload cryptomus.CreateInvoiceRequest
+
+	fs := app.flags("cryptomus invoice create")
+	amountVar(fs, &payload.Amount, "amount", "invoice `amount` (required)")
+	fs.StringVar(&payload.Currency, "currency", "", "invoice `currency`, e.g. USD or USDT (required)")
+	fs.StringVar(&payload.OrderID, "order-id", "", "merchant order `id` (required)")
+	fs.StringVar(&payload.Network, "network", "", "blockchain `network`, e.g. tron")
+	fs.StringVar(&payload.ToCurrency, "to-currency", "", "convert the invoice to this crypto `currency`")
+	fs.StringVar(&payload.URLCallback, "url-callback", "", "webhook `url`")
+	fs.StringVar(&payload.URLReturn, "url-return", "", "`url` of the back button on the payment page")
+	fs.StringVar(&payload.URLSuccess, "url-success", "", "`url` to redirect to after a successful payment")
+	fs.IntVar(&payload.Lifetime, "lifetime", 0, "invoice lifetime in `seconds`")
+	fs.IntVar(&payload.Subtract, "subtract", 0, "`percent` of the commission charged to the customer")
+	fs.IntVar(&payload.AccuracyPaymentPercent, "accuracy", 0, "accepted underpayment in `percent`")
+	fs.IntVar(&payload.DiscountPercent, "discount-percent", 0, "discount or extra charge in `percent`")
+	fs.BoolVar(&payload.IsPaymentMultiple, "multiple", false, "allow paying the remainder after an underpayment")
+	fs.StringVar(&payload.AdditionalData, "additional-data", "", "additional `data` returned in webhooks")
+	if err := app.parse(fs, args, 0); err != nil {
+		return err
+	}
+	if err := requireFlags(fs, "amount", "currency", "order-id"); err != nil {
+		return err
+	}
+
+	sdk, err := app.client()
+	if err != nil {
+		return err
+	}
+
+	result, err := sdk.CreateInvoiceWithContext(ctx, &payload)
+	if err != nil {
+		return err
+	}
+
+	return app.print(result.Result)
+}
+
+func invoiceInfo(ctx context.Context, app *app, args []string) error {
+	var payload cryptomus.PaymentInformationRequest
+	var wait bool
+
+	fs := app.flags("cryptomus invoice info")
+	identify(fs, &payload.UUID, &payload.OrderID)
+	fs.BoolVar(&wait, "wait", false, "wait until the invoice reaches a final status (combine with -timeout)")
+	if err := app.parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	sdk, err := app.client()
+	if err != nil {
+		return err
+	}
+
+	if wait {
+		id := payload.UUID
+		if id == "" {
+			id = payload.OrderID
+		}
+
+		payment, err := sdk.WaitForPayment(ctx, id, cryptomus.WatchOptions{})
+		if err != nil {
+			return err
+		}
+
+		return app.print(payment)
+	}
+
+	result, err := sdk.PaymentInformationWithContext(ctx, &payload)
+	if err != nil {
+		return err
+	}
+
+	return app.print(result.Result.CreateInvoiceData)
+}
+
+func invoiceHistory(ctx context.Context, app *app, args []string) error {
+	var h history
+
+	fs := app.flags("cryptomus invoice history")
+	h.flags(fs)
+	if err := app.parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	from, to, err := h.dates()
+	if err != nil {
+		return err
+	}
+
+	sdk, err := app.client()
+	if err != nil {
+		return err
+	}
+
+	if h.all {
+		items := []cryptomus.CreateInvoiceData{}
+		for payment, err := range sdk.AllPayments(ctx, from, to, h.pageOptions()...) {
+			if err != nil {
+				return err
+			}
+			items = append(items, payment)
+		}
+
+		return app.print(items)
+	}
+
+	result, err := sdk.PaymentHistoryWithContext(ctx, &cryptomus.PaymentHistoryRequest{
+		DateFrom: h.from,
+		DateTo:   h.to,
+		Cursor:   h.cursor,
+	})
+	if err != nil {
+		return err
+	}
+
+	app.printCursor(result.Result.Paginate)
+	return app.print(result.Result.Items)
+}
+
+func invoiceRefund(ctx context.Context, app *app, args []string) error {
+	var payload cryptomus.RefundRequest
+
+	fs := app.flags("cryptomus invoice refund")
+	identify(fs, &payload.UUID, &payload.OrderID)
+	fs.StringVar(&payload.Address, "address", "", "refund `address` (required)")
+	fs.BoolVar(&payload.IsSubtract, "subtract", false, "subtract the commission from the merchant balance instead of the refund")
+	if err := app.parse(fs, args, 0); err != nil {
+		return err
+	}
+	if err := requireFlags(fs, "address"); err != nil {
+		return err
+	}
+
+	sdk, err := app.client()
+	if err != nil {
+		return err
+	}
+
+	if _, err := sdk.RefundWithContext(ctx, &payload); err != nil {
+		return err
+	}
+
+	return app.print(done{OK: true})
+}
+
+func invoiceQR(ctx context.Context, app *app, args []string) error {
+	var payload cryptomus.GenerateQRCodeInvoiceRequest
+	var out string
+
+	fs := app.flags("cryptomus invoice qr")
+	fs.StringVar(&payload.MerchantPaymentUUID, "uuid", "", "invoice `uuid` (required)")
+	fs.StringVar(&out, "out", "", "write the PNG image to `file` instead of printing the data URI")
+	if err := app.parse(fs, args, 0); err != nil {
+		return err
+	}
+	if err := requireFlags(fs, "uuid"); err != nil {
+		return err
+	}
+
+	sdk, err := app.client()
+	if err != nil {
+		return err
+	}
+
+	result, err := sdk.GenerateQRCodeInvoiceWithContext(ctx, &payload)
+	if err != nil {
+		return err
+	}
+
+	return app.writeQR(result.Result.Image, out)
+}
+
+func invoiceServices(ctx context.Context, app *app, args []string) error {
+	fs := app.flags("cryptomus invoice services")
+	if err := app.parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	sdk, err := app.client()
+	if err != nil {
+		return err
+	}
+
+	result, err := sdk.PaymentListOfServicesWithContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	return app.print(result.Result)
+}
+
+// writeQR 输出二维码。out 不为空时把 data URI 中的 PNG 图片写入文件。
+func (a *app) writeQR(image, out string) error {
+	if out == "" {
+		return a.print(map[string]string{"image": image})
+	}
+
+	_, data, ok := strings.Cut(image, ";base64,")
+	if !ok {
+		return fmt.Errorf("cryptomus: unexpected QR code image %.32q", image)
+	}
+
+	png, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return fmt.Errorf("cryptomus: decode QR code image: %w", err)
+	}
+
+	return os.WriteFile(out, png, 0o644)
+}
+
+// history 是 history 与 list 命令共用的分页参数。
+type history struct {
+	from, to string
+	cursor   string
+	all      bool
+}
+
+func (h *history) flags(fs *flag.FlagSet) {
+	fs.StringVar(&h.from, "from", "", "start `date`, format 2006-01-02 15:04:05")
+	fs.StringVar(&h.to, "to", "", "end `date`, format 2006-01-02 15:04:05")
+	fs.StringVar(&h.cursor, "cursor", "", "page `cursor` printed by the previous page")
+	fs.BoolVar(&h.all, "all", false, "follow the cursors and print every page")
+}
+
+// dates 解析 -from 与 -to，未设置时返回零值。
+func (h *history) dates() (from, to time.Time, err error) {
+	const layout = "2006-01-02 15:04:05"
+
+	if h.from != "" {
+		if from, err = time.Parse(layout, h.from); err != nil {
+			return from, to, fmt.Errorf("cryptomus: invalid -from: %w", err)
+		}
+	}
+	if h.to != "" {
+		if to, err = time.Parse(layout, h.to); err != nil {
+			return from, to, fmt.Errorf("cryptomus: invalid -to: %w", err)
+		}
+	}
+
+	return from, to, nil
+}
+
+func (h *history) pageOptions() []cryptomus.PageOption {
+	if h.cursor == "" {
+		return nil
+	}
+
+	return []cryptomus.PageOption{cryptomus.PageCursor(h.cursor)}
+}
+
+// printCursor 在标准错误输出下一页的游标，不影响标准输出中的数据。
+func (a *app) printCursor(paginate *cryptomus.Pagination) {
+	if paginate != nil && paginate.NextCursor != "" {
+		fmt.Fprintf(a.stderr, "next page: -cursor %s\n", paginate.NextCursor)
+	}
+}
@@ -0,0 +1,314 @@
+// Command cryptomus 是 Cryptomus SDK 的命令行工具，用于查询发票、余额，创建提现等日常操作。
+//
+// 凭证从配置文件（默认为用户配置目录下的 cryptomus/config.json）中的 profile 读取，
+// 环境变量 CRYPTOMUS_MERCHANT、CRYPTOMUS_PAYMENT_TOKEN、CRYPTOMUS_PAYOUT_TOKEN 与 CRYPTOMUS_BASE_URL 会覆盖 profile 中的值。
+// 输出格式通过 -o 选择：json（默认）、table 或 csv。
+//
+// 用法：
+//
+//	cryptomus [全局参数] <命令> [子命令] [参数]
+//
+// 示例：
+//
+//	export CRYPTOMUS_MERCHANT=...
+//	export CRYPTOMUS_PAYMENT_TOKEN=...
+//	cryptomus invoice info -order-id 123456
+//	cryptomus balance -o table
+//	cryptomus -profile prod payout history -all -o csv > payouts.csv
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	cryptomus "github.com/difyz9/cryptomus-sdk-go"
+)
+
+// command 是一个命令或命令组，命令组只包含子命令。
+type command struct {
+	name  string
+	usage string
+	run   func(ctx context.Context, app *app, args []string) error
+	sub   []*command
+}
+
+// commands 是所有顶层命令。
+var commands = []*command{
+	invoiceCommand,
+	walletCommand,
+	payoutCommand,
+	recurringCommand,
+	balanceCommand,
+	ratesCommand,
+	discountCommand,
+	webhookCommand,
+}
+
+// errUsage 表示参数错误，帮助信息已经输出。
+var errUsage = errors.New("usage error")
+
+// app 保存全局参数，并在命令需要时创建 SDK 实例。
+type app struct {
+	profile string
+	config  string
+	output  string
+	baseURL string
+	timeout time.Duration
+	debug   bool
+
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func main() {
+	app := &app{stdout: os.Stdout, stderr: os.Stderr}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	err := app.run(ctx, os.Args[1:])
+	stop()
+
+	switch {
+	case err == nil:
+	case errors.Is(err, errUsage), errors.Is(err, flag.ErrHelp):
+		os.Exit(2)
+	default:
+		fmt.Fprintln(app.stderr, err)
+		os.Exit(1)
+	}
+}
+
+func (a *app) run(ctx context.Context, args []string) error {
+	fs := a.flags("cryptomus")
+	fs.Usage = func() { a.usage(nil) }
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	args = fs.Args()
+	if len(args) == 0 || args[0] == "help" {
+		a.usage(nil)
+		return errUsage
+	}
+
+	cmd, args, err := a.lookup(args)
+	if err != nil {
+		return err
+	}
+
+	if a.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.timeout)
+		defer cancel()
+	}
+
+	return cmd.run(ctx, a, args)
+}
+
+// lookup 根据参数找到要执行的命令，返回剩余的参数。
+func (a *app) lookup(args []string) (*command, []string, error) {
+	cmd := find(commands, args[0])
+	if cmd == nil {
+		fmt.Fprintf(a.stderr, "cryptomus: unknown command %q\n\n", args[0])
+		a.usage(nil)
+		return nil, nil, errUsage
+	}
+	args = args[1:]
+
+	if cmd.sub == nil {
+		return cmd, args, nil
+	}
+
+	if len(args) == 0 || args[0] == "help" || strings.HasPrefix(args[0], "-") {
+		a.usage(cmd)
+		return nil, nil, errUsage
+	}
+
+	sub := find(cmd.sub, args[0])
+	if sub == nil {
+		fmt.Fprintf(a.stderr, "cryptomus: unknown command %q\n\n", cmd.name+" "+args[0])
+		a.usage(cmd)
+		return nil, nil, errUsage
+	}
+
+	return sub, args[1:], nil
+}
+
+func find(commands []*command, name string) *command {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd
+		}
+	}
+
+	return nil
+}
+
+// usage 输出命令列表，group 不为 nil 时只输出该命令组的子命令。
+func (a *app) usage(group *command) {
+	w := a.stderr
+
+	list, prefix := commands, ""
+	if group != nil {
+		list, prefix = group.sub, group.name+" "
+		fmt.Fprintf(w, "Usage: cryptomus [global flags] %s<command> [flags]\n\n", prefix)
+	} else {
+		fmt.Fprint(w, "Usage: cryptomus [global flags] <command> [subcommand] [flags]\n\n")
+	}
+
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range list {
+		fmt.Fprintf(w, "  %-28s %s\n", prefix+cmd.name, cmd.usage)
+	}
+
+	fmt.Fprint(w, "\nGlobal flags:\n")
+	fs := a.flags("cryptomus")
+	fs.SetOutput(w)
+	fs.PrintDefaults()
+
+	fmt.Fprint(w, "\nRun 'cryptomus <command> <subcommand> -h' for the flags of a command.\n")
+}
+
+// flags 创建命令的参数集合，全局参数在每个命令中都可以使用。
+func (a *app) flags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+
+	fs.StringVar(&a.profile, "profile", a.profile, "config profile `name` (default $CRYPTOMUS_PROFILE or the config's default_profile)")
+	fs.StringVar(&a.config, "config", a.config, "config `file` (default $CRYPTOMUS_CONFIG or <user config dir>/cryptomus/config.json)")
+	fs.StringVar(&a.output, "o", a.outputOrDefault(), "output `format`: json, table or csv")
+	fs.StringVar(&a.baseURL, "base-url", a.baseURL, "API base `url` (default $CRYPTOMUS_BASE_URL or "+cryptomus.DefaultBaseURL+")")
+	fs.DurationVar(&a.timeout, "timeout", a.timeout, "abort the command after `duration` (0 means no limit)")
+	fs.BoolVar(&a.debug, "debug", a.debug, "log requests and redacted bodies to stderr")
+
+	return fs
+}
+
+func (a *app) outputOrDefault() string {
+	if a.output == "" {
+		return formatJSON
+	}
+
+	return a.output
+}
+
+// parse 解析命令的参数，命令不接受位置参数时 maxArgs 为 0。
+//
+// 与 flag 包不同，位置参数之后的参数仍然会被解析，例如 rates USDT -o table；"--" 之后的参数都是位置参数。
+// 解析后 fs.Args() 返回所有位置参数。
+func (a *app) parse(fs *flag.FlagSet, args []string, maxArgs int) error {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if n := len(args) - fs.NArg(); n > 0 && args[n-1] == "--" {
+			positional = append(positional, fs.Args()...)
+			break
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+
+	// 以 "--" 开头解析，fs.Args() 返回位置参数，以 - 开头的位置参数不会被当作参数。
+	if err := fs.Parse(append([]string{"--"}, positional...)); err != nil {
+		return err
+	}
+
+	if fs.NArg() > maxArgs {
+		fmt.Fprintf(a.stderr, "%s: unexpected argument %q\n", fs.Name(), fs.Arg(maxArgs))
+		fs.Usage()
+		return errUsage
+	}
+
+	return nil
+}
+
+// client 根据配置创建 SDK 实例。
+func (a *app) client() (*cryptomus.Cryptomus, error) {
+	creds, err := loadCredentials(a.config, a.profile)
+	if err != nil {
+		return nil, err
+	}
+
+	if creds.Merchant == "" {
+		return nil, fmt.Errorf("cryptomus: no merchant configured, set %s or add a profile to the config file", envMerchant)
+	}
+
+	options := []cryptomus.Option{
+		cryptomus.WithMerchant(creds.Merchant),
+		cryptomus.WithPaymentToken(creds.PaymentToken),
+		cryptomus.WithPayoutToken(creds.PayoutToken),
+	}
+
+	baseURL := creds.BaseURL
+	if a.baseURL != "" {
+		baseURL = a.baseURL
+	}
+	if baseURL != "" {
+		options = append(options, cryptomus.WithBaseURL(baseURL))
+	}
+
+	if a.debug {
+		options = append(options, cryptomus.WithLogger(slog.New(slog.NewTextHandler(a.stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))))
+	}
+
+	return cryptomus.New(options...), nil
+}
+
+// print 按 -o 指定的格式输出结果。
+func (a *app) print(v any) error {
+	return render(a.stdout, a.outputOrDefault(), v)
+}
+
+// requireFlags 检查必填参数是否已经设置。
+func requireFlags(fs *flag.FlagSet, names ...string) error {
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	var missing []string
+	for _, name := range names {
+		if !set[name] {
+			missing = append(missing, "-"+name)
+		}
+	}
+
+	if len(missing) > 0 {
+		fmt.Fprintf(fs.Output(), "%s: missing required flags: %s\n", fs.Name(), strings.Join(missing, ", "))
+		fs.Usage()
+		return errUsage
+	}
+
+	return nil
+}
+
+// amountVar 定义金额参数。
+func amountVar(fs *flag.FlagSet, p *cryptomus.Amount, name, usage string) {
+	fs.Func(name, usage, func(s string) error {
+		amount, err := cryptomus.ParseAmount(s)
+		if err != nil {
+			return err
+		}
+
+		*p = amount
+		return nil
+	})
+}
+
+// identify 定义 -uuid 与 -order-id 参数，大多数查询与操作接口通过其中之一识别对象。
+func identify(fs *flag.FlagSet, uuid, orderID *string) {
+	fs.StringVar(uuid, "uuid", "", "object `uuid`")
+	fs.StringVar(orderID, "order-id", "", "merchant order `id` (alternative to -uuid)")
+}
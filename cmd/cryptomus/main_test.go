@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	cryptomus "github.com/difyz9/cryptomus-sdk-go"
+	"github.com/difyz9/cryptomus-sdk-go/cryptomustest"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		maxArgs    int
+		positional []string
+		output     string
+		currency   string
+		err        error
+	}{
+		{name: "flags only", args: []string{"-currency", "USDT", "-o", "table"}, output: "table", currency: "USDT"},
+		{name: "flags after argument", args: []string{"USDT", "-o", "csv"}, maxArgs: 1, positional: []string{"USDT"}, output: "csv"},
+		{name: "arguments between flags", args: []string{"-o", "csv", "a", "-currency", "BTC", "b"}, maxArgs: 2, positional: []string{"a", "b"}, output: "csv", currency: "BTC"},
+		{name: "dash argument after terminator", args: []string{"-o", "table", "--", "-5"}, maxArgs: 1, positional: []string{"-5"}, output: "table"},
+		{name: "flags after terminator", args: []string{"a", "--", "-o", "csv"}, maxArgs: 3, positional: []string{"a", "-o", "csv"}, output: "json"},
+		{name: "repeated terminator", args: []string{"--", "--", "-x"}, maxArgs: 2, positional: []string{"--", "-x"}, output: "json"},
+		{name: "too many arguments", args: []string{"a", "b"}, maxArgs: 1, err: errUsage},
+		{name: "unknown flag", args: []string{"-x"}, err: errors.New("flag provided but not defined: -x")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stderr bytes.Buffer
+			a := &app{stderr: &stderr}
+
+			var currency string
+			fs := a.flags("cryptomus test")
+			fs.StringVar(&currency, "currency", "", "")
+
+			err := a.parse(fs, tt.args, tt.maxArgs)
+			if tt.err != nil {
+				if err == nil || err.Error() != tt.err.Error() {
+					t.Fatalf("err = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !slices.Equal(fs.Args(), tt.positional) || a.outputOrDefault() != tt.output || currency != tt.currency {
+				t.Errorf("args = %q, -o = %s, -currency = %q", fs.Args(), a.outputOrDefault(), currency)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	srv := cryptomustest.NewServer(cryptomustest.WithCredentials("cli-merchant", "cli-payment", "cli-payout"))
+	defer srv.Close()
+	srv.SetBalance("USDT", cryptomus.MustParseAmount("12.5"))
+
+	// profile 中的 base_url 指向模拟服务器，环境变量提供凭证。
+	config := filepath.Join(t.TempDir(), "config.json")
+	writeConfig(t, config, `{"profiles":{"test":{"merchant":"wrong","payment_token":"wrong","base_url":"`+srv.URL+`"}}}`)
+	t.Setenv(envConfig, config)
+	t.Setenv(envProfile, "test")
+	t.Setenv(envMerchant, "cli-merchant")
+	t.Setenv(envPaymentToken, "cli-payment")
+	t.Setenv(envPayoutToken, "")
+	t.Setenv(envBaseURL, "")
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			name: "csv",
+			args: []string{"balance", "-non-zero", "-o", "csv"},
+			want: "wallet,currency_code,balance,uuid\nmerchant,USDT,12.5,cli-merchant\n",
+		},
+		{
+			name: "global output flag",
+			args: []string{"-o", "table", "balance", "-non-zero"},
+			want: "WALLET    CURRENCY_CODE  BALANCE  UUID\nmerchant  USDT           12.5     cli-merchant\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			a := &app{stdout: &stdout, stderr: &stderr}
+
+			if err := a.run(context.Background(), tt.args); err != nil {
+				t.Fatalf("run: %v\n%s", err, stderr.String())
+			}
+			if stdout.String() != tt.want {
+				t.Errorf("output:\n%s\nwant:\n%s", stdout.String(), tt.want)
+			}
+		})
+	}
+
+	t.Run("unknown command", func(t *testing.T) {
+		var stderr bytes.Buffer
+		a := &app{stdout: &bytes.Buffer{}, stderr: &stderr}
+
+		if err := a.run(context.Background(), []string{"invoice", "unknown"}); !errors.Is(err, errUsage) {
+			t.Errorf("err = %v, want errUsage", err)
+		}
+		if !strings.Contains(stderr.String(), `unknown command "invoice unknown"`) {
+			t.Errorf("stderr = %s", stderr.String())
+		}
+	})
+
+	t.Run("help", func(t *testing.T) {
+		a := &app{stdout: &bytes.Buffer{}, stderr: &bytes.Buffer{}}
+
+		if err := a.run(context.Background(), []string{"balance", "-h"}); !errors.Is(err, flag.ErrHelp) {
+			t.Errorf("err = %v, want flag.ErrHelp", err)
+		}
+	})
+}
+
+func writeConfig(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// 输出格式。
+const (
+	formatJSON  = "json"
+	formatTable = "table"
+	formatCSV   = "csv"
+)
+
+// done 是没有返回数据的操作（例如退款、重发 Webhook）的输出。
+type done struct {
+	OK bool `json:"ok"`
+}
+
+// render 按格式输出 v。
+//
+// json 原样输出；table 与 csv 把结构体的每个 JSON 字段作为一列，切片的每个元素作为一行，嵌套的对象以紧凑 JSON 显示。
+// table 格式下单个对象按“字段 值”纵向显示。
+func render(w io.Writer, format string, v any) error {
+	switch format {
+	case formatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	case formatTable:
+		columns, rows, list := tabulate(v)
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		if !list && len(rows) == 1 {
+			for i, column := range columns {
+				fmt.Fprintf(tw, "%s\t%s\n", strings.ToUpper(column), rows[0][i])
+			}
+			return tw.Flush()
+		}
+		fmt.Fprintln(tw, strings.ToUpper(strings.Join(columns, "\t")))
+		for _, row := range rows {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		return tw.Flush()
+	case formatCSV:
+		columns, rows, _ := tabulate(v)
+		cw := csv.NewWriter(w)
+		if err := cw.Write(columns); err != nil {
+			return err
+		}
+		if err := cw.WriteAll(rows); err != nil {
+			return err
+		}
+		return cw.Error()
+	default:
+		return fmt.Errorf("cryptomus: unknown output format %q, use json, table or csv", format)
+	}
+}
+
+// tabulate 把 v 转换为列与行，list 表示 v 是切片。
+func tabulate(v any) (columns []string, rows [][]string, list bool) {
+	rv := indirect(reflect.ValueOf(v))
+	if !rv.IsValid() {
+		return []string{"value"}, nil, false
+	}
+
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		columns, fields := structColumns(rv.Type())
+		return columns, [][]string{record(rv, fields)}, false
+	}
+
+	columns, fields := structColumns(elemType(rv.Type()))
+	for i := range rv.Len() {
+		rows = append(rows, record(rv.Index(i), fields))
+	}
+
+	return columns, rows, true
+}
+
+// structColumns 返回结构体的列名与对应字段的索引，匿名字段会被展开。非结构体只有一列 value。
+func structColumns(t reflect.Type) (columns []string, fields [][]int) {
+	if t.Kind() != reflect.Struct || t == timeType {
+		return []string{"value"}, [][]int{nil}
+	}
+
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		columns = append(columns, name)
+		fields = append(fields, field.Index)
+	}
+
+	return columns, fields
+}
+
+// record 返回一行的各列的值。
+func record(v reflect.Value, fields [][]int) []string {
+	v = indirect(v)
+
+	row := make([]string, len(fields))
+	for i, index := range fields {
+		if index == nil {
+			row[i] = cell(v)
+			continue
+		}
+
+		if !v.IsValid() {
+			continue
+		}
+
+		// FieldByIndexErr 在经过 nil 的匿名指针字段时返回错误，此时该列为空。
+		if field, err := v.FieldByIndexErr(index); err == nil {
+			row[i] = cell(field)
+		}
+	}
+
+	return row
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// cell 返回单个值在表格中的文本。
+func cell(v reflect.Value) string {
+	v = indirect(v)
+	if !v.IsValid() {
+		return ""
+	}
+
+	if t, ok := v.Interface().(time.Time); ok {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return s.String()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		data, err := json.Marshal(v.Interface())
+		if err != nil {
+			return fmt.Sprint(v.Interface())
+		}
+		return string(data)
+	}
+
+	return fmt.Sprint(v.Interface())
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+
+	return v
+}
+
+func elemType(t reflect.Type) reflect.Type {
+	t = t.Elem()
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	return t
+}
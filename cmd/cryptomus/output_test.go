@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	cryptomus "github.com/difyz9/cryptomus-sdk-go"
+)
+
+type outputRow struct {
+	Name    string           `json:"name"`
+	Amount  cryptomus.Amount `json:"amount"`
+	Created time.Time        `json:"created_at"`
+	Convert *outputConvert   `json:"convert"`
+	Secret  string           `json:"-"`
+}
+
+type outputConvert struct {
+	To string `json:"to"`
+}
+
+func TestRender(t *testing.T) {
+	created := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	rows := []outputRow{
+		{Name: "a", Amount: cryptomus.MustParseAmount("1.50"), Created: created, Convert: &outputConvert{To: "USDT"}, Secret: "s"},
+		{Name: "b, c", Amount: cryptomus.MustParseAmount("2")},
+	}
+
+	tests := []struct {
+		name   string
+		format string
+		v      any
+		want   string
+	}{
+		{
+			name:   "json",
+			format: formatJSON,
+			v:      done{OK: true},
+			want:   "{\n  \"ok\": true\n}\n",
+		},
+		{
+			name:   "table list",
+			format: formatTable,
+			v:      rows,
+			want: "NAME  AMOUNT  CREATED_AT            CONVERT\n" +
+				"a     1.50    2024-06-01T12:00:00Z  {\"to\":\"USDT\"}\n" +
+				"b, c  2                             \n",
+		},
+		{
+			name:   "table object",
+			format: formatTable,
+			v:      &rows[1],
+			want:   "NAME        b, c\nAMOUNT      2\nCREATED_AT  \nCONVERT     \n",
+		},
+		{
+			name:   "csv",
+			format: formatCSV,
+			v:      rows,
+			want: "name,amount,created_at,convert\n" +
+				"a,1.50,2024-06-01T12:00:00Z,\"{\"\"to\"\":\"\"USDT\"\"}\"\n" +
+				"\"b, c\",2,,\n",
+		},
+		{
+			name:   "csv of values",
+			format: formatCSV,
+			v:      []string{"USDT", "BTC"},
+			want:   "value\nUSDT\nBTC\n",
+		},
+		{
+			name:   "empty csv",
+			format: formatCSV,
+			v:      []outputRow{},
+			want:   "name,amount,created_at,convert\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := render(&buf, tt.format, tt.v); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("output:\n%q\nwant:\n%q", buf.String(), tt.want)
+			}
+		})
+	}
+
+	if err := render(&bytes.Buffer{}, "xml", rows); err == nil {
+		t.Error("unknown format accepted")
+	}
+}
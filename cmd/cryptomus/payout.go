@@ -0,0 +1,304 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	cryptomus "github.com/difyz9/cryptomus-sdk-go"
+)
+
+var payoutCommand = &command{
+	name:  "payout",
+	usage: "create and inspect payouts",
+	sub: []*command{
+		{name: "create", usage: "create a payout", run: payoutCreate},
+		{name: "batch", usage: "create payouts from a CSV or JSON file", run: payoutBatch},
+		{name: "info", usage: "show a payout by -uuid or -order-id", run: payoutInfo},
+		{name: "history", usage: "list payouts", run: payoutHistory},
+		{name: "services", usage: "list payout services", run: payoutServices},
+		{name: "transfer", usage: "transfer funds between the business and personal wallets", run: payoutTransfer},
+	},
+}
+
+func payoutCreate(ctx context.Context, app *app, args []string) error {
+	var payload cryptomus.CreatePayoutRequest
+
+	fs := app.flags("cryptomus payout create")
+	amountVar(fs, &payload.Amount, "amount", "payout `amount` (required)")
+	fs.StringVar(&payload.Currency, "currency", "", "payout `currency` (required)")
+	fs.StringVar(&payload.OrderID, "order-id", "", "merchant order `id` (required)")
+	fs.StringVar(&payload.Address, "address", "", "recipient `address` (required)")
+	fs.StringVar(&payload.Network, "network", "", "blockchain `network`")
+	fs.StringVar(&payload.URLCallback, "url-callback", "", "webhook `url`")
+	fs.BoolVar(&payload.IsSubtract, "subtract", false, "subtract the commission from the merchant balance")
+	fs.StringVar(&payload.ToCurrency, "to-currency", "", "pay out in this crypto `currency`")
+	fs.StringVar(&payload.FromCurrency, "from-currency", "", "debit the balance of this `currency`")
+	fs.StringVar(&payload.CourseSource, "course-source", "", "exchange rate `source`")
+	fs.StringVar(&payload.Priority, "priority", "", "network fee `priority`, e.g. recommended")
+	fs.StringVar(&payload.Memo, "memo", "", "destination `memo` or tag")
+	if err := app.parse(fs, args, 0); err != nil {
+		return err
+	}
+	if err := requireFlags(fs, "amount", "currency", "order-id", "address"); err != nil {
+		return err
+	}
+
+	sdk, err := app.client()
+	if err != nil {
+		return err
+	}
+
+	result, err := sdk.CreatePayoutWithContext(ctx, &payload)
+	if err != nil {
+		return err
+	}
+
+	return app.print(result.Result)
+}
+
+// batchRow 是 payout batch 输出的一行。
+type batchRow struct {
+	OrderID  string                    `json:"order_id"`
+	Amount   cryptomus.Amount          `json:"amount"`
+	Currency string                    `json:"currency"`
+	Network  string                    `json:"network"`
+	State    cryptomus.PayoutItemState `json:"state"`
+	UUID     string                    `json:"uuid"`
+	Status   cryptomus.PayoutStatus    `json:"status"`
+	Error    string                    `json:"error"`
+}
+
+func payoutBatch(ctx context.Context, app *app, args []string) error {
+	var (
+		file, format string
+		opts         cryptomus.PayoutBatchOptions
+	)
+
+	fs := app.flags("cryptomus payout batch")
+	fs.StringVar(&file, "file", "", "payouts `file`, - for stdin (required)")
+	fs.StringVar(&format, "format", "", "input `format`: csv or json (default from the file extension, csv for stdin)")
+	fs.StringVar(&opts.Checkpoint, "checkpoint", "", "checkpoint `file` for resuming an interrupted batch")
+	fs.IntVar(&opts.Concurrency, "concurrency", 4, "number of payouts submitted at the same `time`")
+	fs.Float64Var(&opts.RateLimit.Rate, "rate", 0, "maximum payouts per `second` (0 means no limit)")
+	fs.BoolVar(&opts.SkipChecks, "skip-checks", false, "do not check payout limits and the balance before submitting")
+	fs.BoolVar(&opts.WaitForFinal, "wait", false, "wait until every payout reaches a final status (combine with -timeout)")
+	if err := app.parse(fs, args, 0); err != nil {
+		return err
+	}
+	if err := requireFlags(fs, "file"); err != nil {
+		return err
+	}
+
+	requests, err := readPayouts(file, format)
+	if err != nil {
+		return err
+	}
+
+	sdk, err := app.client()
+	if err != nil {
+		return err
+	}
+
+	report, err := sdk.PayoutBatch(opts).Run(ctx, requests)
+	if report == nil {
+		return err
+	}
+
+	rows := make([]batchRow, len(report.Items))
+	for i, item := range report.Items {
+		rows[i] = batchRow{
+			OrderID:  item.Request.OrderID,
+			Amount:   item.Request.Amount,
+			Currency: item.Request.Currency,
+			Network:  item.Request.Network,
+			State:    item.State,
+			Error:    item.Error,
+		}
+		if item.Payout != nil {
+			rows[i].UUID, rows[i].Status = item.Payout.UUID, item.Payout.Status
+		}
+	}
+
+	if printErr := app.print(rows); printErr != nil {
+		return printErr
+	}
+	report.WriteSummary(app.stderr)
+
+	if err != nil {
+		return err
+	}
+	if created := report.Counts[cryptomus.PayoutItemCreated]; created < len(report.Items) {
+		return fmt.Errorf("cryptomus: %d of %d payouts were not created", len(report.Items)-created, len(report.Items))
+	}
+
+	return nil
+}
+
+// readPayouts 读取 payout batch 的输入文件。
+func readPayouts(file, format string) ([]cryptomus.CreatePayoutRequest, error) {
+	var r io.Reader = os.Stdin
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(file)), ".")
+	}
+
+	switch format {
+	case "json":
+		return cryptomus.ReadPayoutsJSON(r)
+	case "csv", "":
+		return cryptomus.ReadPayoutsCSV(r)
+	}
+
+	return nil, fmt.Errorf("cryptomus: unknown input format %q", format)
+}
+
+func payoutInfo(ctx context.Context, app *app, args []string) error {
+	var payload cryptomus.PayoutInformationRequest
+	var wait bool
+
+	fs := app.flags("cryptomus payout info")
+	identify(fs, &payload.UUID, &payload.OrderID)
+	fs.BoolVar(&wait, "wait", false, "wait until the payout reaches a final status (combine with -timeout)")
+	if err := app.parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	sdk, err := app.client()
+	if err != nil {
+		return err
+	}
+
+	if wait {
+		id := payload.UUID
+		if id == "" {
+			id = payload.OrderID
+		}
+
+		payout, err := sdk.WaitForPayout(ctx, id, cryptomus.WatchOptions{})
+		if err != nil {
+			return err
+		}
+
+		return app.print(payout)
+	}
+
+	result, err := sdk.PayoutInformationWithContext(ctx, &payload)
+	if err != nil {
+		return err
+	}
+
+	return app.print(result.Result)
+}
+
+func payoutHistory(ctx context.Context, app *app, args []string) error {
+	var h history
+
+	fs := app.flags("cryptomus payout history")
+	h.flags(fs)
+	if err := app.parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	from, to, err := h.dates()
+	if err != nil {
+		return err
+	}
+
+	sdk, err := app.client()
+	if err != nil {
+		return err
+	}
+
+	if h.all {
+		items := []*cryptomus.PayoutData{}
+		for payout, err := range sdk.AllPayouts(ctx, from, to, h.pageOptions()...) {
+			if err != nil {
+				return err
+			}
+			items = append(items, payout)
+		}
+
+		return app.print(items)
+	}
+
+	result, err := sdk.PayoutHistoryWithContext(ctx, &cryptomus.PayoutHistoryRequest{
+		DateFrom: h.from,
+		DateTo:   h.to,
+		Cursor:   h.cursor,
+	})
+	if err != nil {
+		return err
+	}
+
+	app.printCursor(result.Result.Paginate)
+	return app.print(result.Result.Items)
+}
+
+func payoutServices(ctx context.Context, app *app, args []string) error {
+	fs := app.flags("cryptomus payout services")
+	if err := app.parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	sdk, err := app.client()
+	if err != nil {
+		return err
+	}
+
+	result, err := sdk.PayoutListOfServicesWithContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	return app.print(result.Result)
+}
+
+func payoutTransfer(ctx context.Context, app *app, args []string) error {
+	var amount cryptomus.Amount
+	var currency, to string
+
+	fs := app.flags("cryptomus payout transfer")
+	amountVar(fs, &amount, "amount", "transfer `amount` (required)")
+	fs.StringVar(&currency, "currency", "", "transfer `currency` (required)")
+	fs.StringVar(&to, "to", "", "destination `wallet`: personal or business (required)")
+	if err := app.parse(fs, args, 0); err != nil {
+		return err
+	}
+	if err := requireFlags(fs, "amount", "currency", "to"); err != nil {
+		return err
+	}
+
+	sdk, err := app.client()
+	if err != nil {
+		return err
+	}
+
+	switch to {
+	case "personal":
+		result, err := sdk.TransferToPersonalWalletWithContext(ctx, &cryptomus.TransferToPersonalWalletRequest{Amount: amount, Currency: currency})
+		if err != nil {
+			return err
+		}
+		return app.print(result.Result)
+	case "business":
+		result, err := sdk.TransferToBusinessWalletWithContext(ctx, &cryptomus.TransferToBusinessWalletRequest{Amount: amount, Currency: currency})
+		if err != nil {
+			return err
+		}
+		return app.print(result.Result)
+	default:
+		return fmt.Errorf("cryptomus: invalid -to %q, use personal or business", to)
+	}
+}
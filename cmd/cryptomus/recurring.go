@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+
+	cryptomus "github.com/difyz9/cryptomus-sdk-go"
+)
+
+var recurringCommand = &command{
+	name:  "recurring",
+	usage: "manage recurring payments",
+	sub: []*command{
+		{name: "create", usage: "create a recurring payment", run: recurringCreate},
+		{name: "info", usage: "show a recurring payment by -uuid or -order-id", run: recurringInfo},
+		{name: "list", usage: "list recurring payments", run: recurringList},
+		{name: "cancel", usage: "cancel a recurring payment", run: recurringCancel},
+	},
+}
+
+func recurringCreate(ctx context.Context, app *app, args []string) error {
+	var payload cryptomus.CreateRecurringPaymentRequest
+	var period string
+
+	fs := app.flags("cryptomus recurring create")
+	amountVar(fs, &payload.Amount, "amount", "payment `amount` (required)")
+	fs.StringVar(&payload.Currency, "currency", "", "payment `currency` (required)")
+	fs.StringVar(&payload.Name, "name", "", "recurring payment `name` (required)")
+	fs.StringVar(&period, "period", "", "`period`: weekly, monthly or three_month (required)")
+	fs.StringVar(&payload.ToCurrency, "to-currency", "", "charge in this crypto `currency`")
+	fs.StringVar(&payload.OrderID, "order-id", "", "merchant order `id`")
+	fs.StringVar(&payload.URLCallback, "url-callback", "", "webhook `url`")
+	fs.IntVar(&payload.DiscountDays, "discount-days", 0, "discounted `days` at the beginning")
+	amountVar(fs, &payload.DiscountAmount, "discount-amount", "`amount` charged during the discounted days")
+	fs.StringVar(&payload.AdditionalData, "additional-data", "", "additional `data`")
+	if err := app.parse(fs, args, 0); err != nil {
+		return err
+	}
+	if err := requireFlags(fs, "amount", "currency", "name", "period"); err != nil {
+		return err
+	}
+	payload.Period = cryptomus.RecurringPaymentPeriod(period)
+
+	sdk, err := app.client()
+	if err != nil {
+		return err
+	}
+
+	result, err := sdk.CreateRecurringPaymentWithContext(ctx, payload)
+	if err != nil {
+		return err
+	}
+
+	return app.print(result.Result)
+}
+
+func recurringInfo(ctx context.Context, app *app, args []string) error {
+	var payload cryptomus.RecurringPaymentInformationRequest
+
+	fs := app.flags("cryptomus recurring info")
+	identify(fs, &payload.UUID, &payload.OrderID)
+	if err := app.parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	sdk, err := app.client()
+	if err != nil {
+		return err
+	}
+
+	result, err := sdk.RecurringPaymentInformationWithContext(ctx, payload)
+	if err != nil {
+		return err
+	}
+
+	return app.print(result.Result)
+}
+
+func recurringList(ctx context.Context, app *app, args []string) error {
+	var cursor string
+	var all bool
+
+	fs := app.flags("cryptomus recurring list")
+	fs.StringVar(&cursor, "cursor", "", "page `cursor` printed by the previous page")
+	fs.BoolVar(&all, "all", false, "follow the cursors and print every page")
+	if err := app.parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	sdk, err := app.client()
+	if err != nil {
+		return err
+	}
+
+	if all {
+		var opts []cryptomus.PageOption
+		if cursor != "" {
+			opts = append(opts, cryptomus.PageCursor(cursor))
+		}
+
+		items := []cryptomus.RecurringPaymentData{}
+		for payment, err := range sdk.AllRecurringPayments(ctx, opts...) {
+			if err != nil {
+				return err
+			}
+			items = append(items, payment)
+		}
+
+		return app.print(items)
+	}
+
+	result, err := sdk.ListRecurringPaymentsWithContext(ctx, cryptomus.ListRecurringPaymentsRequest{Cursor: cursor})
+	if err != nil {
+		return err
+	}
+
+	app.printCursor(result.Result.Paginate)
+	return app.print(result.Result.Items)
+}
+
+func recurringCancel(ctx context.Context, app *app, args []string) error {
+	var payload cryptomus.CancelRecurringPaymentRequest
+
+	fs := app.flags("cryptomus recurring cancel")
+	identify(fs, &payload.UUID, &payload.OrderID)
+	if err := app.parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	sdk, err := app.client()
+	if err != nil {
+		return err
+	}
+
+	result, err := sdk.CancelRecurringPaymentWithContext(ctx, payload)
+	if err != nil {
+		return err
+	}
+
+	return app.print(result.Result)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+
+	cryptomus "github.com/difyz9/cryptomus-sdk-go"
+)
+
+var walletCommand = &command{
+	name:  "wallet",
+	usage: "manage static wallets",
+	sub: []*command{
+		{name: "create", usage: "create a static wallet", run: walletCreate},
+		{name: "qr", usage: "generate the QR code of a static wallet", run: walletQR},
+		{name: "block", usage: "block a static wallet", run: walletBlock},
+		{name: "refund-blocked", usage: "refund the payments received by a blocked wallet", run: walletRefundBlocked},
+	},
+}
+
+func walletCreate(ctx context.Context, app *app, args []string) error {
+	var payload cryptomus.CreateStaticWalletRequest
+
+	fs := app.flags("cryptomus wallet create")
+	fs.StringVar(&payload.Currency, "currency", "", "wallet `currency`, e.g. USDT (required)")
+	fs.StringVar(&payload.Network, "network", "", "blockchain `network`, e.g. tron (required)")
+	fs.StringVar(&payload.OrderID, "order-id", "", "merchant order `id` (required)")
+	fs.StringVar(&payload.URLCallback, "url-callback", "", "webhook `url`")
+	fs.StringVar(&payload.FromReferralCode, "referral-code", "", "referral `code`")
+	if err := app.parse(fs, args, 0); err != nil {
+		return err
+	}
+	if err := requireFlags(fs, "currency", "network", "order-id"); err != nil {
+		return err
+	}
+
+	sdk, err := app.client()
+	if err != nil {
+		return err
+	}
+
+	result, err := sdk.CreateStaticWalletWithContext(ctx, &payload)
+	if err != nil {
+		return err
+	}
+
+	return app.print(result.Result)
+}
+
+func walletQR(ctx context.Context, app *app, args []string) error {
+	var payload cryptomus.GenerateQRCodeWalletRequest
+	var out string
+
+	fs := app.flags("cryptomus wallet qr")
+	fs.StringVar(&payload.WalletAddressUUID, "uuid", "", "wallet address `uuid` (required)")
+	fs.StringVar(&out, "out", "", "write the PNG image to `file` instead of printing the data URI")
+	if err := app.parse(fs, args, 0); err != nil {
+		return err
+	}
+	if err := requireFlags(fs, "uuid"); err != nil {
+		return err
+	}
+
+	sdk, err := app.client()
+	if err != nil {
+		return err
+	}
+
+	result, err := sdk.GenerateQRStaticWalletWithContext(ctx, &payload)
+	if err != nil {
+		return err
+	}
+
+	return app.writeQR(result.Result.Image, out)
+}
+
+func walletBlock(ctx context.Context, app *app, args []string) error {
+	var payload cryptomus.BlockStaticWalletRequest
+
+	fs := app.flags("cryptomus wallet block")
+	identify(fs, &payload.UUID, &payload.OrderID)
+	fs.BoolVar(&payload.IsForceRefund, "force-refund", false, "refund all incoming payments to the sender's address")
+	if err := app.parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	sdk, err := app.client()
+	if err != nil {
+		return err
+	}
+
+	result, err := sdk.BlockStaticWalletWithContext(ctx, &payload)
+	if err != nil {
+		return err
+	}
+
+	return app.print(result.Result)
+}
+
+func walletRefundBlocked(ctx context.Context, app *app, args []string) error {
+	var payload cryptomus.RefundPaymentOnBlockedAddressRequest
+
+	fs := app.flags("cryptomus wallet refund-blocked")
+	identify(fs, &payload.UUID, &payload.OrderID)
+	fs.StringVar(&payload.Address, "address", "", "refund `address` (required)")
+	if err := app.parse(fs, args, 0); err != nil {
+		return err
+	}
+	if err := requireFlags(fs, "address"); err != nil {
+		return err
+	}
+
+	sdk, err := app.client()
+	if err != nil {
+		return err
+	}
+
+	result, err := sdk.RefundPaymentOnBlockedAddressWithContext(ctx, &payload)
+	if err != nil {
+		return err
+	}
+
+	return app.print(result.Result)
+}
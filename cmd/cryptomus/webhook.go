@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	cryptomus "github.com/difyz9/cryptomus-sdk-go"
+)
+
+var webhookCommand = &command{
+	name:  "webhook",
+	usage: "send test webhooks and resend invoice webhooks",
+	sub: []*command{
+		{name: "test", usage: "send a test payment, payout or wallet webhook", run: webhookTest},
+		{name: "resend", usage: "resend the webhook of a finished invoice", run: webhookResend},
+	},
+}
+
+func webhookTest(ctx context.Context, app *app, args []string) error {
+	var kind, url, currency, network, uuid, orderID, status string
+
+	fs := app.flags("cryptomus webhook test")
+	fs.StringVar(&kind, "type", "payment", "webhook `type`: payment, payout or wallet")
+	fs.StringVar(&url, "url", "", "callback `url` (required)")
+	fs.StringVar(&currency, "currency", "", "`currency` (required)")
+	fs.StringVar(&network, "network", "", "`network` (required)")
+	identify(fs, &uuid, &orderID)
+	fs.StringVar(&status, "status", "paid", "webhook `status`")
+	if err := app.parse(fs, args, 0); err != nil {
+		return err
+	}
+	if err := requireFlags(fs, "url", "currency", "network"); err != nil {
+		return err
+	}
+
+	sdk, err := app.client()
+	if err != nil {
+		return err
+	}
+
+	switch kind {
+	case "payment":
+		_, err = sdk.TestingWebhookPaymentWithContext(ctx, &cryptomus.TestingWebhookPaymentRequest{
+			URLCallback: url,
+			Currency:    currency,
+			Network:     network,
+			UUID:        uuid,
+			OrderID:     orderID,
+			Status:      cryptomus.PaymentStatus(status),
+		})
+	case "payout":
+		_, err = sdk.TestingWebhookPayoutWithContext(ctx, &cryptomus.TestingWebhookPayoutRequest{
+			URLCallback: url,
+			Currency:    currency,
+			Network:     network,
+			UUID:        uuid,
+			OrderID:     orderID,
+			Status:      cryptomus.PayoutStatus(status),
+		})
+	case "wallet":
+		_, err = sdk.TestingWebhookWalletWithContext(ctx, &cryptomus.TestingWebhookWalletRequest{
+			URLCallback: url,
+			Currency:    currency,
+			Network:     network,
+			UUID:        uuid,
+			OrderID:     orderID,
+			Status:      cryptomus.PaymentStatus(status),
+		})
+	default:
+		return fmt.Errorf("cryptomus: invalid -type %q, use payment, payout or wallet", kind)
+	}
+	if err != nil {
+		return err
+	}
+
+	return app.print(done{OK: true})
+}
+
+func webhookResend(ctx context.Context, app *app, args []string) error {
+	var payload cryptomus.ResendWebhookRequest
+
+	fs := app.flags("cryptomus webhook resend")
+	identify(fs, &payload.UUID, &payload.OrderID)
+	if err := app.parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	sdk, err := app.client()
+	if err != nil {
+		return err
+	}
+
+	if _, err := sdk.ResendWebhookWithContext(ctx, &payload); err != nil {
+		return err
+	}
+
+	return app.print(done{OK: true})
+}
@@ -0,0 +1,264 @@
+package cryptomus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultCredentialsGracePeriod 是密钥轮换后仍然接受旧密钥签名的 Webhook 的默认时长。
+const DefaultCredentialsGracePeriod = time.Hour
+
+// errLoadCredentials 表示 CredentialsProvider 返回了错误。
+var errLoadCredentials = errors.New("cryptomus: load credentials")
+
+// Credentials 是商户 ID 与 API 密钥。
+type Credentials struct {
+	Merchant     string `json:"merchant"`
+	PaymentToken string `json:"payment_token"`
+	PayoutToken  string `json:"payout_token"`
+}
+
+func (c Credentials) token(key TokenKind) string {
+	switch key {
+	case TokenPayment:
+		return c.PaymentToken
+	case TokenPayout:
+		return c.PayoutToken
+	}
+
+	return ""
+}
+
+// CredentialsProvider 在每次请求与每次校验 Webhook 时提供凭证，用于在不重启进程的情况下轮换密钥。
+//
+// 返回的字段为空时使用 WithMerchant、WithPaymentToken 与 WithPayoutToken 设置的值。
+// Credentials 会被频繁调用，实现应当缓存凭证，并且可以在多个 goroutine 中使用。
+type CredentialsProvider interface {
+	Credentials(ctx context.Context) (Credentials, error)
+}
+
+// CredentialsProviderFunc 把函数转换为 CredentialsProvider。
+type CredentialsProviderFunc func(ctx context.Context) (Credentials, error)
+
+func (f CredentialsProviderFunc) Credentials(ctx context.Context) (Credentials, error) {
+	return f(ctx)
+}
+
+// WithCredentialsProvider 设置 SDK 实例的凭证来源，代替固定的 Merchant、PaymentToken 与 PayoutToken。
+//
+// SDK 只认识凭证来源返回的密钥。凭证来源返回新密钥之前，只接受旧密钥签名的 Webhook，
+// 在 Cryptomus 后台生成新密钥之后、更新凭证来源之前到达的、使用新密钥签名的 Webhook 会被拒绝（Cryptomus 会稍后重试）。
+// SDK 第一次从凭证来源得到新密钥时（下一次 API 调用或 Webhook 校验）宽限期才开始，
+// 此后旧的 PaymentToken 与 PayoutToken 在 WithCredentialsGracePeriod 设置的时长内仍可用于校验 Webhook，
+// 用于接受轮换前签名、重试或延迟到达的 Webhook。因此生成新密钥后应尽快更新凭证来源。
+//
+// 示例：
+//
+//	sdk := cryptomus.New(
+//		cryptomus.WithCredentialsProvider(cryptomus.FileCredentials("/etc/cryptomus/credentials.json")),
+//	)
+func WithCredentialsProvider(provider CredentialsProvider) Option {
+	return func(c *Cryptomus) {
+		c.credentialsProvider = provider
+	}
+}
+
+// WithCredentialsGracePeriod 设置密钥轮换后仍然接受旧密钥签名的 Webhook 的时长，默认为 DefaultCredentialsGracePeriod。
+// 设置为 0 时密钥变化后立即只接受新密钥。
+func WithCredentialsGracePeriod(d time.Duration) Option {
+	return func(c *Cryptomus) {
+		c.keyring.grace = d
+	}
+}
+
+// credentials 返回本次请求使用的凭证，并记录密钥的变化。
+func (sdk *Cryptomus) credentials(ctx context.Context) (Credentials, error) {
+	creds := Credentials{
+		Merchant:     sdk.Merchant,
+		PaymentToken: sdk.PaymentToken,
+		PayoutToken:  sdk.PayoutToken,
+	}
+
+	if sdk.credentialsProvider != nil {
+		provided, err := sdk.credentialsProvider.Credentials(ctx)
+		if err != nil {
+			return creds, fmt.Errorf("%w: %w", errLoadCredentials, err)
+		}
+
+		override(&creds.Merchant, provided.Merchant)
+		override(&creds.PaymentToken, provided.PaymentToken)
+		override(&creds.PayoutToken, provided.PayoutToken)
+	}
+
+	sdk.keyring.observe(creds, time.Now())
+
+	return creds, nil
+}
+
+func override(field *string, value string) {
+	if value != "" {
+		*field = value
+	}
+}
+
+// keyring 记录当前密钥与轮换前的密钥，旧密钥在宽限期内仍然可以校验 Webhook。
+type keyring struct {
+	grace time.Duration
+
+	mu       sync.Mutex
+	current  Credentials
+	previous []retiredKey
+}
+
+type retiredKey struct {
+	key     TokenKind
+	token   string
+	retired time.Time
+}
+
+// observe 记录本次使用的凭证，密钥发生变化时保留旧密钥。
+//
+// 每个旧密钥只保留一份，宽限期从它第一次被替换时开始计算；凭证来源在新旧密钥之间来回切换时不会延长宽限期。
+func (k *keyring) observe(creds Credentials, now time.Time) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.prune(now)
+
+	for _, key := range []TokenKind{TokenPayment, TokenPayout} {
+		old, token := k.current.token(key), creds.token(key)
+		if old != "" && old != token && !k.retired(key, old) {
+			k.previous = append(k.previous, retiredKey{key: key, token: old, retired: now})
+		}
+	}
+	k.current = creds
+}
+
+// retired 判断 token 是否已经记录为旧密钥，调用时必须持有 mu。
+func (k *keyring) retired(key TokenKind, token string) bool {
+	for _, retired := range k.previous {
+		if retired.key == key && retired.token == token {
+			return true
+		}
+	}
+
+	return false
+}
+
+// accepted 返回 Webhook 签名可以使用的旧密钥。
+func (k *keyring) accepted(key TokenKind, now time.Time) []string {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.prune(now)
+
+	var tokens []string
+	for _, retired := range k.previous {
+		if retired.key == key && retired.token != k.current.token(key) {
+			tokens = append(tokens, retired.token)
+		}
+	}
+
+	return tokens
+}
+
+func (k *keyring) prune(now time.Time) {
+	previous := k.previous[:0]
+	for _, retired := range k.previous {
+		if now.Sub(retired.retired) < k.grace {
+			previous = append(previous, retired)
+		}
+	}
+	clear(k.previous[len(previous):])
+	k.previous = previous
+}
+
+// EnvCredentials 返回从环境变量读取凭证的 CredentialsProvider，每次调用都会重新读取。
+//
+// 变量名为 prefix 加上 MERCHANT、PAYMENT_TOKEN 与 PAYOUT_TOKEN，prefix 为空时使用 "CRYPTOMUS_"，
+// 即 CRYPTOMUS_MERCHANT、CRYPTOMUS_PAYMENT_TOKEN 与 CRYPTOMUS_PAYOUT_TOKEN。
+func EnvCredentials(prefix string) CredentialsProvider {
+	if prefix == "" {
+		prefix = "CRYPTOMUS_"
+	}
+
+	return CredentialsProviderFunc(func(ctx context.Context) (Credentials, error) {
+		return Credentials{
+			Merchant:     os.Getenv(prefix + "MERCHANT"),
+			PaymentToken: os.Getenv(prefix + "PAYMENT_TOKEN"),
+			PayoutToken:  os.Getenv(prefix + "PAYOUT_TOKEN"),
+		}, nil
+	})
+}
+
+// FileCredentialsProvider 从 JSON 文件读取凭证，文件的修改时间或大小变化后重新读取。
+//
+// 文件内容例如：
+//
+//	{"merchant": "...", "payment_token": "...", "payout_token": "..."}
+//
+// 文件暂时无法读取或内容无效（例如正在写入）时继续使用上一次成功读取的凭证；从未成功读取时返回错误。
+// 更新文件时建议先写入临时文件再重命名，避免读到不完整的内容。
+type FileCredentialsProvider struct {
+	path string
+
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+	creds   Credentials
+	loaded  bool
+}
+
+// FileCredentials 返回从 path 读取凭证的 FileCredentialsProvider。
+//
+// 示例：
+//
+//	sdk := cryptomus.New(
+//		cryptomus.WithCredentialsProvider(cryptomus.FileCredentials("/etc/cryptomus/credentials.json")),
+//	)
+func FileCredentials(path string) *FileCredentialsProvider {
+	return &FileCredentialsProvider{path: path}
+}
+
+// Credentials 返回文件中的凭证，文件变化后重新读取。
+func (p *FileCredentialsProvider) Credentials(ctx context.Context) (Credentials, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return p.fallback(err)
+	}
+
+	if p.loaded && info.ModTime().Equal(p.modTime) && info.Size() == p.size {
+		return p.creds, nil
+	}
+
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return p.fallback(err)
+	}
+
+	var creds Credentials
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return p.fallback(fmt.Errorf("invalid credentials file %s: %w", p.path, err))
+	}
+
+	p.creds, p.loaded = creds, true
+	p.modTime, p.size = info.ModTime(), info.Size()
+
+	return creds, nil
+}
+
+func (p *FileCredentialsProvider) fallback(err error) (Credentials, error) {
+	if p.loaded {
+		return p.creds, nil
+	}
+
+	return Credentials{}, err
+}
@@ -0,0 +1,175 @@
+package cryptomus
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestKeyringRotation(t *testing.T) {
+	k := keyring{grace: time.Hour}
+	start := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	k.observe(Credentials{PaymentToken: "a", PayoutToken: "x"}, start)
+	if got := k.accepted(TokenPayment, start); len(got) != 0 {
+		t.Fatalf("accepted before rotation = %v", got)
+	}
+
+	k.observe(Credentials{PaymentToken: "b", PayoutToken: "x"}, start)
+
+	tests := []struct {
+		after time.Duration
+		kind  TokenKind
+		want  []string
+	}{
+		{after: 0, kind: TokenPayment, want: []string{"a"}},
+		{after: 59 * time.Minute, kind: TokenPayment, want: []string{"a"}},
+		{after: 59 * time.Minute, kind: TokenPayout},
+		{after: time.Hour, kind: TokenPayment},
+	}
+
+	for _, tt := range tests {
+		if got := k.accepted(tt.kind, start.Add(tt.after)); !slices.Equal(got, tt.want) {
+			t.Errorf("accepted(%v) after %v = %v, want %v", tt.kind, tt.after, got, tt.want)
+		}
+	}
+}
+
+func TestKeyringFlipDoesNotExtendGrace(t *testing.T) {
+	k := keyring{grace: time.Hour}
+	start := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	// 凭证来源在新旧密钥之间来回切换，例如多个副本读取到不同版本的文件。
+	for i, token := range []string{"a", "b", "a", "b", "a", "b"} {
+		k.observe(Credentials{PaymentToken: token}, start.Add(time.Duration(i)*10*time.Minute))
+	}
+
+	if len(k.previous) != 2 {
+		t.Fatalf("previous = %+v, want one entry per token", k.previous)
+	}
+	if got := k.accepted(TokenPayment, start.Add(55*time.Minute)); !slices.Equal(got, []string{"a"}) {
+		t.Errorf("accepted = %v, want [a]", got)
+	}
+
+	// 宽限期从 a 第一次被替换时（10 分钟）开始。
+	if got := k.accepted(TokenPayment, start.Add(70*time.Minute)); len(got) != 0 {
+		t.Errorf("accepted after grace = %v, want none", got)
+	}
+}
+
+func TestKeyringZeroGrace(t *testing.T) {
+	var k keyring
+	now := time.Now()
+
+	k.observe(Credentials{PaymentToken: "a"}, now)
+	k.observe(Credentials{PaymentToken: "b"}, now)
+	if got := k.accepted(TokenPayment, now); len(got) != 0 {
+		t.Errorf("accepted = %v, want none", got)
+	}
+}
+
+func TestCredentialsProviderRotation(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		token = "old"
+	)
+	sdk := New(
+		WithMerchant("merchant"),
+		WithPayoutToken("static-payout"),
+		WithCredentialsProvider(CredentialsProviderFunc(func(ctx context.Context) (Credentials, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			return Credentials{PaymentToken: token}, nil
+		})),
+	)
+
+	body := []byte(`{"type":"payment","uuid":"a1","order_id":"order-1","amount":"15.00","status":"paid","is_final":true}`)
+	signedWith := func(key string) []byte {
+		signed, err := SignWebhook(body, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	if _, err := sdk.ParseWebhook(signedWith("old")); err != nil {
+		t.Fatalf("webhook signed with the current key: %v", err)
+	}
+
+	mu.Lock()
+	token = "new"
+	mu.Unlock()
+
+	creds, err := sdk.credentials(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.Merchant != "merchant" || creds.PaymentToken != "new" || creds.PayoutToken != "static-payout" {
+		t.Errorf("credentials = %+v, want empty fields filled from the options", creds)
+	}
+
+	for _, key := range []string{"new", "old"} {
+		if _, err := sdk.ParseWebhook(signedWith(key)); err != nil {
+			t.Errorf("webhook signed with %q after rotation: %v", key, err)
+		}
+	}
+	if _, err := sdk.ParseWebhook(signedWith("other")); err == nil {
+		t.Error("webhook signed with an unknown key accepted")
+	}
+}
+
+func TestFileCredentials(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "credentials.json")
+	ctx := context.Background()
+
+	if _, err := FileCredentials(path).Credentials(ctx); err == nil {
+		t.Fatal("missing file without previous credentials returned no error")
+	}
+
+	write := func(content string) {
+		t.Helper()
+		tmp := filepath.Join(dir, "credentials.tmp")
+		if err := os.WriteFile(tmp, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	provider := FileCredentials(path)
+	write(`{"merchant":"m","payment_token":"p1","payout_token":"x1"}`)
+	if creds, err := provider.Credentials(ctx); err != nil || creds.PaymentToken != "p1" {
+		t.Fatalf("credentials = %+v, %v", creds, err)
+	}
+
+	// 内容变化后重新读取。
+	write(`{"merchant":"m","payment_token":"p2-rotated","payout_token":"x1"}`)
+	if creds, err := provider.Credentials(ctx); err != nil || creds.PaymentToken != "p2-rotated" {
+		t.Fatalf("credentials after rotation = %+v, %v", creds, err)
+	}
+
+	// 内容无效或文件被删除时继续使用上一次成功读取的凭证。
+	write(`{"merchant":`)
+	if creds, err := provider.Credentials(ctx); err != nil || creds.PaymentToken != "p2-rotated" {
+		t.Errorf("credentials with invalid file = %+v, %v", creds, err)
+	}
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if creds, err := provider.Credentials(ctx); err != nil || creds.PaymentToken != "p2-rotated" {
+		t.Errorf("credentials with missing file = %+v, %v", creds, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "invalid.json"), []byte("not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FileCredentials(filepath.Join(dir, "invalid.json")).Credentials(ctx); err == nil {
+		t.Error("invalid file without previous credentials returned no error")
+	}
+}
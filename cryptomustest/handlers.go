@@ -0,0 +1,817 @@
+package cryptomustest
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	cryptomus "github.com/difyz9/cryptomus-sdk-go"
+)
+
+const historyDateLayout = "2006-01-02 15:04:05"
+
+// qrImage 是 QR 码接口返回的占位图片（1x1 像素的 PNG）。
+const qrImage = "data:image/png;base64,iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAQAAAC1HAwCAAAAC0lEQVR42mNkYAAAAAYAAjCB0C8AAAAASUVORK5CYII="
+
+func (s *Server) createInvoice(r *http.Request, body []byte) (any, *apiError) {
+	req, apiErr := decode[cryptomus.CreateInvoiceRequest](body)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	switch {
+	case req.Amount.Sign() <= 0:
+		return nil, validationError("amount", "The amount must be greater than 0.")
+	case req.Currency == "":
+		return nil, validationError("currency", "The currency field is required.")
+	case req.OrderID == "":
+		return nil, validationError("order_id", "The order id field is required.")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 相同 order_id 的发票会被直接返回，不检查是否过期，也不比较请求参数。
+	if invoice := s.findInvoice("", req.OrderID); invoice != nil {
+		return *invoice, nil
+	}
+
+	lifetime := req.Lifetime
+	if lifetime == 0 {
+		lifetime = 3600
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	invoice := &cryptomus.CreateInvoiceData{
+		UUID:           newUUID(),
+		OrderID:        req.OrderID,
+		Amount:         req.Amount,
+		PaymentAmount:  req.Amount,
+		Currency:       req.Currency,
+		PayerCurrency:  req.ToCurrency,
+		Network:        req.Network,
+		PaymentStatus:  cryptomus.PaymentStatusCheck,
+		Status:         cryptomus.PaymentStatusCheck,
+		ExpiredAt:      int(now.Add(time.Duration(lifetime) * time.Second).Unix()),
+		AdditionalData: req.AdditionalData,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	invoice.URL = "https://pay.cryptomus.com/pay/" + invoice.UUID
+	if req.Network != "" {
+		invoice.Address = newAddress()
+	}
+
+	s.invoices = append(s.invoices, invoice)
+	s.invoiceCallbacks[invoice.UUID] = req.URLCallback
+
+	return *invoice, nil
+}
+
+func (s *Server) createStaticWallet(r *http.Request, body []byte) (any, *apiError) {
+	req, apiErr := decode[cryptomus.CreateStaticWalletRequest](body)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	switch {
+	case req.Currency == "":
+		return nil, validationError("currency", "The currency field is required.")
+	case req.Network == "":
+		return nil, validationError("network", "The network field is required.")
+	case req.OrderID == "":
+		return nil, validationError("order_id", "The order id field is required.")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if w := s.findWallet("", req.OrderID); w != nil {
+		return w.data, nil
+	}
+
+	w := &wallet{
+		data: cryptomus.CreateStaticWalletData{
+			WalletUUID: newUUID(),
+			UUID:       newUUID(),
+			Address:    newAddress(),
+			Network:    req.Network,
+			Currency:   req.Currency,
+		},
+		orderID:     req.OrderID,
+		urlCallback: req.URLCallback,
+		status:      cryptomus.BlockStaticWalletStatusActive,
+	}
+	w.data.URL = "https://pay.cryptomus.com/wallet/" + w.data.UUID
+
+	s.wallets = append(s.wallets, w)
+
+	return w.data, nil
+}
+
+func (s *Server) walletQR(r *http.Request, body []byte) (any, *apiError) {
+	req, apiErr := decode[cryptomus.GenerateQRCodeWalletRequest](body)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.findWallet(req.WalletAddressUUID, "") == nil {
+		return nil, notFound("Wallet not found")
+	}
+
+	return cryptomus.GenerateQRCodeWalletData{Image: qrImage}, nil
+}
+
+func (s *Server) invoiceQR(r *http.Request, body []byte) (any, *apiError) {
+	req, apiErr := decode[cryptomus.GenerateQRCodeInvoiceRequest](body)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.findInvoice(req.MerchantPaymentUUID, "") == nil {
+		return nil, notFound("Payment not found")
+	}
+
+	return cryptomus.GenerateQRCodeInvoiceData{Image: qrImage}, nil
+}
+
+func (s *Server) blockStaticWallet(r *http.Request, body []byte) (any, *apiError) {
+	req, apiErr := decode[cryptomus.BlockStaticWalletRequest](body)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w := s.findWallet(req.UUID, req.OrderID)
+	if w == nil {
+		return nil, notFound("Wallet not found")
+	}
+
+	w.status = cryptomus.BlockStaticWalletStatusBlocked
+
+	return cryptomus.BlockStaticWalletData{UUID: w.data.UUID, Status: w.status}, nil
+}
+
+func (s *Server) refundBlockedAddress(r *http.Request, body []byte) (any, *apiError) {
+	req, apiErr := decode[cryptomus.RefundPaymentOnBlockedAddressRequest](body)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	if req.Address == "" {
+		return nil, validationError("address", "The address field is required.")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w := s.findWallet(req.UUID, req.OrderID)
+	switch {
+	case w == nil:
+		return nil, notFound("Wallet not found")
+	case w.status != cryptomus.BlockStaticWalletStatusBlocked:
+		return nil, &apiError{status: http.StatusUnprocessableEntity, message: "Wallet is not blocked"}
+	case w.refunded:
+		return nil, &apiError{status: http.StatusUnprocessableEntity, message: "Refund has already been made"}
+	}
+
+	w.refunded = true
+
+	return cryptomus.RefundPaymentOnBlockedAddressData{}, nil
+}
+
+func (s *Server) paymentInformation(r *http.Request, body []byte) (any, *apiError) {
+	req, apiErr := decode[cryptomus.PaymentInformationRequest](body)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	invoice := s.findInvoice(req.UUID, req.OrderID)
+	if invoice == nil {
+		return nil, notFound("Payment not found")
+	}
+
+	return *invoice, nil
+}
+
+func (s *Server) refund(r *http.Request, body []byte) (any, *apiError) {
+	req, apiErr := decode[cryptomus.RefundRequest](body)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	if req.Address == "" {
+		return nil, validationError("address", "The address field is required.")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	invoice := s.findInvoice(req.UUID, req.OrderID)
+	if invoice == nil {
+		return nil, notFound("Payment not found")
+	}
+
+	if !invoice.PaymentStatus.IsSuccess() {
+		return nil, &apiError{status: http.StatusUnprocessableEntity, message: "Payment is not paid"}
+	}
+
+	setInvoiceStatus(invoice, cryptomus.PaymentStatusRefundProcess)
+
+	return []any{}, nil
+}
+
+func (s *Server) resendWebhook(r *http.Request, body []byte) (any, *apiError) {
+	req, apiErr := decode[cryptomus.ResendWebhookRequest](body)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	s.mu.Lock()
+	invoice := s.findInvoice(req.UUID, req.OrderID)
+	var url string
+	var webhook *cryptomus.PaymentWebhook
+	if invoice != nil {
+		url = s.invoiceCallbacks[invoice.UUID]
+		webhook = s.invoiceWebhook(invoice)
+	}
+	s.mu.Unlock()
+
+	switch {
+	case invoice == nil:
+		return nil, notFound("Payment not found")
+	case url == "":
+		return nil, &apiError{status: http.StatusUnprocessableEntity, message: "Payment has no url_callback"}
+	}
+
+	_ = s.SendWebhook(url, webhook)
+
+	return []any{}, nil
+}
+
+func (s *Server) paymentServicesList(r *http.Request, body []byte) (any, *apiError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.paymentServices, nil
+}
+
+func (s *Server) paymentHistory(r *http.Request, body []byte) (any, *apiError) {
+	req, apiErr := decode[cryptomus.PaymentHistoryRequest](body)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	from, to, apiErr := historyRange(req.DateFrom, req.DateTo)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []cryptomus.CreateInvoiceData
+	for _, invoice := range slices.Backward(s.invoices) {
+		if inRange(invoice.CreatedAt, from, to) {
+			items = append(items, *invoice)
+		}
+	}
+
+	page, paginate := paginate(s, items, r.URL.Query().Get("cursor"))
+	return cryptomus.PaymentHistoryData{Items: page, Paginate: paginate}, nil
+}
+
+func (s *Server) testWebhookPayment(r *http.Request, body []byte) (any, *apiError) {
+	req, apiErr := decode[cryptomus.TestingWebhookPaymentRequest](body)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	if apiErr := validateTestWebhook(req.URLCallback, req.Currency, req.Network, string(req.Status)); apiErr != nil {
+		return nil, apiErr
+	}
+
+	webhook := s.testPaymentWebhook(req.UUID, req.OrderID, req.Currency, req.Network, req.Status)
+	webhook.Type = cryptomus.WebhookTypePayment
+	_ = s.SendWebhook(req.URLCallback, webhook)
+
+	return []any{}, nil
+}
+
+func (s *Server) testWebhookWallet(r *http.Request, body []byte) (any, *apiError) {
+	req, apiErr := decode[cryptomus.TestingWebhookWalletRequest](body)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	if apiErr := validateTestWebhook(req.URLCallback, req.Currency, req.Network, string(req.Status)); apiErr != nil {
+		return nil, apiErr
+	}
+
+	webhook := s.testPaymentWebhook(req.UUID, req.OrderID, req.Currency, req.Network, req.Status)
+	webhook.Type = cryptomus.WebhookTypeWallet
+	webhook.WalletAddressUUID = newUUID()
+	_ = s.SendWebhook(req.URLCallback, &cryptomus.WalletWebhook{PaymentWebhook: *webhook})
+
+	return []any{}, nil
+}
+
+func (s *Server) testWebhookPayout(r *http.Request, body []byte) (any, *apiError) {
+	req, apiErr := decode[cryptomus.TestingWebhookPayoutRequest](body)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	if apiErr := validateTestWebhook(req.URLCallback, req.Currency, req.Network, string(req.Status)); apiErr != nil {
+		return nil, apiErr
+	}
+
+	uuid, orderID := req.UUID, req.OrderID
+	if uuid == "" {
+		uuid = newUUID()
+	}
+
+	_ = s.SendWebhook(req.URLCallback, &cryptomus.PayoutWebhook{
+		Type:          cryptomus.WebhookTypePayout,
+		UUID:          uuid,
+		OrderID:       orderID,
+		Amount:        cryptomus.MustParseAmount("10"),
+		IsFinal:       req.Status.IsFinal(),
+		Status:        req.Status,
+		Currency:      req.Currency,
+		Network:       req.Network,
+		PayerCurrency: req.Currency,
+		PayerAmount:   cryptomus.MustParseAmount("10"),
+		MerchantID:    s.Merchant,
+	})
+
+	return []any{}, nil
+}
+
+func (s *Server) testPaymentWebhook(uuid, orderID, currency, network string, status cryptomus.PaymentStatus) *cryptomus.PaymentWebhook {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if invoice := s.findInvoice(uuid, orderID); invoice != nil && (uuid != "" || orderID != "") {
+		webhook := s.invoiceWebhook(invoice)
+		webhook.Status = status
+		webhook.IsFinal = status.IsFinal()
+		return webhook
+	}
+
+	if uuid == "" {
+		uuid = newUUID()
+	}
+
+	return &cryptomus.PaymentWebhook{
+		UUID:          uuid,
+		OrderID:       orderID,
+		Amount:        cryptomus.MustParseAmount("10"),
+		PaymentAmount: cryptomus.MustParseAmount("10"),
+		IsFinal:       status.IsFinal(),
+		Status:        status,
+		Network:       network,
+		Currency:      currency,
+		PayerCurrency: currency,
+		MerchantID:    s.Merchant,
+	}
+}
+
+func validateTestWebhook(urlCallback, currency, network, status string) *apiError {
+	errs := cryptomus.Errors{}
+	if urlCallback == "" {
+		errs["url_callback"] = []string{"The url callback field is required."}
+	}
+	if currency == "" {
+		errs["currency"] = []string{"The currency field is required."}
+	}
+	if network == "" {
+		errs["network"] = []string{"The network field is required."}
+	}
+	if status == "" {
+		errs["status"] = []string{"The status field is required."}
+	}
+
+	if len(errs) > 0 {
+		return &apiError{status: http.StatusUnprocessableEntity, errors: errs}
+	}
+
+	return nil
+}
+
+func (s *Server) createPayout(r *http.Request, body []byte) (any, *apiError) {
+	req, apiErr := decode[cryptomus.CreatePayoutRequest](body)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	switch {
+	case req.Amount.Sign() <= 0:
+		return nil, validationError("amount", "The amount must be greater than 0.")
+	case req.Currency == "":
+		return nil, validationError("currency", "The currency field is required.")
+	case req.Address == "":
+		return nil, validationError("address", "The address field is required.")
+	case req.OrderID == "":
+		return nil, validationError("order_id", "The order id field is required.")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.findPayout("", req.OrderID) != nil {
+		return nil, validationError("order_id", "The order id has already been taken.")
+	}
+
+	currency := strings.ToUpper(req.Currency)
+	balance := s.merchantBalances[currency]
+	if balance.Cmp(req.Amount) < 0 {
+		return nil, &apiError{status: http.StatusUnprocessableEntity, message: "Not enough funds"}
+	}
+	balance = balance.Sub(req.Amount)
+	s.merchantBalances[currency] = balance
+
+	now := time.Now().UTC().Truncate(time.Second)
+	p := &payout{
+		data: cryptomus.PayoutData{
+			UUID:          newUUID(),
+			Amount:        req.Amount,
+			Currency:      req.Currency,
+			Network:       req.Network,
+			Address:       req.Address,
+			Status:        cryptomus.PayoutStatusProcess,
+			Balance:       balance,
+			PayerCurrency: req.Currency,
+			PayerAmount:   req.Amount,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		},
+		orderID:     req.OrderID,
+		urlCallback: req.URLCallback,
+	}
+
+	s.payouts = append(s.payouts, p)
+
+	return p.data, nil
+}
+
+func (s *Server) payoutInformation(r *http.Request, body []byte) (any, *apiError) {
+	req, apiErr := decode[cryptomus.PayoutInformationRequest](body)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := s.findPayout(req.UUID, req.OrderID)
+	if p == nil {
+		return nil, notFound("Payout not found")
+	}
+
+	return p.data, nil
+}
+
+func (s *Server) payoutHistory(r *http.Request, body []byte) (any, *apiError) {
+	req, apiErr := decode[cryptomus.PayoutHistoryRequest](body)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	from, to, apiErr := historyRange(req.DateFrom, req.DateTo)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []*cryptomus.PayoutData
+	for _, p := range slices.Backward(s.payouts) {
+		if inRange(p.data.CreatedAt, from, to) {
+			data := p.data
+			items = append(items, &data)
+		}
+	}
+
+	page, paginate := paginate(s, items, r.URL.Query().Get("cursor"))
+	return cryptomus.PayoutHistoryData{MerchantUUID: s.Merchant, Items: page, Paginate: paginate}, nil
+}
+
+func (s *Server) payoutServicesList(r *http.Request, body []byte) (any, *apiError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.payoutServices, nil
+}
+
+func (s *Server) transferToPersonal(r *http.Request, body []byte) (any, *apiError) {
+	req, apiErr := decode[cryptomus.TransferToPersonalWalletRequest](body)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	merchant, user, apiErr := transfer(s.merchantBalances, s.userBalances, req.Currency, req.Amount)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	return cryptomus.TransferToPersonalWalletData{
+		UserWalletTransactionUUID: newUUID(),
+		UserWalletBalance:         user,
+		MerchantTransactionUUID:   newUUID(),
+		MerchantBalance:           merchant,
+	}, nil
+}
+
+func (s *Server) transferToBusiness(r *http.Request, body []byte) (any, *apiError) {
+	req, apiErr := decode[cryptomus.TransferToBusinessWalletRequest](body)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, merchant, apiErr := transfer(s.userBalances, s.merchantBalances, req.Currency, req.Amount)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	return cryptomus.TransferToBusinessWalletData{
+		UserWalletTransactionUUID: newUUID(),
+		UserWalletBalance:         user,
+		MerchantTransactionUUID:   newUUID(),
+		MerchantBalance:           merchant,
+	}, nil
+}
+
+// transfer 从 from 向 to 转移 amount，返回转移后双方的余额。
+func transfer(from, to map[string]cryptomus.Amount, currency string, amount cryptomus.Amount) (cryptomus.Amount, cryptomus.Amount, *apiError) {
+	switch {
+	case amount.Sign() <= 0:
+		return cryptomus.Amount{}, cryptomus.Amount{}, validationError("amount", "The amount must be greater than 0.")
+	case currency == "":
+		return cryptomus.Amount{}, cryptomus.Amount{}, validationError("currency", "The currency field is required.")
+	}
+
+	currency = strings.ToUpper(currency)
+	if from[currency].Cmp(amount) < 0 {
+		return cryptomus.Amount{}, cryptomus.Amount{}, &apiError{status: http.StatusUnprocessableEntity, message: "Not enough funds"}
+	}
+
+	from[currency] = from[currency].Sub(amount)
+	to[currency] = to[currency].Add(amount)
+
+	return from[currency], to[currency], nil
+}
+
+func (s *Server) createRecurring(r *http.Request, body []byte) (any, *apiError) {
+	req, apiErr := decode[cryptomus.CreateRecurringPaymentRequest](body)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	switch {
+	case req.Amount.Sign() <= 0:
+		return nil, validationError("amount", "The amount must be greater than 0.")
+	case req.Currency == "":
+		return nil, validationError("currency", "The currency field is required.")
+	case req.Name == "":
+		return nil, validationError("name", "The name field is required.")
+	case req.Period == "":
+		return nil, validationError("period", "The period field is required.")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if req.OrderID != "" && s.findRecurring("", req.OrderID) != nil {
+		return nil, validationError("order_id", "The order id has already been taken.")
+	}
+
+	payment := &cryptomus.RecurringPaymentData{
+		UUID:           newUUID(),
+		Name:           req.Name,
+		OrderID:        req.OrderID,
+		Amount:         req.Amount,
+		Currency:       req.Currency,
+		PayerCurrency:  req.ToCurrency,
+		PayerAmountUSD: req.Amount,
+		URLCallback:    req.URLCallback,
+		Period:         string(req.Period),
+		Status:         "wait_accept",
+	}
+	payment.URL = "https://pay.cryptomus.com/recurring/" + payment.UUID
+
+	s.recurring = append(s.recurring, payment)
+
+	return *payment, nil
+}
+
+func (s *Server) recurringInformation(r *http.Request, body []byte) (any, *apiError) {
+	req, apiErr := decode[cryptomus.RecurringPaymentInformationRequest](body)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	payment := s.findRecurring(req.UUID, req.OrderID)
+	if payment == nil {
+		return nil, notFound("Recurring payment not found")
+	}
+
+	return *payment, nil
+}
+
+func (s *Server) listRecurring(r *http.Request, body []byte) (any, *apiError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := make([]cryptomus.RecurringPaymentData, 0, len(s.recurring))
+	for _, payment := range slices.Backward(s.recurring) {
+		items = append(items, *payment)
+	}
+
+	page, paginate := paginate(s, items, r.URL.Query().Get("cursor"))
+	return cryptomus.ListRecurringPaymentsData{Items: page, Paginate: paginate}, nil
+}
+
+func (s *Server) cancelRecurring(r *http.Request, body []byte) (any, *apiError) {
+	req, apiErr := decode[cryptomus.CancelRecurringPaymentRequest](body)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	payment := s.findRecurring(req.UUID, req.OrderID)
+	if payment == nil {
+		return nil, notFound("Recurring payment not found")
+	}
+
+	payment.Status = "cancel_by_merchant"
+
+	return *payment, nil
+}
+
+func (s *Server) listDiscounts(r *http.Request, body []byte) (any, *apiError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	discounts := s.discounts
+	if discounts == nil {
+		discounts = cryptomus.ListOfDiscount{}
+	}
+
+	return discounts, nil
+}
+
+func (s *Server) setDiscount(r *http.Request, body []byte) (any, *apiError) {
+	req, apiErr := decode[cryptomus.SetDiscountToPaymentMethodRequest](body)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	switch {
+	case req.Currency == "":
+		return nil, validationError("currency", "The currency field is required.")
+	case req.Network == "":
+		return nil, validationError("network", "The network field is required.")
+	case req.Discount < -99 || req.Discount > 100:
+		return nil, validationError("discount_percent", "The discount percent must be between -99 and 100.")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	discount := cryptomus.Discount{Currency: req.Currency, Network: req.Network, Discount: req.Discount}
+
+	i := slices.IndexFunc(s.discounts, func(d cryptomus.Discount) bool {
+		return d.Currency == req.Currency && d.Network == req.Network
+	})
+	if i >= 0 {
+		s.discounts[i] = discount
+	} else {
+		s.discounts = append(s.discounts, discount)
+	}
+
+	return discount, nil
+}
+
+func (s *Server) balance(r *http.Request, body []byte) (any, *apiError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	balance := cryptomus.Balance{Merchant: []cryptomus.Merchant{}, User: []cryptomus.User{}}
+	for _, currency := range sortedKeys(s.merchantBalances) {
+		balance.Merchant = append(balance.Merchant, cryptomus.Merchant{
+			UUID:         s.Merchant,
+			Balance:      s.merchantBalances[currency],
+			CurrencyCode: currency,
+		})
+	}
+	for _, currency := range sortedKeys(s.userBalances) {
+		balance.User = append(balance.User, cryptomus.User{
+			UUID:         s.Merchant,
+			Balance:      s.userBalances[currency],
+			CurrencyCode: currency,
+		})
+	}
+
+	return []cryptomus.BalanceResult{{Balance: balance}}, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	return keys
+}
+
+func historyRange(dateFrom, dateTo string) (time.Time, time.Time, *apiError) {
+	var from, to time.Time
+	var err error
+
+	if dateFrom != "" {
+		if from, err = time.Parse(historyDateLayout, dateFrom); err != nil {
+			return from, to, validationError("date_from", "The date from does not match the format Y-m-d H:i:s.")
+		}
+	}
+	if dateTo != "" {
+		if to, err = time.Parse(historyDateLayout, dateTo); err != nil {
+			return from, to, validationError("date_to", "The date to does not match the format Y-m-d H:i:s.")
+		}
+	}
+
+	return from, to, nil
+}
+
+func inRange(t, from, to time.Time) bool {
+	return (from.IsZero() || !t.Before(from)) && (to.IsZero() || !t.After(to))
+}
+
+// findInvoice 按 uuid 或 order_id 查找发票，调用方必须持有 s.mu。
+func (s *Server) findInvoice(uuid, orderID string) *cryptomus.CreateInvoiceData {
+	for _, invoice := range s.invoices {
+		if (uuid != "" && invoice.UUID == uuid) || (uuid == "" && orderID != "" && invoice.OrderID == orderID) {
+			return invoice
+		}
+	}
+
+	return nil
+}
+
+// findWallet 按 uuid 或 order_id 查找静态钱包，调用方必须持有 s.mu。
+func (s *Server) findWallet(uuid, orderID string) *wallet {
+	for _, w := range s.wallets {
+		if (uuid != "" && (w.data.UUID == uuid || w.data.WalletUUID == uuid)) || (uuid == "" && orderID != "" && w.orderID == orderID) {
+			return w
+		}
+	}
+
+	return nil
+}
+
+// findPayout 按 uuid 或 order_id 查找提现，调用方必须持有 s.mu。
+func (s *Server) findPayout(uuid, orderID string) *payout {
+	for _, p := range s.payouts {
+		if (uuid != "" && p.data.UUID == uuid) || (uuid == "" && orderID != "" && p.orderID == orderID) {
+			return p
+		}
+	}
+
+	return nil
+}
+
+// findRecurring 按 uuid 或 order_id 查找循环支付，调用方必须持有 s.mu。
+func (s *Server) findRecurring(uuid, orderID string) *cryptomus.RecurringPaymentData {
+	for _, payment := range s.recurring {
+		if (uuid != "" && payment.UUID == uuid) || (uuid == "" && orderID != "" && payment.OrderID == orderID) {
+			return payment
+		}
+	}
+
+	return nil
+}
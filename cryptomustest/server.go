@@ -0,0 +1,460 @@
+// Package cryptomustest 提供一个内存中的 Cryptomus API 模拟服务器，用于集成测试。
+//
+// 服务器实现了 SDK 中的所有接口，使用 cryptomus.Sign 校验 merchant 与 sign 请求头，
+// 并在内存中保存发票、静态钱包、提现、循环支付与余额。测试可以推进发票与提现的状态，
+// 服务器会向 url_callback 发送签名后的 Webhook。
+//
+// 示例：
+//
+//	srv := cryptomustest.NewServer()
+//	defer srv.Close()
+//
+//	sdk := srv.Client()
+//	invoice, err := sdk.CreateInvoice(&cryptomus.CreateInvoiceRequest{
+//		Amount:      cryptomus.MustParseAmount("15"),
+//		Currency:    "USDT",
+//		OrderID:     "order-1",
+//		URLCallback: callbackURL,
+//	})
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//
+//	if err := srv.SetPaymentStatus("order-1", cryptomus.PaymentStatusPaid); err != nil {
+//		t.Fatal(err)
+//	}
+package cryptomustest
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	cryptomus "github.com/difyz9/cryptomus-sdk-go"
+)
+
+const (
+	DefaultMerchant     = "test-merchant"
+	DefaultPaymentToken = "test-payment-token"
+	DefaultPayoutToken  = "test-payout-token"
+
+	// DefaultPageSize 是历史记录接口每页返回的条数。
+	DefaultPageSize = 15
+)
+
+// Server 是模拟的 Cryptomus API 服务器。
+type Server struct {
+	*httptest.Server
+
+	Merchant     string
+	PaymentToken string
+	PayoutToken  string
+
+	pageSize      int
+	webhookClient *http.Client
+
+	mu               sync.Mutex
+	invoices         []*cryptomus.CreateInvoiceData
+	invoiceCallbacks map[string]string
+	wallets          []*wallet
+	payouts          []*payout
+	recurring        []*cryptomus.RecurringPaymentData
+	merchantBalances map[string]cryptomus.Amount
+	userBalances     map[string]cryptomus.Amount
+	rates            map[string]cryptomus.ExchangeRateList
+	discounts        cryptomus.ListOfDiscount
+	paymentServices  []cryptomus.PaymentListOfServicesData
+	payoutServices   []*cryptomus.PayoutListOfServicesData
+	webhooks         []Webhook
+}
+
+type wallet struct {
+	data        cryptomus.CreateStaticWalletData
+	orderID     string
+	urlCallback string
+	status      cryptomus.BlockStaticWalletStatus
+	refunded    bool
+}
+
+type payout struct {
+	data        cryptomus.PayoutData
+	orderID     string
+	urlCallback string
+}
+
+// Webhook 是服务器发送过的 Webhook。
+type Webhook struct {
+	URL        string
+	Body       []byte
+	StatusCode int
+	Err        error
+}
+
+// Option 配置 Server。
+type Option func(*Server)
+
+// WithCredentials 设置服务器接受的商户 ID 与密钥。
+func WithCredentials(merchant, paymentToken, payoutToken string) Option {
+	return func(s *Server) {
+		s.Merchant = merchant
+		s.PaymentToken = paymentToken
+		s.PayoutToken = payoutToken
+	}
+}
+
+// WithPageSize 设置历史记录接口每页返回的条数。
+func WithPageSize(size int) Option {
+	return func(s *Server) {
+		s.pageSize = size
+	}
+}
+
+// WithWebhookClient 设置发送 Webhook 使用的 HTTP 客户端。
+func WithWebhookClient(client *http.Client) Option {
+	return func(s *Server) {
+		s.webhookClient = client
+	}
+}
+
+// NewServer 启动一个模拟服务器，使用完毕后需要调用 Close。
+func NewServer(opts ...Option) *Server {
+	s := &Server{
+		Merchant:         DefaultMerchant,
+		PaymentToken:     DefaultPaymentToken,
+		PayoutToken:      DefaultPayoutToken,
+		pageSize:         DefaultPageSize,
+		webhookClient:    &http.Client{Timeout: 5 * time.Second},
+		invoiceCallbacks: map[string]string{},
+		merchantBalances: map[string]cryptomus.Amount{},
+		userBalances:     map[string]cryptomus.Amount{},
+		rates:            map[string]cryptomus.ExchangeRateList{},
+		paymentServices:  defaultPaymentServices(),
+		payoutServices:   defaultPayoutServices(),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+
+	return s
+}
+
+// Client 返回一个指向该服务器并使用服务器凭据的 SDK 实例。
+func (s *Server) Client(opts ...cryptomus.Option) *cryptomus.Cryptomus {
+	return cryptomus.New(append([]cryptomus.Option{
+		cryptomus.WithBaseURL(s.URL),
+		cryptomus.WithMerchant(s.Merchant),
+		cryptomus.WithPaymentToken(s.PaymentToken),
+		cryptomus.WithPayoutToken(s.PayoutToken),
+	}, opts...)...)
+}
+
+// SetBalance 设置商户钱包中某个货币的余额。
+func (s *Server) SetBalance(currency string, amount cryptomus.Amount) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.merchantBalances[strings.ToUpper(currency)] = amount
+}
+
+// Balance 返回商户钱包中某个货币的余额。
+func (s *Server) Balance(currency string) cryptomus.Amount {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.merchantBalances[strings.ToUpper(currency)]
+}
+
+// SetExchangeRates 设置 from 货币的汇率列表。
+func (s *Server) SetExchangeRates(from string, rates cryptomus.ExchangeRateList) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rates[strings.ToUpper(from)] = rates
+}
+
+// SetPaymentServices 替换支付服务列表。
+func (s *Server) SetPaymentServices(services []cryptomus.PaymentListOfServicesData) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.paymentServices = services
+}
+
+// SetPayoutServices 替换提现服务列表。
+func (s *Server) SetPayoutServices(services []*cryptomus.PayoutListOfServicesData) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.payoutServices = services
+}
+
+// Webhooks 返回服务器已发送的所有 Webhook。
+func (s *Server) Webhooks() []Webhook {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Webhook(nil), s.webhooks...)
+}
+
+type route struct {
+	key     string
+	handler func(s *Server, r *http.Request, body []byte) (any, *apiError)
+}
+
+// routes 与 api.go 中的 Endpoint 一一对应，key 表示校验签名使用的密钥。
+var routes = map[string]route{
+	cryptomus.CreateInvoiceEndpoint.String():                 {"payment", (*Server).createInvoice},
+	cryptomus.CreateStaticWalletEndpoint.String():            {"payment", (*Server).createStaticWallet},
+	cryptomus.GenerateQRCodeWalletEndpoint.String():          {"payment", (*Server).walletQR},
+	cryptomus.GenerateQRCodeInvoiceEndpoint.String():         {"payment", (*Server).invoiceQR},
+	cryptomus.BlockStaticWalletEndpoint.String():             {"payment", (*Server).blockStaticWallet},
+	cryptomus.RefundPaymentOnBlockedAddressEndpoint.String(): {"payment", (*Server).refundBlockedAddress},
+	cryptomus.PaymentInformationEndpoint.String():            {"payment", (*Server).paymentInformation},
+	cryptomus.RefundEndpoint.String():                        {"payment", (*Server).refund},
+	cryptomus.ResendWebhookEndpoint.String():                 {"payment", (*Server).resendWebhook},
+	cryptomus.PaymentListOfServicesEndpoint.String():         {"payment", (*Server).paymentServicesList},
+	cryptomus.PaymentHistoryEndpoint.String():                {"payment", (*Server).paymentHistory},
+	cryptomus.TestingWebhookPaymentEndpoint.String():         {"payment", (*Server).testWebhookPayment},
+	cryptomus.TestingWebhookPayoutEndpoint.String():          {"payment", (*Server).testWebhookPayout},
+	cryptomus.TestingWebhookWalletEndpoint.String():          {"payment", (*Server).testWebhookWallet},
+	cryptomus.CreatePayoutEndpoint.String():                  {"payout", (*Server).createPayout},
+	cryptomus.PayoutInformationEndpoint.String():             {"payout", (*Server).payoutInformation},
+	cryptomus.PayoutHistoryEndpoint.String():                 {"payout", (*Server).payoutHistory},
+	cryptomus.PayoutListOfServicesEndpoint.String():          {"payout", (*Server).payoutServicesList},
+	cryptomus.TransferToPersonalWalletEndpoint.String():      {"payout", (*Server).transferToPersonal},
+	cryptomus.TransferToBusinessWalletEndpoint.String():      {"payout", (*Server).transferToBusiness},
+	cryptomus.CreateRecurringPaymentEndpoint.String():        {"payment", (*Server).createRecurring},
+	cryptomus.RecurringPaymentInformationEndpoint.String():   {"payment", (*Server).recurringInformation},
+	cryptomus.ListRecurringPaymentsEndpoint.String():         {"payment", (*Server).listRecurring},
+	cryptomus.CancelRecurringPaymentEndpoint.String():        {"payment", (*Server).cancelRecurring},
+	cryptomus.ListOfDiscountsEndpoint.String():               {"payment", (*Server).listDiscounts},
+	cryptomus.SetDiscountToPaymentMethodEndpoint.String():    {"payment", (*Server).setDiscount},
+	cryptomus.BalanceEndpoint.String():                       {"payment", (*Server).balance},
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, &apiError{status: http.StatusBadRequest, message: err.Error()})
+		return
+	}
+
+	if currency, ok := exchangeRateCurrency(r.URL.Path); ok {
+		s.exchangeRates(w, currency)
+		return
+	}
+
+	route, ok := routes[r.URL.Path]
+	if !ok {
+		writeError(w, &apiError{status: http.StatusNotFound, message: "Not found"})
+		return
+	}
+
+	if apiErr := s.authenticate(r, body, route.key); apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+
+	result, apiErr := route.handler(s, r, body)
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"state": 0, "result": result})
+}
+
+func (s *Server) authenticate(r *http.Request, body []byte, key string) *apiError {
+	merchant := r.Header.Get("merchant")
+	sign := r.Header.Get("sign")
+	if merchant == "" || sign == "" {
+		return &apiError{status: http.StatusUnauthorized, message: "You did not provide authorization headers"}
+	}
+
+	if merchant != s.Merchant {
+		return &apiError{status: http.StatusUnauthorized, message: "Merchant not found"}
+	}
+
+	token := s.PaymentToken
+	if key == "payout" {
+		token = s.PayoutToken
+	}
+
+	if cryptomus.SignBody(token, body) != sign {
+		return &apiError{status: http.StatusUnauthorized, message: "Invalid Sign"}
+	}
+
+	return nil
+}
+
+// apiError 是以 Cryptomus 格式返回的错误响应。
+type apiError struct {
+	status  int
+	message string
+	errors  cryptomus.Errors
+}
+
+func validationError(field, message string) *apiError {
+	return &apiError{
+		status: http.StatusUnprocessableEntity,
+		errors: cryptomus.Errors{field: {message}},
+	}
+}
+
+func notFound(message string) *apiError {
+	return &apiError{status: http.StatusNotFound, message: message}
+}
+
+func writeError(w http.ResponseWriter, apiErr *apiError) {
+	body := map[string]any{"state": 1}
+	if apiErr.message != "" {
+		body["message"] = apiErr.message
+	}
+	if len(apiErr.errors) > 0 {
+		body["errors"] = apiErr.errors
+	}
+
+	writeJSON(w, apiErr.status, body)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func decode[T any](body []byte) (T, *apiError) {
+	var v T
+	if len(body) == 0 {
+		return v, nil
+	}
+
+	if err := json.Unmarshal(body, &v); err != nil {
+		return v, &apiError{status: http.StatusBadRequest, message: err.Error()}
+	}
+
+	return v, nil
+}
+
+func exchangeRateCurrency(path string) (string, bool) {
+	prefix, suffix, _ := strings.Cut(cryptomus.ExchangeRateListEndpoint.String(), "{currency}")
+
+	if !strings.HasPrefix(path, prefix) || !strings.HasSuffix(path, suffix) {
+		return "", false
+	}
+
+	currency := strings.TrimSuffix(strings.TrimPrefix(path, prefix), suffix)
+	return currency, currency != "" && !strings.Contains(currency, "/")
+}
+
+func (s *Server) exchangeRates(w http.ResponseWriter, currency string) {
+	s.mu.Lock()
+	rates, ok := s.rates[strings.ToUpper(currency)]
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, notFound("Currency not found"))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"state": 0, "result": rates})
+}
+
+// paginate 返回 items 中 cursor 指向的一页，游标是 base64 编码的偏移量。
+func paginate[T any](s *Server, items []T, cursor string) ([]T, *cryptomus.Pagination) {
+	offset := 0
+	if cursor != "" {
+		if raw, err := base64.RawURLEncoding.DecodeString(cursor); err == nil {
+			offset, _ = strconv.Atoi(string(raw))
+		}
+	}
+	offset = min(max(offset, 0), len(items))
+
+	end := min(offset+s.pageSize, len(items))
+	page := &cryptomus.Pagination{
+		Count:    end - offset,
+		HasPages: len(items) > s.pageSize,
+		PerPage:  s.pageSize,
+	}
+
+	if end < len(items) {
+		page.NextCursor = encodeCursor(end)
+	}
+	if offset > 0 {
+		page.PreviousCursor = encodeCursor(max(offset-s.pageSize, 0))
+	}
+
+	return append([]T{}, items[offset:end]...), page
+}
+
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset)))
+}
+
+func newUUID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	h := hex.EncodeToString(b[:])
+	return fmt.Sprintf("%s-%s-%s-%s-%s", h[0:8], h[8:12], h[12:16], h[16:20], h[20:32])
+}
+
+func newAddress() string {
+	var b [20]byte
+	_, _ = rand.Read(b[:])
+
+	return "0x" + hex.EncodeToString(b[:])
+}
+
+func defaultPaymentServices() []cryptomus.PaymentListOfServicesData {
+	return []cryptomus.PaymentListOfServicesData{
+		{
+			Network:     "tron",
+			Currency:    "USDT",
+			IsAvailable: true,
+			Limit:       cryptomus.PaymentListOfServiceLimit{MinAmount: cryptomus.MustParseAmount("1.00000000"), MaxAmount: cryptomus.MustParseAmount("10000000.00000000")},
+			Commission:  cryptomus.PaymentListOfServiceCommission{FeeAmount: cryptomus.MustParseAmount("0.00"), Percent: cryptomus.MustParseAmount("2.00")},
+		},
+		{
+			Network:     "btc",
+			Currency:    "BTC",
+			IsAvailable: true,
+			Limit:       cryptomus.PaymentListOfServiceLimit{MinAmount: cryptomus.MustParseAmount("0.00001000"), MaxAmount: cryptomus.MustParseAmount("1000000.00000000")},
+			Commission:  cryptomus.PaymentListOfServiceCommission{FeeAmount: cryptomus.MustParseAmount("0.00"), Percent: cryptomus.MustParseAmount("2.00")},
+		},
+	}
+}
+
+func defaultPayoutServices() []*cryptomus.PayoutListOfServicesData {
+	return []*cryptomus.PayoutListOfServicesData{
+		{
+			Network:     "tron",
+			Currency:    "USDT",
+			IsAvailable: true,
+			Limit:       cryptomus.PayoutListOfServiceLimit{MinAmount: cryptomus.MustParseAmount("10.00000000"), MaxAmount: cryptomus.MustParseAmount("10000000.00000000")},
+			Commission:  cryptomus.PayoutListOfServiceCommission{FeeAmount: cryptomus.MustParseAmount("1.00"), Percent: cryptomus.MustParseAmount("1.00")},
+		},
+		{
+			Network:     "btc",
+			Currency:    "BTC",
+			IsAvailable: true,
+			Limit:       cryptomus.PayoutListOfServiceLimit{MinAmount: cryptomus.MustParseAmount("0.00010000"), MaxAmount: cryptomus.MustParseAmount("1000000.00000000")},
+			Commission:  cryptomus.PayoutListOfServiceCommission{FeeAmount: cryptomus.MustParseAmount("0.00005000"), Percent: cryptomus.MustParseAmount("1.00")},
+		},
+	}
+}
@@ -0,0 +1,256 @@
+package cryptomustest_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	cryptomus "github.com/difyz9/cryptomus-sdk-go"
+	"github.com/difyz9/cryptomus-sdk-go/cryptomustest"
+)
+
+// callbackRecorder 是接收 Webhook 的回调地址，保存收到的请求体。
+type callbackRecorder struct {
+	*httptest.Server
+
+	mu     sync.Mutex
+	bodies [][]byte
+}
+
+func newCallbackRecorder(t *testing.T) *callbackRecorder {
+	t.Helper()
+
+	c := &callbackRecorder{}
+	c.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		c.mu.Lock()
+		c.bodies = append(c.bodies, body)
+		c.mu.Unlock()
+	}))
+	t.Cleanup(c.Close)
+
+	return c
+}
+
+func (c *callbackRecorder) last(t *testing.T) []byte {
+	t.Helper()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.bodies) == 0 {
+		t.Fatal("no webhook received")
+	}
+
+	return c.bodies[len(c.bodies)-1]
+}
+
+func TestInvoiceRoundTrip(t *testing.T) {
+	srv := cryptomustest.NewServer()
+	defer srv.Close()
+	callback := newCallbackRecorder(t)
+	sdk := srv.Client()
+
+	created, err := sdk.CreateInvoice(&cryptomus.CreateInvoiceRequest{
+		Amount:      cryptomus.MustParseAmount("15.5"),
+		Currency:    "USDT",
+		Network:     "tron",
+		OrderID:     "order-1",
+		URLCallback: callback.URL + "/payment",
+	})
+	if err != nil {
+		t.Fatalf("CreateInvoice: %v", err)
+	}
+	if created.Result.UUID == "" || created.Result.Address == "" || created.Result.PaymentStatus != cryptomus.PaymentStatusCheck {
+		t.Fatalf("invoice = %+v", created.Result)
+	}
+
+	again, err := sdk.CreateInvoice(&cryptomus.CreateInvoiceRequest{
+		Amount:   cryptomus.MustParseAmount("15.5"),
+		Currency: "USDT",
+		OrderID:  "order-1",
+	})
+	if err != nil {
+		t.Fatalf("CreateInvoice with the same order_id: %v", err)
+	}
+	if again.Result.UUID != created.Result.UUID {
+		t.Errorf("same order_id created invoice %s, want %s", again.Result.UUID, created.Result.UUID)
+	}
+
+	if err := srv.SetPaymentStatus("order-1", cryptomus.PaymentStatusPaid); err != nil {
+		t.Fatalf("SetPaymentStatus: %v", err)
+	}
+
+	body := callback.last(t)
+	if err := sdk.VerifyWebhook(body); err != nil {
+		t.Fatalf("VerifyWebhook: %v", err)
+	}
+	if err := cryptomus.VerifyWebhook(body, srv.PayoutToken); err == nil {
+		t.Error("payment webhook verified with the payout key")
+	}
+
+	webhook, err := sdk.ParseWebhook(body)
+	if err != nil {
+		t.Fatalf("ParseWebhook: %v", err)
+	}
+	payment, ok := webhook.(*cryptomus.PaymentWebhook)
+	if !ok {
+		t.Fatalf("webhook type = %T, want *cryptomus.PaymentWebhook", webhook)
+	}
+	if payment.UUID != created.Result.UUID || payment.OrderID != "order-1" || payment.Status != cryptomus.PaymentStatusPaid || !payment.IsFinal {
+		t.Errorf("webhook = %+v", payment)
+	}
+	if !payment.Amount.Equal(cryptomus.MustParseAmount("15.5")) || payment.Txid == "" {
+		t.Errorf("webhook amount = %s, txid = %q", payment.Amount, payment.Txid)
+	}
+
+	info, err := sdk.PaymentInformation(&cryptomus.PaymentInformationRequest{UUID: created.Result.UUID})
+	if err != nil {
+		t.Fatalf("PaymentInformation: %v", err)
+	}
+	if info.Result.PaymentStatus != cryptomus.PaymentStatusPaid || !info.Result.IsFinal {
+		t.Errorf("payment status = %s, is_final = %v", info.Result.PaymentStatus, info.Result.IsFinal)
+	}
+	if got, want := srv.Balance("USDT"), cryptomus.MustParseAmount("15.5"); !got.Equal(want) {
+		t.Errorf("balance = %s, want %s", got, want)
+	}
+
+	// 已支付的发票再次标记为 paid_over 不会重复计入余额。
+	if err := srv.SetPaymentStatus(created.Result.UUID, cryptomus.PaymentStatusPaidOver); err != nil {
+		t.Fatalf("SetPaymentStatus: %v", err)
+	}
+	if got, want := srv.Balance("USDT"), cryptomus.MustParseAmount("15.5"); !got.Equal(want) {
+		t.Errorf("balance after paid_over = %s, want %s", got, want)
+	}
+	if deliveries := srv.Webhooks(); len(deliveries) != 2 || deliveries[1].StatusCode != http.StatusOK {
+		t.Errorf("webhooks = %+v", deliveries)
+	}
+
+	if err := srv.SetPaymentStatus("missing", cryptomus.PaymentStatusPaid); err == nil {
+		t.Error("SetPaymentStatus accepted an unknown invoice")
+	}
+}
+
+func TestPayoutRoundTrip(t *testing.T) {
+	srv := cryptomustest.NewServer()
+	defer srv.Close()
+	srv.SetBalance("USDT", cryptomus.MustParseAmount("100"))
+	callback := newCallbackRecorder(t)
+	sdk := srv.Client()
+
+	created, err := sdk.CreatePayout(&cryptomus.CreatePayoutRequest{
+		Amount:      cryptomus.MustParseAmount("40"),
+		Currency:    "USDT",
+		Network:     "tron",
+		Address:     "TXYZ",
+		OrderID:     "payout-1",
+		URLCallback: callback.URL + "/payout",
+	})
+	if err != nil {
+		t.Fatalf("CreatePayout: %v", err)
+	}
+	if got, want := srv.Balance("USDT"), cryptomus.MustParseAmount("60"); !got.Equal(want) {
+		t.Errorf("balance after payout = %s, want %s", got, want)
+	}
+
+	if err := srv.SetPayoutStatus("payout-1", cryptomus.PayoutStatusPaid); err != nil {
+		t.Fatalf("SetPayoutStatus: %v", err)
+	}
+
+	body := callback.last(t)
+	if err := sdk.VerifyWebhook(body); err != nil {
+		t.Fatalf("VerifyWebhook: %v", err)
+	}
+	if err := cryptomus.VerifyWebhook(body, srv.PaymentToken); err == nil {
+		t.Error("payout webhook verified with the payment key")
+	}
+
+	webhook, err := sdk.ParseWebhook(body)
+	if err != nil {
+		t.Fatalf("ParseWebhook: %v", err)
+	}
+	payout, ok := webhook.(*cryptomus.PayoutWebhook)
+	if !ok {
+		t.Fatalf("webhook type = %T, want *cryptomus.PayoutWebhook", webhook)
+	}
+	if payout.UUID != created.Result.UUID || payout.OrderID != "payout-1" || payout.Status != cryptomus.PayoutStatusPaid || payout.Txid == "" {
+		t.Errorf("webhook = %+v", payout)
+	}
+
+	info, err := sdk.PayoutInformation(&cryptomus.PayoutInformationRequest{OrderID: "payout-1"})
+	if err != nil {
+		t.Fatalf("PayoutInformation: %v", err)
+	}
+	if info.Result.Status != cryptomus.PayoutStatusPaid || !info.Result.IsFinal {
+		t.Errorf("payout status = %s, is_final = %v", info.Result.Status, info.Result.IsFinal)
+	}
+
+	// 失败的提现退回余额。
+	if _, err := sdk.CreatePayout(&cryptomus.CreatePayoutRequest{
+		Amount:   cryptomus.MustParseAmount("10"),
+		Currency: "USDT",
+		Network:  "tron",
+		Address:  "TXYZ",
+		OrderID:  "payout-2",
+	}); err != nil {
+		t.Fatalf("CreatePayout: %v", err)
+	}
+	if err := srv.SetPayoutStatus("payout-2", cryptomus.PayoutStatusFail); err != nil {
+		t.Fatalf("SetPayoutStatus: %v", err)
+	}
+	if got, want := srv.Balance("USDT"), cryptomus.MustParseAmount("60"); !got.Equal(want) {
+		t.Errorf("balance after failed payout = %s, want %s", got, want)
+	}
+}
+
+func TestStaticWalletRoundTrip(t *testing.T) {
+	srv := cryptomustest.NewServer()
+	defer srv.Close()
+	callback := newCallbackRecorder(t)
+	sdk := srv.Client()
+
+	wallet, err := sdk.CreateStaticWallet(&cryptomus.CreateStaticWalletRequest{
+		Currency:    "USDT",
+		Network:     "tron",
+		OrderID:     "wallet-1",
+		URLCallback: callback.URL + "/wallet",
+	})
+	if err != nil {
+		t.Fatalf("CreateStaticWallet: %v", err)
+	}
+
+	if err := srv.SendWalletPayment("wallet-1", cryptomus.MustParseAmount("25")); err != nil {
+		t.Fatalf("SendWalletPayment: %v", err)
+	}
+
+	webhook, err := sdk.ParseWebhook(callback.last(t))
+	if err != nil {
+		t.Fatalf("ParseWebhook: %v", err)
+	}
+	payment, ok := webhook.(*cryptomus.WalletWebhook)
+	if !ok {
+		t.Fatalf("webhook type = %T, want *cryptomus.WalletWebhook", webhook)
+	}
+	if payment.OrderID != "wallet-1" || payment.WalletAddressUUID != wallet.Result.UUID || !payment.Amount.Equal(cryptomus.MustParseAmount("25")) {
+		t.Errorf("webhook = %+v", payment)
+	}
+	if got, want := srv.Balance("USDT"), cryptomus.MustParseAmount("25"); !got.Equal(want) {
+		t.Errorf("balance = %s, want %s", got, want)
+	}
+}
+
+func TestServerRejectsInvalidSign(t *testing.T) {
+	srv := cryptomustest.NewServer()
+	defer srv.Close()
+
+	sdk := srv.Client(cryptomus.WithPaymentToken("wrong-key"))
+	_, err := sdk.CreateInvoice(&cryptomus.CreateInvoiceRequest{
+		Amount:   cryptomus.MustParseAmount("1"),
+		Currency: "USDT",
+		OrderID:  "order-1",
+	})
+	if _, ok := cryptomus.AsAPIError(err); !ok {
+		t.Fatalf("CreateInvoice error = %v, want an APIError", err)
+	}
+}
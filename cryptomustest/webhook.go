@@ -0,0 +1,217 @@
+package cryptomustest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	cryptomus "github.com/difyz9/cryptomus-sdk-go"
+)
+
+// SendWebhook 使用服务器的密钥签名 webhook 并发送到 url。
+//
+// 提现 Webhook 使用提现密钥签名，其余使用支付密钥。非 2xx 响应会作为错误返回，
+// 所有发送记录都可以通过 Webhooks 获取。
+func (s *Server) SendWebhook(url string, webhook cryptomus.Webhook) error {
+	body, err := json.Marshal(webhook)
+	if err != nil {
+		return err
+	}
+
+	key := s.PaymentToken
+	if webhook.WebhookType() == cryptomus.WebhookTypePayout {
+		key = s.PayoutToken
+	}
+
+	body, err = cryptomus.SignWebhook(body, key)
+	if err != nil {
+		return err
+	}
+
+	delivery := Webhook{URL: url, Body: body}
+
+	resp, err := s.webhookClient.Post(url, "application/json", bytes.NewReader(body))
+	if err == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+
+		delivery.StatusCode = resp.StatusCode
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			err = fmt.Errorf("cryptomustest: webhook %s returned %s", url, resp.Status)
+		}
+	}
+	delivery.Err = err
+
+	s.mu.Lock()
+	s.webhooks = append(s.webhooks, delivery)
+	s.mu.Unlock()
+
+	return err
+}
+
+// SetPaymentStatus 修改发票状态，并在发票设置了 url_callback 时发送支付 Webhook。
+//
+// 状态变为 paid 或 paid_over 时，发票金额会计入商户余额。uuidOrOrderID 可以是发票的 uuid 或 order_id。
+func (s *Server) SetPaymentStatus(uuidOrOrderID string, status cryptomus.PaymentStatus) error {
+	s.mu.Lock()
+
+	invoice := s.findInvoice(uuidOrOrderID, "")
+	if invoice == nil {
+		invoice = s.findInvoice("", uuidOrOrderID)
+	}
+	if invoice == nil {
+		s.mu.Unlock()
+		return fmt.Errorf("cryptomustest: payment %q not found", uuidOrOrderID)
+	}
+
+	wasPaid := invoice.PaymentStatus.IsSuccess()
+	setInvoiceStatus(invoice, status)
+
+	if status.IsSuccess() && !wasPaid {
+		invoice.MerchantAmount = invoice.Amount
+		currency := strings.ToUpper(invoice.Currency)
+		s.merchantBalances[currency] = s.merchantBalances[currency].Add(invoice.Amount)
+	}
+
+	url := s.invoiceCallbacks[invoice.UUID]
+	webhook := s.invoiceWebhook(invoice)
+	s.mu.Unlock()
+
+	if url == "" {
+		return nil
+	}
+
+	return s.SendWebhook(url, webhook)
+}
+
+// SetPayoutStatus 修改提现状态，并在提现设置了 url_callback 时发送提现 Webhook。
+//
+// 状态变为 fail、cancel 或 system_fail 时，提现金额会退回商户余额。uuidOrOrderID 可以是提现的 uuid 或 order_id。
+func (s *Server) SetPayoutStatus(uuidOrOrderID string, status cryptomus.PayoutStatus) error {
+	s.mu.Lock()
+
+	p := s.findPayout(uuidOrOrderID, "")
+	if p == nil {
+		p = s.findPayout("", uuidOrOrderID)
+	}
+	if p == nil {
+		s.mu.Unlock()
+		return fmt.Errorf("cryptomustest: payout %q not found", uuidOrOrderID)
+	}
+
+	currency := strings.ToUpper(p.data.Currency)
+	if status.IsFailed() && !p.data.Status.IsFailed() {
+		s.merchantBalances[currency] = s.merchantBalances[currency].Add(p.data.Amount)
+	}
+
+	p.data.Status = status
+	p.data.IsFinal = status.IsFinal()
+	p.data.Balance = s.merchantBalances[currency]
+	p.data.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+	if status == cryptomus.PayoutStatusPaid && p.data.TxID == "" {
+		p.data.TxID = newUUID()
+	}
+
+	url := p.urlCallback
+	webhook := &cryptomus.PayoutWebhook{
+		Type:           cryptomus.WebhookTypePayout,
+		UUID:           p.data.UUID,
+		OrderID:        p.orderID,
+		Amount:         p.data.Amount,
+		MerchantAmount: p.data.Amount,
+		IsFinal:        p.data.IsFinal,
+		Status:         status,
+		Txid:           p.data.TxID,
+		Currency:       p.data.Currency,
+		Network:        p.data.Network,
+		PayerCurrency:  p.data.PayerCurrency,
+		PayerAmount:    p.data.PayerAmount,
+		MerchantID:     s.Merchant,
+	}
+	s.mu.Unlock()
+
+	if url == "" {
+		return nil
+	}
+
+	return s.SendWebhook(url, webhook)
+}
+
+// SendWalletPayment 模拟一笔到静态钱包的充值：金额计入商户余额，并向钱包的 url_callback 发送钱包 Webhook。
+func (s *Server) SendWalletPayment(uuidOrOrderID string, amount cryptomus.Amount) error {
+	s.mu.Lock()
+
+	w := s.findWallet(uuidOrOrderID, "")
+	if w == nil {
+		w = s.findWallet("", uuidOrOrderID)
+	}
+	if w == nil {
+		s.mu.Unlock()
+		return fmt.Errorf("cryptomustest: wallet %q not found", uuidOrOrderID)
+	}
+
+	currency := strings.ToUpper(w.data.Currency)
+	s.merchantBalances[currency] = s.merchantBalances[currency].Add(amount)
+
+	url := w.urlCallback
+	webhook := &cryptomus.WalletWebhook{PaymentWebhook: cryptomus.PaymentWebhook{
+		Type:              cryptomus.WebhookTypeWallet,
+		UUID:              newUUID(),
+		OrderID:           w.orderID,
+		Amount:            amount,
+		PaymentAmount:     amount,
+		MerchantAmount:    amount,
+		IsFinal:           true,
+		Status:            cryptomus.PaymentStatusPaid,
+		From:              newAddress(),
+		WalletAddressUUID: w.data.UUID,
+		Network:           w.data.Network,
+		Currency:          w.data.Currency,
+		PayerCurrency:     w.data.Currency,
+		Txid:              newUUID(),
+		MerchantID:        s.Merchant,
+	}}
+	s.mu.Unlock()
+
+	if url == "" {
+		return nil
+	}
+
+	return s.SendWebhook(url, webhook)
+}
+
+func setInvoiceStatus(invoice *cryptomus.CreateInvoiceData, status cryptomus.PaymentStatus) {
+	invoice.PaymentStatus = status
+	invoice.Status = status
+	invoice.IsFinal = status.IsFinal()
+	invoice.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+
+	if status.IsSuccess() && invoice.Txid == "" {
+		invoice.Txid = newUUID()
+		invoice.From = newAddress()
+		invoice.PayerAmount = invoice.PaymentAmount
+	}
+}
+
+func (s *Server) invoiceWebhook(invoice *cryptomus.CreateInvoiceData) *cryptomus.PaymentWebhook {
+	return &cryptomus.PaymentWebhook{
+		Type:           cryptomus.WebhookTypePayment,
+		UUID:           invoice.UUID,
+		OrderID:        invoice.OrderID,
+		Amount:         invoice.Amount,
+		PaymentAmount:  invoice.PayerAmount,
+		MerchantAmount: invoice.MerchantAmount,
+		IsFinal:        invoice.IsFinal,
+		Status:         invoice.PaymentStatus,
+		From:           invoice.From,
+		Network:        invoice.Network,
+		Currency:       invoice.Currency,
+		PayerCurrency:  invoice.PayerCurrency,
+		AdditionalData: invoice.AdditionalData,
+		Txid:           invoice.Txid,
+		MerchantID:     s.Merchant,
+	}
+}
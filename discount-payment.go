@@ -2,6 +2,7 @@ package cryptomus
 
 import (
 	"context"
+	"net/http"
 )
 
 type Discount struct {
@@ -48,14 +49,11 @@ func (sdk *Cryptomus) ListOfDiscountWithContext(ctx context.Context) (*ListOfDis
 
 	var result ListOfDiscountResponse
 
-	req := sdk.HttpClient.NewRequest().
-		SetContext(ctx).
-		SetHeader("merchant", sdk.Merchant).
-		SetHeader("sign", Sign(sdk.PaymentToken, nil)).
-		SetSuccessResult(&result).
-		SetErrorResult(&result)
-
-	if _, err := req.Post(ListOfDiscountsEndpoint.URL()); err != nil {
+	if err := sdk.send(ctx, &Request{
+		Method:   http.MethodPost,
+		Endpoint: ListOfDiscountsEndpoint,
+		Token:    TokenPayment,
+	}, &result); err != nil {
 		return nil, err
 	}
 
@@ -71,6 +69,17 @@ type SetDiscountToPaymentMethodRequest struct {
 	Discount int `json:"discount_percent"`
 }
 
+// Validate 校验 SetDiscountToPaymentMethod 的请求参数。
+func (r SetDiscountToPaymentMethodRequest) Validate() error {
+	var v validation
+
+	v.required("currency", r.Currency)
+	v.required("network", r.Network)
+	v.between("discount_percent", r.Discount, MinDiscountPercent, MaxDiscountPercent)
+
+	return v.err()
+}
+
 type SetDiscountToPaymentMethodResponse struct {
 	*HTTPResponse
 	Result Discount `json:"result,omitempty"`
@@ -116,15 +125,12 @@ func (sdk *Cryptomus) SetDiscountToPaymentMethodWithContext(ctx context.Context,
 
 	var result SetDiscountToPaymentMethodResponse
 
-	req := sdk.HttpClient.NewRequest().
-		SetContext(ctx).
-		SetHeader("merchant", sdk.Merchant).
-		SetHeader("sign", Sign(sdk.PaymentToken, payload)).
-		SetBody(payload).
-		SetSuccessResult(&result).
-		SetErrorResult(&result)
-
-	if _, err := req.Post(SetDiscountToPaymentMethodEndpoint.URL()); err != nil {
+	if err := sdk.send(ctx, &Request{
+		Method:   http.MethodPost,
+		Endpoint: SetDiscountToPaymentMethodEndpoint,
+		Token:    TokenPayment,
+		Payload:  payload,
+	}, &result); err != nil {
 		return nil, err
 	}
 
@@ -0,0 +1,143 @@
+package cryptomus
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// APIError 是 Cryptomus 返回的错误：HTTP 状态码 >= 400，或响应中的 state 不为 0。
+//
+// 详情：https://doc.cryptomus.com/business/general/errors
+//
+// 示例：
+//
+//	result, err := sdk.CreateInvoice(payload)
+//	var apiErr *cryptomus.APIError
+//	if errors.As(err, &apiErr) {
+//	    for field, messages := range apiErr.Errors {
+//	        log.Printf("%s: %v", field, messages)
+//	    }
+//	}
+type APIError struct {
+	// HTTP 状态码
+	StatusCode int
+	// 响应中的 state 字段，出错时通常为 1
+	State int
+	// 响应中的 code 字段
+	Code int
+	// 错误信息，取自响应中的 message 或 error 字段
+	Message string
+	// 字段校验错误
+	Errors Errors
+	// 解析后的原始响应，响应体不是 JSON 时为 nil
+	Response *HTTPResponse
+	// 原始响应体
+	Body []byte
+	// 响应头
+	Header http.Header
+}
+
+func newAPIError(statusCode int, header http.Header, response *HTTPResponse, body []byte) *APIError {
+	apiErr := &APIError{
+		StatusCode: statusCode,
+		Response:   response,
+		Body:       body,
+		Header:     header,
+	}
+
+	if response != nil {
+		apiErr.State = response.State
+		apiErr.Code = response.Code
+		apiErr.Errors = response.Errors
+		apiErr.Message = response.Message
+		if apiErr.Message == "" {
+			apiErr.Message = response.Error
+		}
+	}
+
+	if apiErr.Message == "" && len(apiErr.Errors) == 0 {
+		apiErr.Message = http.StatusText(statusCode)
+	}
+
+	return apiErr
+}
+
+func (e *APIError) Error() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "cryptomus: api error (http %d, state %d", e.StatusCode, e.State)
+	if e.Code != 0 {
+		fmt.Fprintf(&b, ", code %d", e.Code)
+	}
+	b.WriteString(")")
+
+	if e.Message != "" {
+		b.WriteString(": ")
+		b.WriteString(e.Message)
+	}
+
+	if len(e.Errors) > 0 {
+		b.WriteString(": ")
+		b.WriteString(e.Errors.String())
+	}
+
+	return b.String()
+}
+
+// String 以 "field: message" 的形式返回所有字段错误，按字段名排序。
+func (e Errors) String() string {
+	fields := make([]string, 0, len(e))
+	for field := range e {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	parts := make([]string, 0, len(fields))
+	for _, field := range fields {
+		parts = append(parts, field+": "+strings.Join(e[field], ", "))
+	}
+
+	return strings.Join(parts, "; ")
+}
+
+// AsAPIError 返回 err 链中的 *APIError。
+func AsAPIError(err error) (*APIError, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr, true
+	}
+
+	return nil, false
+}
+
+// IsValidationError 判断 err 是否为请求参数校验错误（本地校验失败、HTTP 422 或包含字段错误）。
+func IsValidationError(err error) bool {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return true
+	}
+
+	apiErr, ok := AsAPIError(err)
+	return ok && (apiErr.StatusCode == http.StatusUnprocessableEntity || len(apiErr.Errors) > 0)
+}
+
+// IsUnauthorized 判断 err 是否为签名或商户校验失败（HTTP 401）。
+func IsUnauthorized(err error) bool {
+	apiErr, ok := AsAPIError(err)
+	return ok && (apiErr.StatusCode == http.StatusUnauthorized || apiErr.Code == http.StatusUnauthorized)
+}
+
+// IsRateLimited 判断 err 是否为请求过于频繁（HTTP 429）。
+func IsRateLimited(err error) bool {
+	apiErr, ok := AsAPIError(err)
+	return ok && (apiErr.StatusCode == http.StatusTooManyRequests || apiErr.Code == http.StatusTooManyRequests)
+}
+
+// IsNotFound 判断 err 是否为资源不存在（HTTP 404），例如 uuid 或 order_id 对应的发票不存在。
+func IsNotFound(err error) bool {
+	apiErr, ok := AsAPIError(err)
+	return ok && (apiErr.StatusCode == http.StatusNotFound || apiErr.Code == http.StatusNotFound)
+}
@@ -18,7 +18,7 @@ func main() {
 	)
 
 	result, err := sdk.CreateInvoice(&cryptomus.CreateInvoiceRequest{
-		Amount:   "15",
+		Amount:   cryptomus.MustParseAmount("15"),
 		Currency: "USD",
 		OrderID:  "123456",
 		Lifetime: 3600,
@@ -21,7 +21,7 @@ func main() {
 	log.Println("Creating recurring payment...")
 
 	result, err := sdk.CreateRecurringPaymentWithContext(context.Background(), cryptomus.CreateRecurringPaymentRequest{
-		Amount:   "15",
+		Amount:   cryptomus.MustParseAmount("15"),
 		Currency: "USDT",
 		Name:     "Recurring payment",
 		Period:   "monthly",
@@ -23,7 +23,7 @@ func main() {
 	defer cancel()
 
 	createResult, err := sdk.CreateRecurringPaymentWithContext(ctx, cryptomus.CreateRecurringPaymentRequest{
-		Amount:   "15",
+		Amount:   cryptomus.MustParseAmount("15"),
 		Currency: "USDT",
 		Name:     "Recurring payment",
 		Period:   "monthly",
@@ -2,7 +2,7 @@ package cryptomus
 
 import (
 	"context"
-	"fmt"
+	"net/http"
 )
 
 type ExchangeRate struct {
@@ -11,7 +11,7 @@ type ExchangeRate struct {
 	// 目标货币代码（例如 BTC、ETH、USDT 等）
 	To string `json:"to,omitempty"`
 	// 汇率（例如 0.0001、0.0002、0.0003 等）
-	Course string `json:"course,omitempty"`
+	Course Amount `json:"course,omitzero"`
 }
 
 type ExchangeRateList []ExchangeRate
@@ -46,16 +46,13 @@ func (sdk *Cryptomus) ExchangeRateList(currency string) (*ExchangeRateResponse,
 //	    log.Fatal(err)
 //	}
 func (sdk *Cryptomus) ExchangeRateListWithContext(ctx context.Context, currency string) (*ExchangeRateResponse, error) {
-	url := fmt.Sprintf(ExchangeRateListEndpoint.URL(), currency)
-
 	var result ExchangeRateResponse
 
-	req := sdk.HttpClient.NewRequest().
-		SetContext(ctx).
-		SetSuccessResult(&result).
-		SetErrorResult(&result)
-
-	if _, err := req.Get(url); err != nil {
+	if err := sdk.send(ctx, &Request{
+		Method:   http.MethodGet,
+		Endpoint: ExchangeRateListEndpoint,
+		Params:   map[string]string{"currency": currency},
+	}, &result); err != nil {
 		return nil, err
 	}
 
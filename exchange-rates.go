@@ -0,0 +1,342 @@
+package cryptomus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrRateNotFound 表示既没有直接的汇率，也无法通过中间货币换算。
+var ErrRateNotFound = errors.New("cryptomus: exchange rate not found")
+
+// rateDecimals 是需要取倒数的汇率保留的小数位数。
+const rateDecimals = 18
+
+// ExchangeRatesOptions 配置 ExchangeRates。
+type ExchangeRatesOptions struct {
+	// TTL 是每个基准货币的汇率列表的缓存时间，默认为 1 分钟。
+	TTL time.Duration
+	// RefreshInterval 大于 0 时在后台按该间隔刷新已经成功获取的基准货币，使用完毕后需要调用 Close。
+	RefreshInterval time.Duration
+	// StaleWhileRevalidate 为 true 时，过期的汇率列表立即返回并在后台刷新；
+	// 刷新失败（例如 Cryptomus 不可用）时继续使用过期的列表，直到超过 MaxStale。
+	StaleWhileRevalidate bool
+	// MaxStale 是过期之后仍可使用的最长时间，0 表示不限制，仅在 StaleWhileRevalidate 为 true 时有效。
+	MaxStale time.Duration
+	// Bridge 是没有直接汇率时使用的中间货币，默认为 USDT。
+	Bridge string
+	// FetchTimeout 是每次获取汇率列表的超时时间，默认为 10 秒。获取由多个调用共享，不受单个调用的 ctx 取消影响。
+	FetchTimeout time.Duration
+}
+
+// ExchangeRates 按基准货币缓存 ExchangeRateList 的结果，并提供精确的金额换算。
+// ExchangeRates 可以在多个 goroutine 中使用。
+type ExchangeRates struct {
+	sdk  *Cryptomus
+	opts ExchangeRatesOptions
+
+	mu sync.Mutex
+	// entries 只包含成功获取过的基准货币，fetches 是正在获取的基准货币。
+	entries map[string]*ratesEntry
+	fetches map[string]*ratesFetch
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// ratesEntry 是一个基准货币的缓存。
+type ratesEntry struct {
+	rates   map[string]Amount
+	fetched time.Time
+}
+
+// ratesFetch 是一次进行中的获取，其他调用等待它的结果。
+type ratesFetch struct {
+	done  chan struct{}
+	rates map[string]Amount
+	err   error
+}
+
+// ExchangeRates 返回使用该 SDK 实例的汇率缓存。
+//
+// 示例：
+//
+//	rates := sdk.ExchangeRates(cryptomus.ExchangeRatesOptions{
+//		TTL:                  time.Minute,
+//		StaleWhileRevalidate: true,
+//		MaxStale:             time.Hour,
+//	})
+//	defer rates.Close()
+//
+//	amount, err := rates.Convert(ctx, cryptomus.MustParseAmount("100"), "USDT", "BTC")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+func (sdk *Cryptomus) ExchangeRates(opts ExchangeRatesOptions) *ExchangeRates {
+	if opts.TTL <= 0 {
+		opts.TTL = time.Minute
+	}
+	if opts.Bridge == "" {
+		opts.Bridge = "USDT"
+	}
+	if opts.FetchTimeout <= 0 {
+		opts.FetchTimeout = 10 * time.Second
+	}
+
+	r := &ExchangeRates{
+		sdk:     sdk,
+		opts:    opts,
+		entries: map[string]*ratesEntry{},
+		fetches: map[string]*ratesFetch{},
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	if opts.RefreshInterval > 0 {
+		go r.refreshLoop()
+	} else {
+		close(r.done)
+	}
+
+	return r
+}
+
+// Close 停止后台刷新，可以多次调用。Close 之后仍然可以查询汇率。
+func (r *ExchangeRates) Close() error {
+	r.closeOnce.Do(func() { close(r.stop) })
+	<-r.done
+
+	return nil
+}
+
+// Rates 返回 base 的汇率列表，键为大写的目标货币代码。返回的 map 不能修改。
+//
+// 只有获取成功的基准货币会被缓存；Cryptomus 不支持的货币返回空的列表，但不会被缓存。
+func (r *ExchangeRates) Rates(ctx context.Context, base string) (map[string]Amount, error) {
+	base = strings.ToUpper(base)
+
+	r.mu.Lock()
+	if entry := r.entries[base]; entry != nil {
+		age := time.Since(entry.fetched)
+		if age < r.opts.TTL {
+			r.mu.Unlock()
+			return entry.rates, nil
+		}
+		if r.usableLocked(entry) {
+			r.refreshLocked(ctx, base)
+			r.mu.Unlock()
+			return entry.rates, nil
+		}
+	}
+
+	fetch := r.refreshLocked(ctx, base)
+	r.mu.Unlock()
+
+	select {
+	case <-fetch.done:
+		return fetch.rates, fetch.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Rate 返回 1 单位 from 可以兑换的 to 的数量。
+//
+// 依次尝试 from 的汇率列表、to 的汇率列表（取倒数），以及通过 Bridge 换算；
+// 需要取倒数时结果保留 18 位小数，否则结果是精确的。都没有时返回 ErrRateNotFound。
+func (r *ExchangeRates) Rate(ctx context.Context, from, to string) (Amount, error) {
+	legs, err := r.path(ctx, from, to)
+	if err != nil {
+		return Amount{}, err
+	}
+
+	numerator, denominator := applyRates(AmountFromInt(1), legs)
+	if denominator.Equal(AmountFromInt(1)) {
+		return numerator, nil
+	}
+
+	return numerator.Div(denominator, rateDecimals), nil
+}
+
+// Convert 把 amount 从 from 换算为 to，结果按 to 的精度四舍五入（见 CurrencyDecimals）。
+//
+// 换算使用精确的十进制计算，只在最后舍入一次；汇率的查找方式与 Rate 相同。
+func (r *ExchangeRates) Convert(ctx context.Context, amount Amount, from, to string) (Amount, error) {
+	legs, err := r.path(ctx, from, to)
+	if err != nil {
+		return Amount{}, err
+	}
+
+	numerator, denominator := applyRates(amount, legs)
+	return numerator.Div(denominator, CurrencyDecimals(to)), nil
+}
+
+// rateLeg 是换算路径中的一段，invert 为 true 时除以 rate，否则乘以 rate。
+type rateLeg struct {
+	rate   Amount
+	invert bool
+}
+
+// path 返回从 from 到 to 的换算路径，from 与 to 相同时为空。
+func (r *ExchangeRates) path(ctx context.Context, from, to string) ([]rateLeg, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if from == to {
+		return nil, nil
+	}
+
+	leg, ok, err := r.leg(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return []rateLeg{leg}, nil
+	}
+
+	bridge := strings.ToUpper(r.opts.Bridge)
+	if from != bridge && to != bridge {
+		first, ok, err := r.leg(ctx, from, bridge)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			second, ok, err := r.leg(ctx, bridge, to)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				return []rateLeg{first, second}, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("cryptomus: no exchange rate from %s to %s: %w", from, to, ErrRateNotFound)
+}
+
+// leg 查找 from 到 to 的直接汇率，没有时使用 to 到 from 的汇率取倒数。
+func (r *ExchangeRates) leg(ctx context.Context, from, to string) (rateLeg, bool, error) {
+	rates, err := r.Rates(ctx, from)
+	if err != nil {
+		return rateLeg{}, false, err
+	}
+	if rate, ok := rates[to]; ok && rate.Sign() > 0 {
+		return rateLeg{rate: rate}, true, nil
+	}
+
+	rates, err = r.Rates(ctx, to)
+	if err != nil {
+		return rateLeg{}, false, err
+	}
+	if rate, ok := rates[from]; ok && rate.Sign() > 0 {
+		return rateLeg{rate: rate, invert: true}, true, nil
+	}
+
+	return rateLeg{}, false, nil
+}
+
+// applyRates 按路径换算 amount，返回精确的分子与分母，分母为 1 表示路径中没有倒数。
+func applyRates(amount Amount, legs []rateLeg) (numerator, denominator Amount) {
+	numerator, denominator = amount, AmountFromInt(1)
+	for _, leg := range legs {
+		if leg.invert {
+			denominator = denominator.Mul(leg.rate)
+		} else {
+			numerator = numerator.Mul(leg.rate)
+		}
+	}
+
+	return numerator, denominator
+}
+
+// usableLocked 报告过期的 entry 是否仍可以在刷新期间返回。调用时必须持有 r.mu。
+func (r *ExchangeRates) usableLocked(entry *ratesEntry) bool {
+	return r.opts.StaleWhileRevalidate && (r.opts.MaxStale <= 0 || time.Since(entry.fetched) < r.opts.TTL+r.opts.MaxStale)
+}
+
+// refreshLocked 开始获取 base 的汇率列表，已经在获取时返回进行中的获取。调用时必须持有 r.mu。
+//
+// 获取成功后才会创建或更新 base 的缓存，失败时保留已有的缓存。
+func (r *ExchangeRates) refreshLocked(ctx context.Context, base string) *ratesFetch {
+	if fetch := r.fetches[base]; fetch != nil {
+		return fetch
+	}
+
+	fetch := &ratesFetch{done: make(chan struct{})}
+	r.fetches[base] = fetch
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), r.opts.FetchTimeout)
+		defer cancel()
+
+		rates, listed, err := r.fetch(ctx, base)
+
+		r.mu.Lock()
+		switch {
+		case err == nil && listed:
+			r.entries[base] = &ratesEntry{rates: rates, fetched: time.Now()}
+		case err == nil:
+			// Cryptomus 不再支持该货币，不需要继续刷新。
+			delete(r.entries, base)
+		}
+		delete(r.fetches, base)
+		r.mu.Unlock()
+
+		fetch.rates, fetch.err = rates, err
+		close(fetch.done)
+	}()
+
+	return fetch
+}
+
+// fetch 获取 base 的汇率列表。Cryptomus 不支持该货币（404）时返回空的列表，listed 为 false。
+func (r *ExchangeRates) fetch(ctx context.Context, base string) (rates map[string]Amount, listed bool, err error) {
+	result, err := r.sdk.ExchangeRateListWithContext(ctx, base)
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return map[string]Amount{}, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	rates = make(map[string]Amount, len(result.Result))
+	for _, rate := range result.Result {
+		if strings.EqualFold(rate.From, base) || rate.From == "" {
+			rates[strings.ToUpper(rate.To)] = rate.Course
+		}
+	}
+
+	return rates, true, nil
+}
+
+// refreshLoop 按 RefreshInterval 刷新已经缓存的基准货币，直到 Close。
+// 过期超过 MaxStale 的缓存已经不能使用，会被删除而不再刷新，之后的查询重新获取。
+func (r *ExchangeRates) refreshLoop() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.opts.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+		}
+
+		r.mu.Lock()
+		for base, entry := range r.entries {
+			if r.opts.MaxStale > 0 && time.Since(entry.fetched) >= r.opts.TTL+r.opts.MaxStale {
+				delete(r.entries, base)
+				continue
+			}
+			r.refreshLocked(context.Background(), base)
+		}
+		r.mu.Unlock()
+	}
+}
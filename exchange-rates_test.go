@@ -0,0 +1,82 @@
+package cryptomus_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	cryptomus "github.com/difyz9/cryptomus-sdk-go"
+	"github.com/difyz9/cryptomus-sdk-go/cryptomustest"
+)
+
+// TestExchangeRatesOnlyRefreshesFetchedBases 检查不支持的货币与获取失败的货币不会被缓存，
+// 也不会被后台刷新反复获取。
+func TestExchangeRatesOnlyRefreshesFetchedBases(t *testing.T) {
+	srv := cryptomustest.NewServer()
+	defer srv.Close()
+	srv.SetExchangeRates("USDT", cryptomus.ExchangeRateList{
+		{From: "USDT", To: "BTC", Course: cryptomus.MustParseAmount("0.00001")},
+	})
+
+	var mu sync.Mutex
+	requests := map[string]int{}
+	sdk := srv.Client(cryptomus.WithMiddleware(func(next cryptomus.Handler) cryptomus.Handler {
+		return func(ctx context.Context, req *cryptomus.Request) (*cryptomus.Response, error) {
+			if req.Endpoint != cryptomus.ExchangeRateListEndpoint {
+				return next(ctx, req)
+			}
+
+			currency := req.Params["currency"]
+			mu.Lock()
+			requests[currency]++
+			mu.Unlock()
+
+			if currency == "FAIL" {
+				return cryptomus.NewResponse(http.StatusUnauthorized, []byte(`{"state":1,"message":"Unauthorized"}`)), nil
+			}
+			return next(ctx, req)
+		}
+	}))
+
+	rates := sdk.ExchangeRates(cryptomus.ExchangeRatesOptions{TTL: 10 * time.Millisecond, RefreshInterval: 10 * time.Millisecond})
+	defer rates.Close()
+
+	ctx := context.Background()
+	amount, err := rates.Convert(ctx, cryptomus.MustParseAmount("100"), "USDT", "BTC")
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+	if want := cryptomus.MustParseAmount("0.001"); !amount.Equal(want) {
+		t.Errorf("Convert = %s, want %s", amount, want)
+	}
+
+	if list, err := rates.Rates(ctx, "unknown"); err != nil || len(list) != 0 {
+		t.Errorf("Rates(unknown) = %v, %v, want an empty list", list, err)
+	}
+	if _, err := rates.Rates(ctx, "fail"); err == nil {
+		t.Error("Rates(fail) succeeded")
+	}
+	if _, err := rates.Rate(ctx, "UNKNOWN", "BTC"); !errors.Is(err, cryptomus.ErrRateNotFound) {
+		t.Errorf("Rate(UNKNOWN, BTC) error = %v, want ErrRateNotFound", err)
+	}
+
+	mu.Lock()
+	before := map[string]int{"UNKNOWN": requests["UNKNOWN"], "FAIL": requests["FAIL"]}
+	mu.Unlock()
+
+	time.Sleep(100 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if requests["USDT"] < 3 {
+		t.Errorf("USDT requests = %d, want the cached base to be refreshed", requests["USDT"])
+	}
+	for _, base := range []string{"UNKNOWN", "FAIL"} {
+		if requests[base] != before[base] {
+			t.Errorf("%s requests = %d after refreshes, want %d", base, requests[base], before[base])
+		}
+	}
+}
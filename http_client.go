@@ -1,6 +1,10 @@
 package cryptomus
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
 	"time"
 
 	"github.com/imroc/req/v3"
@@ -15,6 +19,158 @@ func DefaultHTTPClient() *req.Client {
 		SetTimeout(maxTimeout).
 		SetUserAgent(userAgent).
 		SetCommonHeader("Content-Type", "application/json").
-		SetCommonHeader("X-SDK-Language", "go").
-		EnableDumpAllAsync()
+		SetCommonHeader("X-SDK-Language", "go")
+}
+
+// send 发送请求并将响应解析到 result 中，请求依次经过中间件与 RetryPolicy。
+//
+// 当 HTTP 状态码 >= 400 或响应中的 state 不为 0 时，返回 *APIError。
+func (sdk *Cryptomus) send(ctx context.Context, call *Request, result any) error {
+	if !sdk.skipValidation && call.Payload != nil {
+		if err := validateRequest(call.Payload); err != nil {
+			return err
+		}
+	}
+
+	body, err := sdk.EncodeRequest(call.Payload)
+	if err != nil {
+		return err
+	}
+	call.Body = body
+
+	resp, err := sdk.handler()(ctx, call)
+	if err != nil {
+		return err
+	}
+
+	return decodeResponse(resp, result)
+}
+
+// decodeResponse 将响应解析到 result 中，中间件构造的响应同样会检查状态码与 state。
+func decodeResponse(resp *Response, result any) error {
+	data := resp.Bytes()
+	if !resp.checked {
+		var err error
+		if data, err = parseResponse(resp.StatusCode(), resp.Header(), data); err != nil {
+			return err
+		}
+	}
+
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(data, result)
+}
+
+// retry 是最内层的 Handler，按照 RetryPolicy 发送请求直到成功或不再重试。
+func (sdk *Cryptomus) retry(ctx context.Context, call *Request) (*Response, error) {
+	policy := sdk.retryPolicy
+
+	for attempt := 1; ; attempt++ {
+		resp, err := sdk.sendOnce(ctx, call, attempt)
+		if err == nil || !policy.shouldRetry(ctx, call, attempt, err) {
+			return resp, err
+		}
+
+		timer := time.NewTimer(policy.delay(attempt, err))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return resp, err
+		case <-timer.C:
+		}
+	}
+}
+
+func (sdk *Cryptomus) sendOnce(ctx context.Context, call *Request, attempt int) (*Response, error) {
+	if err := sdk.rateLimiter.wait(ctx, call.Endpoint); err != nil {
+		return nil, err
+	}
+
+	r := sdk.HttpClient.NewRequest().SetContext(ctx)
+
+	if call.Token != TokenNone {
+		creds, err := sdk.credentials(ctx)
+		if err != nil {
+			return nil, err
+		}
+		call.merchant = creds.Merchant
+
+		r.SetHeader("merchant", creds.Merchant).
+			SetHeader("sign", SignBody(creds.token(call.Token), call.Body))
+	}
+
+	if call.Body != nil {
+		r.SetBodyBytes(call.Body)
+	}
+
+	for key, value := range call.Query {
+		if value != "" {
+			r.SetQueryParam(key, value)
+		}
+	}
+
+	url, err := sdk.URL(call.Endpoint, call.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	done, err := sdk.breaker.allow(call.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	call.attempts = attempt
+	start := time.Now()
+
+	resp, err := r.Send(call.Method, url)
+	if err != nil {
+		done(err)
+		sdk.logCall(ctx, call, attempt, 0, time.Since(start), nil, err)
+		return nil, err
+	}
+
+	sdk.rateLimiter.observe(call.Endpoint, resp.StatusCode, resp.Header)
+
+	body := resp.Bytes()
+	_, err = parseResponse(resp.StatusCode, resp.Header, body)
+	done(err)
+	sdk.logCall(ctx, call, attempt, resp.StatusCode, time.Since(start), body, err)
+
+	return &Response{HTTPResponse: resp.Response, raw: body, checked: err == nil}, err
+}
+
+// parseResponse 检查响应的状态码与 state，成功时返回响应体，响应体为空时返回 nil。
+func parseResponse(status int, header http.Header, body []byte) ([]byte, error) {
+	if len(bytes.TrimSpace(body)) == 0 {
+		if status >= 400 {
+			return nil, newAPIError(status, header, nil, body)
+		}
+		return nil, nil
+	}
+
+	var envelope *HTTPResponse
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		if status >= 400 {
+			return nil, newAPIError(status, header, nil, body)
+		}
+		return nil, err
+	}
+
+	if status >= 400 || (envelope != nil && envelope.State != 0) {
+		return nil, newAPIError(status, header, envelope, body)
+	}
+
+	return body, nil
+}
+
+// URL 返回 endpoint 在该实例 API 地址下的完整地址，params 用于替换路径参数。
+func (sdk *Cryptomus) URL(endpoint Endpoint, params map[string]string) (string, error) {
+	path, err := endpoint.Path(params)
+	if err != nil {
+		return "", err
+	}
+
+	return sdk.BaseURL() + path, nil
 }
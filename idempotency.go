@@ -0,0 +1,447 @@
+package cryptomus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrOutcomeUnknown 表示创建请求的结果无法确定：请求可能已经被 Cryptomus 处理，按 order_id 查询也没有得到结论。
+	ErrOutcomeUnknown = errors.New("cryptomus: outcome unknown")
+	// ErrPayoutOutcomeUnknown 表示提现的结果无法确定，此时不能使用新的 order_id 重新提现，否则可能重复付款。
+	ErrPayoutOutcomeUnknown = errors.New("cryptomus: payout outcome unknown")
+)
+
+// OutcomeUnknownError 是 IdempotentClient 无法确定创建结果时返回的错误，
+// 满足 errors.Is(err, ErrOutcomeUnknown)，提现还满足 errors.Is(err, ErrPayoutOutcomeUnknown)。
+//
+// order_id 会保留在 InFlightStore 中，使用相同的 order_id 再次调用时会先查询结果，不会直接重新提交。
+type OutcomeUnknownError struct {
+	Endpoint Endpoint
+	OrderID  string
+	// Err 是最后一次创建请求的错误，例如超时、HTTP 5xx 或 order_id 已被使用。
+	Err error
+	// LookupErr 是按 order_id 查询的错误，查询成功但没有找到时为 nil。
+	LookupErr error
+}
+
+func (e *OutcomeUnknownError) Error() string {
+	msg := fmt.Sprintf("cryptomus: outcome of %s for order %q is unknown", e.Endpoint.Name(), e.OrderID)
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	if e.LookupErr != nil {
+		msg += " (lookup: " + e.LookupErr.Error() + ")"
+	}
+
+	return msg
+}
+
+func (e *OutcomeUnknownError) Is(target error) bool {
+	return target == ErrOutcomeUnknown || (target == ErrPayoutOutcomeUnknown && e.Endpoint == CreatePayoutEndpoint)
+}
+
+func (e *OutcomeUnknownError) Unwrap() []error {
+	return []error{e.Err, e.LookupErr}
+}
+
+// InFlightStore 记录已经提交、但结果尚未确认的创建请求。
+//
+// key 由商户 ID、接口与 order_id 组成。使用持久化的实现（例如 FileInFlightStore）时，
+// 进程崩溃后重新调用也会先查询之前的结果。实现需要可以在多个 goroutine 中使用。
+type InFlightStore interface {
+	Add(ctx context.Context, key string) error
+	Contains(ctx context.Context, key string) (bool, error)
+	Remove(ctx context.Context, key string) error
+}
+
+// MemoryInFlightStore 是保存在内存中的 InFlightStore，进程退出后记录会丢失。
+type MemoryInFlightStore struct {
+	mu   sync.Mutex
+	keys map[string]struct{}
+}
+
+// NewMemoryInFlightStore 创建 MemoryInFlightStore。
+func NewMemoryInFlightStore() *MemoryInFlightStore {
+	return &MemoryInFlightStore{keys: map[string]struct{}{}}
+}
+
+func (s *MemoryInFlightStore) Add(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys[key] = struct{}{}
+	return nil
+}
+
+func (s *MemoryInFlightStore) Contains(ctx context.Context, key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.keys[key]
+	return ok, nil
+}
+
+func (s *MemoryInFlightStore) Remove(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.keys, key)
+	return nil
+}
+
+// FileInFlightStore 是保存在 JSON 文件中的 InFlightStore，每次修改都会先写入临时文件再重命名。
+//
+// 同一个文件只能由一个进程使用。
+type FileInFlightStore struct {
+	path string
+
+	mu     sync.Mutex
+	keys   map[string]struct{}
+	loaded bool
+}
+
+// NewFileInFlightStore 创建使用 path 的 FileInFlightStore，文件不存在时会在第一次修改时创建。
+func NewFileInFlightStore(path string) *FileInFlightStore {
+	return &FileInFlightStore{path: path}
+}
+
+func (s *FileInFlightStore) Add(ctx context.Context, key string) error {
+	return s.update(func(keys map[string]struct{}) { keys[key] = struct{}{} })
+}
+
+func (s *FileInFlightStore) Contains(ctx context.Context, key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(); err != nil {
+		return false, err
+	}
+
+	_, ok := s.keys[key]
+	return ok, nil
+}
+
+func (s *FileInFlightStore) Remove(ctx context.Context, key string) error {
+	return s.update(func(keys map[string]struct{}) { delete(keys, key) })
+}
+
+func (s *FileInFlightStore) load() error {
+	if s.loaded {
+		return nil
+	}
+
+	s.keys = map[string]struct{}{}
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		s.loaded = true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var keys []string
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return fmt.Errorf("cryptomus: invalid in-flight file %s: %w", s.path, err)
+	}
+	for _, key := range keys {
+		s.keys[key] = struct{}{}
+	}
+	s.loaded = true
+
+	return nil
+}
+
+func (s *FileInFlightStore) update(fn func(map[string]struct{})) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(); err != nil {
+		return err
+	}
+
+	fn(s.keys)
+
+	keys := make([]string, 0, len(s.keys))
+	for key := range s.keys {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	data, err := json.MarshalIndent(keys, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(s.path, data)
+}
+
+// writeFileAtomic 先写入同一目录下的临时文件再重命名，避免留下不完整的文件。
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// IdempotencyOptions 配置 IdempotentClient。
+type IdempotencyOptions struct {
+	// Store 记录结果尚未确认的 order_id，默认为 NewMemoryInFlightStore()。
+	Store InFlightStore
+	// MaxAttempts 是查询确认请求未被处理后，最多提交创建请求的次数，默认为 3。
+	MaxAttempts int
+	// LookupTimeout 是按 order_id 查询的超时时间，默认为 10 秒。
+	// 查询不受调用方 ctx 的取消与超时影响，因此创建请求超时后仍然可以确认结果。
+	LookupTimeout time.Duration
+}
+
+// IdempotentClient 以 order_id 为幂等键创建提现、发票与静态钱包，避免超时后重复创建。
+//
+// 创建请求的结果不明确时（网络错误、超时或 HTTP 5xx），先按 order_id 查询：已经创建时返回查询到的结果；
+// 确认没有创建时使用相同的 order_id 重新提交；仍然无法确定时返回 *OutcomeUnknownError，
+// 此时调用方应稍后使用相同的请求重试，而不是更换 order_id。
+// order_id 已被使用时同样按 order_id 查询，因此重复调用会返回已经创建的对象；查询不到时同样返回 *OutcomeUnknownError。
+type IdempotentClient struct {
+	sdk  *Cryptomus
+	opts IdempotencyOptions
+}
+
+// Idempotent 返回使用该 SDK 实例的 IdempotentClient。
+//
+// 示例：
+//
+//	client := sdk.Idempotent(cryptomus.IdempotencyOptions{
+//		Store: cryptomus.NewFileInFlightStore("/var/lib/shop/cryptomus-inflight.json"),
+//	})
+//
+//	result, err := client.CreatePayout(ctx, &cryptomus.CreatePayoutRequest{
+//		Amount:   cryptomus.MustParseAmount("100"),
+//		Currency: "USDT",
+//		Network:  "tron",
+//		Address:  address,
+//		OrderID:  withdrawal.ID,
+//	})
+//	if errors.Is(err, cryptomus.ErrPayoutOutcomeUnknown) {
+//	    // 稍后使用相同的 order_id 重试
+//	}
+func (sdk *Cryptomus) Idempotent(opts IdempotencyOptions) *IdempotentClient {
+	if opts.Store == nil {
+		opts.Store = NewMemoryInFlightStore()
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 3
+	}
+	if opts.LookupTimeout <= 0 {
+		opts.LookupTimeout = 10 * time.Second
+	}
+
+	return &IdempotentClient{sdk: sdk, opts: opts}
+}
+
+// CreatePayout 创建提现，结果不明确时按 order_id 调用 PayoutInformation 确认，payload.OrderID 不能为空。
+func (c *IdempotentClient) CreatePayout(ctx context.Context, payload *CreatePayoutRequest) (*CreatePayoutResponse, error) {
+	if payload == nil {
+		return nil, errors.New("cryptomus: nil payout request")
+	}
+
+	return idempotent(ctx, c, CreatePayoutEndpoint, payload.OrderID,
+		func(ctx context.Context) (*CreatePayoutResponse, error) {
+			return c.sdk.CreatePayoutWithContext(ctx, payload)
+		},
+		func(ctx context.Context) (*CreatePayoutResponse, error) {
+			info, err := c.sdk.PayoutInformationWithContext(ctx, &PayoutInformationRequest{OrderID: payload.OrderID})
+			if err != nil {
+				return nil, err
+			}
+			return &CreatePayoutResponse{HTTPResponse: info.HTTPResponse, Result: info.Result}, nil
+		})
+}
+
+// CreateInvoice 创建发票，结果不明确时按 order_id 调用 PaymentInformation 确认，payload.OrderID 不能为空。
+func (c *IdempotentClient) CreateInvoice(ctx context.Context, payload *CreateInvoiceRequest) (*CreateInvoiceResponse, error) {
+	if payload == nil {
+		return nil, errors.New("cryptomus: nil invoice request")
+	}
+
+	return idempotent(ctx, c, CreateInvoiceEndpoint, payload.OrderID,
+		func(ctx context.Context) (*CreateInvoiceResponse, error) {
+			return c.sdk.CreateInvoiceWithContext(ctx, payload)
+		},
+		func(ctx context.Context) (*CreateInvoiceResponse, error) {
+			info, err := c.sdk.PaymentInformationWithContext(ctx, &PaymentInformationRequest{OrderID: payload.OrderID})
+			if err != nil {
+				return nil, err
+			}
+			if info.Result.CreateInvoiceData == nil {
+				return nil, fmt.Errorf("cryptomus: empty payment information for order %q", payload.OrderID)
+			}
+			return &CreateInvoiceResponse{HTTPResponse: info.HTTPResponse, Result: *info.Result.CreateInvoiceData}, nil
+		})
+}
+
+// CreateStaticWallet 创建静态钱包，payload.OrderID 不能为空。
+//
+// Cryptomus 没有按 order_id 查询静态钱包的接口，但相同 order_id 的创建请求会返回已有的钱包，
+// 因此结果不明确时直接使用相同的 order_id 重新提交。
+func (c *IdempotentClient) CreateStaticWallet(ctx context.Context, payload *CreateStaticWalletRequest) (*CreateStaticWalletResponse, error) {
+	if payload == nil {
+		return nil, errors.New("cryptomus: nil static wallet request")
+	}
+
+	return idempotent(ctx, c, CreateStaticWalletEndpoint, payload.OrderID,
+		func(ctx context.Context) (*CreateStaticWalletResponse, error) {
+			return c.sdk.CreateStaticWalletWithContext(ctx, payload)
+		}, nil)
+}
+
+// idempotent 执行创建请求，lookup 按 order_id 查询已创建的对象，没有查询接口时为 nil。
+func idempotent[T any](
+	ctx context.Context,
+	c *IdempotentClient,
+	endpoint Endpoint,
+	orderID string,
+	create func(context.Context) (T, error),
+	lookup func(context.Context) (T, error),
+) (T, error) {
+	var zero T
+
+	if orderID == "" {
+		return zero, fmt.Errorf("cryptomus: order_id is required for idempotent %s", endpoint.Name())
+	}
+
+	store := c.opts.Store
+	key := idempotencyKey(c.sdk.Merchant, endpoint, orderID)
+	unknown := func(err, lookupErr error) (T, error) {
+		return zero, &OutcomeUnknownError{Endpoint: endpoint, OrderID: orderID, Err: err, LookupErr: lookupErr}
+	}
+	settle := func(result T, err error) (T, error) {
+		if removeErr := store.Remove(context.WithoutCancel(ctx), key); removeErr != nil && err == nil {
+			return result, removeErr
+		}
+		return result, err
+	}
+
+	// 之前的请求结果不明确（例如进程崩溃），先确认是否已经创建。
+	resubmit, err := store.Contains(ctx, key)
+	if err != nil {
+		return zero, err
+	}
+	if resubmit {
+		result, found, lookupErr := lookupOrder(ctx, c.opts.LookupTimeout, lookup)
+		if lookupErr != nil {
+			return unknown(nil, lookupErr)
+		}
+		if found {
+			return settle(result, nil)
+		}
+	} else if err := store.Add(ctx, key); err != nil {
+		return zero, err
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= c.opts.MaxAttempts; attempt++ {
+		if attempt > 1 && ctx.Err() != nil {
+			break
+		}
+
+		result, err := create(ctx)
+		if err == nil {
+			return settle(result, nil)
+		}
+
+		if !ambiguous(err) {
+			// order_id 已存在或重新提交被拒绝时，之前的请求可能已经成功：
+			// 查询不到时无法确定结果，保留 InFlightStore 中的记录，避免调用方换一个 order_id 重复创建。
+			if resubmit || orderIDRejected(err) {
+				result, found, lookupErr := lookupOrder(ctx, c.opts.LookupTimeout, lookup)
+				if lookupErr == nil && found {
+					return settle(result, nil)
+				}
+				return unknown(err, lookupErr)
+			}
+			return settle(zero, err)
+		}
+		lastErr = err
+
+		result, found, lookupErr := lookupOrder(ctx, c.opts.LookupTimeout, lookup)
+		if lookupErr != nil {
+			return unknown(err, lookupErr)
+		}
+		if found {
+			return settle(result, nil)
+		}
+		resubmit = true
+	}
+
+	return unknown(lastErr, nil)
+}
+
+// idempotencyKey 返回 InFlightStore 中使用的 key。
+func idempotencyKey(merchant string, endpoint Endpoint, orderID string) string {
+	return merchant + ":" + endpoint.String() + ":" + orderID
+}
+
+// lookupOrder 按 order_id 查询，不受 ctx 取消的影响。没有找到或 lookup 为 nil 时 found 为 false，err 为 nil。
+func lookupOrder[T any](ctx context.Context, timeout time.Duration, lookup func(context.Context) (T, error)) (result T, found bool, err error) {
+	if lookup == nil {
+		return result, false, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+
+	result, err = lookup(ctx)
+	switch {
+	case err == nil:
+		return result, true, nil
+	case IsNotFound(err):
+		return result, false, nil
+	}
+
+	return result, false, err
+}
+
+// orderIDRejected 判断 Cryptomus 是否因为 order_id 拒绝了请求，例如 order_id 已被使用。
+func orderIDRejected(err error) bool {
+	apiErr, ok := AsAPIError(err)
+	return ok && len(apiErr.Errors["order_id"]) > 0
+}
+
+// ambiguous 判断创建请求失败后 Cryptomus 是否可能已经处理了请求。
+func ambiguous(err error) bool {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) || errors.Is(err, ErrCircuitOpen) || errors.Is(err, errLoadCredentials) {
+		return false
+	}
+
+	if apiErr, ok := AsAPIError(err); ok {
+		return apiErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	return true
+}
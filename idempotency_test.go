@@ -0,0 +1,88 @@
+package cryptomus_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	cryptomus "github.com/difyz9/cryptomus-sdk-go"
+	"github.com/difyz9/cryptomus-sdk-go/cryptomustest"
+)
+
+// TestIdempotentPayoutDuplicateOrderID 覆盖：创建超时（请求实际已处理）→ 查询不到 → 重新提交被拒绝（order_id 已被使用）。
+// 此时结果无法确定，必须返回 ErrPayoutOutcomeUnknown 并保留 InFlightStore 中的记录，而不是当作失败。
+func TestIdempotentPayoutDuplicateOrderID(t *testing.T) {
+	srv := cryptomustest.NewServer()
+	defer srv.Close()
+	srv.SetBalance("USDT", cryptomus.MustParseAmount("1000"))
+
+	var creates atomic.Int32
+	var hideLookup atomic.Bool
+	hideLookup.Store(true)
+
+	sdk := srv.Client(cryptomus.WithMiddleware(func(next cryptomus.Handler) cryptomus.Handler {
+		return func(ctx context.Context, req *cryptomus.Request) (*cryptomus.Response, error) {
+			switch req.Endpoint {
+			case cryptomus.CreatePayoutEndpoint:
+				resp, err := next(ctx, req)
+				if creates.Add(1) == 1 {
+					// 请求已经被处理，但响应丢失。
+					return nil, context.DeadlineExceeded
+				}
+				return resp, err
+			case cryptomus.PayoutInformationEndpoint:
+				if hideLookup.Load() {
+					return cryptomus.NewResponse(http.StatusNotFound, []byte(`{"state":1,"message":"Not found"}`)), nil
+				}
+			}
+			return next(ctx, req)
+		}
+	}))
+
+	store := cryptomus.NewMemoryInFlightStore()
+	client := sdk.Idempotent(cryptomus.IdempotencyOptions{Store: store})
+	payload := &cryptomus.CreatePayoutRequest{
+		Amount:   cryptomus.MustParseAmount("100"),
+		Currency: "USDT",
+		Network:  "tron",
+		Address:  "TXYZ",
+		OrderID:  "payout-1",
+	}
+
+	_, err := client.CreatePayout(context.Background(), payload)
+	if !errors.Is(err, cryptomus.ErrPayoutOutcomeUnknown) {
+		t.Fatalf("CreatePayout error = %v, want ErrPayoutOutcomeUnknown", err)
+	}
+	if _, ok := cryptomus.AsAPIError(err); !ok {
+		t.Errorf("CreatePayout error = %v, want it to wrap the duplicate order_id APIError", err)
+	}
+	if got := creates.Load(); got != 2 {
+		t.Errorf("create requests = %d, want 2", got)
+	}
+
+	key := sdk.Merchant + ":" + cryptomus.CreatePayoutEndpoint.String() + ":" + payload.OrderID
+	if ok, _ := store.Contains(context.Background(), key); !ok {
+		t.Fatalf("in-flight key %q was removed, want it kept", key)
+	}
+
+	// 查询恢复后使用相同的 order_id 重试，返回已经创建的提现，不会重复付款。
+	hideLookup.Store(false)
+	result, err := client.CreatePayout(context.Background(), payload)
+	if err != nil {
+		t.Fatalf("retry CreatePayout: %v", err)
+	}
+	if result.Result.UUID == "" {
+		t.Error("retry CreatePayout returned an empty payout")
+	}
+	if got := creates.Load(); got != 2 {
+		t.Errorf("create requests after retry = %d, want 2", got)
+	}
+	if ok, _ := store.Contains(context.Background(), key); ok {
+		t.Error("in-flight key kept after the payout was found")
+	}
+	if got, want := srv.Balance("USDT"), cryptomus.MustParseAmount("900"); !got.Equal(want) {
+		t.Errorf("balance = %s, want %s (exactly one payout)", got, want)
+	}
+}
@@ -0,0 +1,185 @@
+package cryptomus
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"time"
+)
+
+// historyDateLayout 是历史记录接口 date_from/date_to 参数的时间格式。
+const historyDateLayout = "2006-01-02 15:04:05"
+
+// ErrBackwardWithoutCursor 表示使用了 PageBackward 而没有通过 PageCursor 指定起始的游标。
+var ErrBackwardWithoutCursor = errors.New("cryptomus: PageBackward requires a starting PageCursor")
+
+// PageOption 配置 AllPayments、AllPayouts 与 AllRecurringPayments 的分页方式。
+type PageOption func(*pageOptions)
+
+type pageOptions struct {
+	cursor   string
+	backward bool
+}
+
+// PageCursor 从指定的游标开始分页，例如上一次中断时保存的 Pagination.NextCursor。
+func PageCursor(cursor string) PageOption {
+	return func(o *pageOptions) {
+		o.cursor = cursor
+	}
+}
+
+// PageBackward 沿 Pagination.PreviousCursor 向前翻页，必须同时使用 PageCursor 指定起始的游标，
+// 否则迭代器只返回 ErrBackwardWithoutCursor：没有游标时 Cryptomus 返回第一页，它之前没有更多的记录。
+//
+// 示例：
+//
+//	for payment, err := range sdk.AllPayments(ctx, from, to, cryptomus.PageCursor(page.PreviousCursor), cryptomus.PageBackward()) {
+//		// ...
+//	}
+func PageBackward() PageOption {
+	return func(o *pageOptions) {
+		o.backward = true
+	}
+}
+
+// AllPayments 返回 [from, to] 时间范围内所有支付记录的迭代器，自动跟随分页游标。
+//
+// from 或 to 为零值时不限制对应的边界。出错或 ctx 被取消时，迭代器返回该错误并停止。
+//
+// 示例：
+//
+//	for payment, err := range sdk.AllPayments(ctx, from, to) {
+//		if err != nil {
+//			log.Fatal(err)
+//		}
+//		log.Println(payment.OrderID, payment.PaymentStatus)
+//	}
+func (sdk *Cryptomus) AllPayments(ctx context.Context, from, to time.Time, opts ...PageOption) iter.Seq2[CreateInvoiceData, error] {
+	return paginate(ctx, opts, func(ctx context.Context, cursor string) ([]CreateInvoiceData, *Pagination, error) {
+		result, err := sdk.PaymentHistoryWithContext(ctx, &PaymentHistoryRequest{
+			DateFrom: formatHistoryDate(from),
+			DateTo:   formatHistoryDate(to),
+			Cursor:   cursor,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return result.Result.Items, result.Result.Paginate, nil
+	})
+}
+
+// AllPayouts 返回 [from, to] 时间范围内所有提现记录的迭代器，自动跟随分页游标。
+//
+// 示例：
+//
+//	for payout, err := range sdk.AllPayouts(ctx, from, to) {
+//		if err != nil {
+//			log.Fatal(err)
+//		}
+//		log.Println(payout.UUID, payout.Status)
+//	}
+func (sdk *Cryptomus) AllPayouts(ctx context.Context, from, to time.Time, opts ...PageOption) iter.Seq2[*PayoutData, error] {
+	return paginate(ctx, opts, func(ctx context.Context, cursor string) ([]*PayoutData, *Pagination, error) {
+		result, err := sdk.PayoutHistoryWithContext(ctx, &PayoutHistoryRequest{
+			DateFrom: formatHistoryDate(from),
+			DateTo:   formatHistoryDate(to),
+			Cursor:   cursor,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if result.Result == nil {
+			return nil, nil, nil
+		}
+
+		return result.Result.Items, result.Result.Paginate, nil
+	})
+}
+
+// AllRecurringPayments 返回所有循环支付的迭代器，自动跟随分页游标。
+//
+// 示例：
+//
+//	for recurring, err := range sdk.AllRecurringPayments(ctx) {
+//		if err != nil {
+//			log.Fatal(err)
+//		}
+//		log.Println(recurring.UUID, recurring.Status)
+//	}
+func (sdk *Cryptomus) AllRecurringPayments(ctx context.Context, opts ...PageOption) iter.Seq2[RecurringPaymentData, error] {
+	return paginate(ctx, opts, func(ctx context.Context, cursor string) ([]RecurringPaymentData, *Pagination, error) {
+		result, err := sdk.ListRecurringPaymentsWithContext(ctx, ListRecurringPaymentsRequest{
+			Cursor: cursor,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return result.Result.Items, result.Result.Paginate, nil
+	})
+}
+
+func paginate[T any](ctx context.Context, opts []PageOption, fetch func(ctx context.Context, cursor string) ([]T, *Pagination, error)) iter.Seq2[T, error] {
+	var o pageOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return func(yield func(T, error) bool) {
+		var zero T
+
+		if o.backward && o.cursor == "" {
+			yield(zero, ErrBackwardWithoutCursor)
+			return
+		}
+
+		cursor := o.cursor
+		seen := map[string]bool{cursor: true}
+
+		for {
+			if err := ctx.Err(); err != nil {
+				yield(zero, err)
+				return
+			}
+
+			items, page, err := fetch(ctx, cursor)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+
+			if page == nil {
+				return
+			}
+
+			next := page.NextCursor
+			if o.backward {
+				next = page.PreviousCursor
+			}
+
+			// 游标为空或重复出现时说明已经到达最后一页。
+			if next == "" || seen[next] {
+				return
+			}
+
+			seen[next] = true
+			cursor = next
+		}
+	}
+}
+
+func formatHistoryDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(historyDateLayout)
+}
@@ -0,0 +1,220 @@
+package cryptomus
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+// fakePages 用中间件模拟分页接口，按请求的 cursor 返回 pages 中的响应，并记录请求的游标。
+type fakePages struct {
+	endpoint Endpoint
+	pages    map[string]string
+	cursors  []string
+}
+
+func (f *fakePages) middleware(next Handler) Handler {
+	return func(ctx context.Context, req *Request) (*Response, error) {
+		if req.Endpoint != f.endpoint {
+			return next(ctx, req)
+		}
+
+		cursor := req.Query["cursor"]
+		f.cursors = append(f.cursors, cursor)
+
+		body, ok := f.pages[cursor]
+		if !ok {
+			return NewResponse(http.StatusNotFound, []byte(`{"state":1,"message":"cursor not found"}`)), nil
+		}
+
+		return NewResponse(http.StatusOK, []byte(body)), nil
+	}
+}
+
+func newPagesSDK(pages *fakePages) *Cryptomus {
+	return New(WithMerchant("merchant"), WithPaymentToken("payment"), WithPayoutToken("payout"), WithMiddleware(pages.middleware))
+}
+
+func TestAllPayments(t *testing.T) {
+	pages := &fakePages{endpoint: PaymentHistoryEndpoint, pages: map[string]string{
+		"":   `{"state":0,"result":{"items":[{"order_id":"a"},{"order_id":"b"}],"paginate":{"nextCursor":"c2"}}}`,
+		"c2": `{"state":0,"result":{"items":[{"order_id":"c"}],"paginate":{"nextCursor":"c3"}}}`,
+		"c3": `{"state":0,"result":{"items":[{"order_id":"d"}],"paginate":{"previousCursor":"c2"}}}`,
+	}}
+	sdk := newPagesSDK(pages)
+
+	collectOrders := func(opts ...PageOption) ([]string, error) {
+		var orders []string
+		for payment, err := range sdk.AllPayments(context.Background(), time.Time{}, time.Time{}, opts...) {
+			if err != nil {
+				return orders, err
+			}
+			orders = append(orders, payment.OrderID)
+		}
+		return orders, nil
+	}
+
+	tests := []struct {
+		name    string
+		opts    []PageOption
+		want    []string
+		cursors []string
+		err     error
+	}{
+		{name: "forward", want: []string{"a", "b", "c", "d"}, cursors: []string{"", "c2", "c3"}},
+		{name: "from cursor", opts: []PageOption{PageCursor("c2")}, want: []string{"c", "d"}, cursors: []string{"c2", "c3"}},
+		{name: "backward", opts: []PageOption{PageCursor("c3"), PageBackward()}, want: []string{"d", "c"}, cursors: []string{"c3", "c2"}},
+		{name: "backward without cursor", opts: []PageOption{PageBackward()}, err: ErrBackwardWithoutCursor},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pages.cursors = nil
+
+			got, err := collectOrders(tt.opts...)
+			if !slices.Equal(got, tt.want) || !slices.Equal(pages.cursors, tt.cursors) {
+				t.Errorf("orders = %v, cursors = %v, want %v and %v", got, pages.cursors, tt.want, tt.cursors)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("err = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestAllPayouts(t *testing.T) {
+	pages := &fakePages{endpoint: PayoutHistoryEndpoint, pages: map[string]string{
+		"":   `{"state":0,"result":{"merchant_uuid":"m","items":[{"uuid":"p1"}],"paginate":{"nextCursor":"c2"}}}`,
+		"c2": `{"state":0,"result":null}`,
+	}}
+	sdk := newPagesSDK(pages)
+
+	var got []string
+	for payout, err := range sdk.AllPayouts(context.Background(), time.Time{}, time.Time{}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, payout.UUID)
+	}
+
+	if !slices.Equal(got, []string{"p1"}) || !slices.Equal(pages.cursors, []string{"", "c2"}) {
+		t.Errorf("payouts = %v, cursors = %v", got, pages.cursors)
+	}
+}
+
+func TestAllRecurringPayments(t *testing.T) {
+	pages := &fakePages{endpoint: ListRecurringPaymentsEndpoint, pages: map[string]string{
+		"":   `{"state":0,"result":{"items":[{"uuid":"r1"},{"uuid":"r2"}],"paginate":{"nextCursor":"c2"}}}`,
+		"c2": `{"state":0,"result":{"items":[{"uuid":"r3"}],"paginate":{"nextCursor":"c2"}}}`,
+	}}
+	sdk := newPagesSDK(pages)
+
+	// 提前结束迭代时不再请求下一页。
+	for recurring, err := range sdk.AllRecurringPayments(context.Background()) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if recurring.UUID == "r1" {
+			break
+		}
+	}
+	if !slices.Equal(pages.cursors, []string{""}) {
+		t.Errorf("cursors after break = %v", pages.cursors)
+	}
+
+	// 重复出现的游标说明已经到达最后一页。
+	pages.cursors = nil
+	var got []string
+	for recurring, err := range sdk.AllRecurringPayments(context.Background()) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, recurring.UUID)
+	}
+	if !slices.Equal(got, []string{"r1", "r2", "r3"}) || !slices.Equal(pages.cursors, []string{"", "c2"}) {
+		t.Errorf("recurring = %v, cursors = %v", got, pages.cursors)
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	errFetch := errors.New("fetch failed")
+
+	t.Run("error after items", func(t *testing.T) {
+		fetch := func(_ context.Context, cursor string) ([]int, *Pagination, error) {
+			if cursor == "" {
+				return []int{1, 2}, &Pagination{NextCursor: "next"}, nil
+			}
+			return nil, nil, errFetch
+		}
+
+		var (
+			got  []int
+			errs []error
+		)
+		for item, err := range paginate(context.Background(), nil, fetch) {
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			got = append(got, item)
+		}
+		if !slices.Equal(got, []int{1, 2}) || len(errs) != 1 || !errors.Is(errs[0], errFetch) {
+			t.Errorf("items = %v, errors = %v", got, errs)
+		}
+	})
+
+	t.Run("stop on error", func(t *testing.T) {
+		calls := 0
+		fetch := func(context.Context, string) ([]int, *Pagination, error) {
+			calls++
+			return nil, nil, errFetch
+		}
+
+		for _, err := range paginate(context.Background(), nil, fetch) {
+			if err != nil {
+				break
+			}
+		}
+		if calls != 1 {
+			t.Errorf("fetch called %d times", calls)
+		}
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		fetch := func(context.Context, string) ([]int, *Pagination, error) {
+			t.Fatal("fetch called after cancel")
+			return nil, nil, nil
+		}
+		for _, err := range paginate(ctx, nil, fetch) {
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("err = %v, want context.Canceled", err)
+			}
+		}
+	})
+
+	t.Run("cursor cycle", func(t *testing.T) {
+		var cursors []string
+		fetch := func(_ context.Context, cursor string) ([]int, *Pagination, error) {
+			cursors = append(cursors, cursor)
+			next := map[string]string{"": "a", "a": "b", "b": "a"}[cursor]
+			return []int{len(cursors)}, &Pagination{NextCursor: next}, nil
+		}
+
+		var got []int
+		for item, err := range paginate(context.Background(), nil, fetch) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, item)
+		}
+		if !slices.Equal(got, []int{1, 2, 3}) || !slices.Equal(cursors, []string{"", "a", "b"}) {
+			t.Errorf("items = %v, cursors = %v", got, cursors)
+		}
+	})
+}
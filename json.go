@@ -1,6 +1,15 @@
 package cryptomus
 
-import "encoding/json"
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
 
 // ToJSON 将结构体转换为 JSON 字节数组。
 //
@@ -54,3 +63,305 @@ func FromJSONString[T any](v string) (T, error) {
 
 	return res, nil
 }
+
+// JSONEscaping 决定请求体的 JSON 转义规则。
+type JSONEscaping int
+
+const (
+	// JSONEscapingGo 使用 encoding/json 的默认规则：<、>、& 被转义为 \u003c 等形式，斜杠保持原样。
+	JSONEscapingGo JSONEscaping = iota
+	// JSONEscapingPHP 与 PHP json_encode($data, JSON_UNESCAPED_UNICODE) 一致：斜杠被转义为 \/，
+	// <、>、& 与非 ASCII 字符保持原样。
+	JSONEscapingPHP
+)
+
+// WithJSONEscaping 设置请求体的 JSON 转义规则，默认为 JSONEscapingGo。
+//
+// 无论使用哪种规则，请求体都只序列化一次，签名与发送使用完全相同的字节。
+// 如果需要与 PHP 计算的签名逐字节一致（例如 url_callback 或 additional_data 中包含 / 或 <>&），请使用 JSONEscapingPHP。
+//
+// 示例：
+//
+//	sdk := cryptomus.New(
+//		cryptomus.WithMerchant(merchantID),
+//		cryptomus.WithPaymentToken(paymentToken),
+//		cryptomus.WithJSONEscaping(cryptomus.JSONEscapingPHP),
+//	)
+func WithJSONEscaping(escaping JSONEscaping) Option {
+	return func(c *Cryptomus) {
+		c.escaping = escaping
+	}
+}
+
+// EncodeRequest 按照 SDK 实例的转义规则序列化请求体，返回的字节即为签名与发送的内容。payload 为 nil 时返回 nil。
+func (sdk *Cryptomus) EncodeRequest(payload any) ([]byte, error) {
+	if payload == nil {
+		return nil, nil
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("cryptomus: encode request: %w", err)
+	}
+
+	if sdk.escaping == JSONEscapingPHP {
+		body, _, err = phpJSON{}.encode(body, "")
+		if err != nil {
+			return nil, fmt.Errorf("cryptomus: encode request: %w", err)
+		}
+	}
+
+	return body, nil
+}
+
+// phpJSON 按 PHP json_encode($data, JSON_UNESCAPED_UNICODE) 的规则重新序列化 JSON。
+//
+// Cryptomus 使用 PHP 计算签名，因此与 Go 的 encoding/json 存在差异：
+// 斜杠会被转义为 \/，而 <、>、& 与非 ASCII 字符保持原样。
+// 对象的键顺序与原始数据保持一致。
+type phpJSON struct {
+	// assoc 模拟 json_decode($body, true) 之后再 json_encode 的行为：
+	// 空对象以及键为 0..n-1 的对象会被编码为数组。
+	assoc bool
+}
+
+// encode 重新序列化 raw。如果 omitKey 不为空，顶层对象中的该键会被移除，其原始值通过第二个返回值返回。
+func (p phpJSON) encode(raw []byte, omitKey string) ([]byte, json.RawMessage, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+
+	var (
+		buf     bytes.Buffer
+		omitted json.RawMessage
+	)
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if delim, ok := tok.(json.Delim); ok && delim == '{' && omitKey != "" {
+		omitted, err = p.object(dec, &buf, omitKey)
+	} else {
+		err = p.value(dec, &buf, tok)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, nil, errors.New("cryptomus: unexpected data after top-level JSON value")
+	}
+
+	return buf.Bytes(), omitted, nil
+}
+
+func (p phpJSON) value(dec *json.Decoder, buf *bytes.Buffer, tok json.Token) error {
+	switch v := tok.(type) {
+	case json.Delim:
+		switch v {
+		case '{':
+			_, err := p.object(dec, buf, "")
+			return err
+		case '[':
+			return p.array(dec, buf)
+		}
+		return fmt.Errorf("cryptomus: unexpected JSON delimiter %q", v)
+	case string:
+		writePHPString(buf, v)
+	case json.Number:
+		buf.WriteString(phpNumber(v))
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	case nil:
+		buf.WriteString("null")
+	default:
+		return fmt.Errorf("cryptomus: unexpected JSON token %T", tok)
+	}
+
+	return nil
+}
+
+func (p phpJSON) object(dec *json.Decoder, buf *bytes.Buffer, omitKey string) (json.RawMessage, error) {
+	type member struct {
+		key   string
+		value []byte
+	}
+
+	var (
+		members []member
+		omitted json.RawMessage
+	)
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		key, ok := tok.(string)
+		if !ok {
+			return nil, fmt.Errorf("cryptomus: unexpected JSON object key %T", tok)
+		}
+
+		if omitKey != "" && key == omitKey {
+			if err := dec.Decode(&omitted); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		tok, err = dec.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		var value bytes.Buffer
+		if err := p.value(dec, &value, tok); err != nil {
+			return nil, err
+		}
+
+		members = append(members, member{key: key, value: value.Bytes()})
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+
+	isList := p.assoc
+	for i, m := range members {
+		if !isList || m.key != strconv.Itoa(i) {
+			isList = false
+			break
+		}
+	}
+
+	if isList {
+		buf.WriteByte('[')
+		for i, m := range members {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.Write(m.value)
+		}
+		buf.WriteByte(']')
+		return omitted, nil
+	}
+
+	buf.WriteByte('{')
+	for i, m := range members {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		writePHPString(buf, m.key)
+		buf.WriteByte(':')
+		buf.Write(m.value)
+	}
+	buf.WriteByte('}')
+
+	return omitted, nil
+}
+
+func (p phpJSON) array(dec *json.Decoder, buf *bytes.Buffer) error {
+	buf.WriteByte('[')
+
+	for i := 0; dec.More(); i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+
+		if err := p.value(dec, buf, tok); err != nil {
+			return err
+		}
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+
+	buf.WriteByte(']')
+
+	return nil
+}
+
+// phpNumber 按 PHP json_encode（serialize_precision = -1）的规则格式化数字：整数保持原样，
+// 浮点数使用能还原该值的最短数字。绝对值小于 1e-4 或不小于 1e15 时使用指数形式，
+// 尾数只有一位时补上 ".0"，指数不补零（例如 1.0e-5、1.2e+20）；否则使用普通形式并保留小数点（例如 1234567.5、10.0）。
+func phpNumber(n json.Number) string {
+	s := n.String()
+	if !strings.ContainsAny(s, ".eE") {
+		return s
+	}
+
+	f, err := n.Float64()
+	if err != nil || math.IsInf(f, 0) {
+		return s
+	}
+
+	// 科学计数法的形式为 d.ddde±XX，decpt 是小数点相对于第一位数字的位置，与 PHP 的 zend_dtoa 相同。
+	mantissa, exp, _ := strings.Cut(strconv.FormatFloat(f, 'e', -1, 64), "e")
+	exponent, _ := strconv.Atoi(exp)
+	decpt := exponent + 1
+
+	if f != 0 && (decpt < -3 || decpt > 15) {
+		if !strings.Contains(mantissa, ".") {
+			mantissa += ".0"
+		}
+		sign := "+"
+		if exponent < 0 {
+			sign, exponent = "-", -exponent
+		}
+		return mantissa + "e" + sign + strconv.Itoa(exponent)
+	}
+
+	s = strconv.FormatFloat(f, 'f', -1, 64)
+	if !strings.Contains(s, ".") {
+		s += ".0"
+	}
+
+	return s
+}
+
+func writePHPString(buf *bytes.Buffer, s string) {
+	const hex = "0123456789abcdef"
+
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '/':
+			buf.WriteString(`\/`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		case '\u2028':
+			buf.WriteString(`\u2028`)
+		case '\u2029':
+			buf.WriteString(`\u2029`)
+		default:
+			if r < 0x20 {
+				buf.WriteString(`\u00`)
+				buf.WriteByte(hex[r>>4])
+				buf.WriteByte(hex[r&0xf])
+				continue
+			}
+			buf.WriteRune(r)
+		}
+	}
+	buf.WriteByte('"')
+}
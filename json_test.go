@@ -0,0 +1,179 @@
+package cryptomus
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestPHPNumber 的期望值是 PHP 8 中 json_encode 在 serialize_precision = -1（默认值）下的输出，
+// 整数形式的浮点数按输入保留 ".0"。
+func TestPHPNumber(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"100", "100"},
+		{"-7", "-7"},
+		{"0.1", "0.1"},
+		{"10.0", "10.0"},
+		{"0.0", "0.0"},
+		{"1234567.5", "1234567.5"},
+		{"1.2345675e+06", "1234567.5"},
+		{"0.30000000000000004", "0.30000000000000004"},
+		{"3.3333333333333335", "3.3333333333333335"},
+		{"0.0001", "0.0001"},
+		{"0.00001", "1.0e-5"},
+		{"1e-5", "1.0e-5"},
+		{"1.5e-7", "1.5e-7"},
+		{"-2.5E-10", "-2.5e-10"},
+		{"123456789012345.6", "123456789012345.6"},
+		{"999999999999999.0", "999999999999999.0"},
+		{"1e15", "1.0e+15"},
+		{"1.0e+25", "1.0e+25"},
+		{"1.2345e20", "1.2345e+20"},
+	}
+
+	for _, tt := range tests {
+		if got := phpNumber(json.Number(tt.in)); got != tt.want {
+			t.Errorf("phpNumber(%s) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+type encodeTestPayload struct {
+	Text   string         `json:"text,omitempty"`
+	Amount Amount         `json:"amount,omitzero"`
+	Count  int            `json:"count,omitempty"`
+	Rate   float64        `json:"rate,omitempty"`
+	Object map[string]any `json:"object,omitzero"`
+	List   []int          `json:"list,omitzero"`
+}
+
+func TestEncodeRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload any
+		goJSON  string
+		phpJSON string
+	}{
+		{name: "nil payload", payload: nil},
+		{
+			name:    "slashes",
+			payload: encodeTestPayload{Text: "https://example.com/webhook/cryptomus?a=1"},
+			goJSON:  `{"text":"https://example.com/webhook/cryptomus?a=1"}`,
+			phpJSON: `{"text":"https:\/\/example.com\/webhook\/cryptomus?a=1"}`,
+		},
+		{
+			name:    "html characters",
+			payload: encodeTestPayload{Text: "<b>Tom & Jerry</b>"},
+			goJSON:  `{"text":"\u003cb\u003eTom \u0026 Jerry\u003c/b\u003e"}`,
+			phpJSON: `{"text":"<b>Tom & Jerry<\/b>"}`,
+		},
+		{
+			name:    "non-ASCII",
+			payload: encodeTestPayload{Text: "Оплата заказа №42 — 支付 €"},
+			goJSON:  `{"text":"Оплата заказа №42 — 支付 €"}`,
+			phpJSON: `{"text":"Оплата заказа №42 — 支付 €"}`,
+		},
+		{
+			name:    "line terminators and control characters",
+			payload: encodeTestPayload{Text: "a\u2028b\tc\"d\\e\x01"},
+			goJSON:  `{"text":"a\u2028b\tc\"d\\e\u0001"}`,
+			phpJSON: `{"text":"a\u2028b\tc\"d\\e\u0001"}`,
+		},
+		{
+			name:    "empty object and array",
+			payload: encodeTestPayload{Object: map[string]any{}, List: []int{}},
+			goJSON:  `{"object":{},"list":[]}`,
+			phpJSON: `{"object":{},"list":[]}`,
+		},
+		{
+			name:    "nested braces and brackets in strings",
+			payload: encodeTestPayload{Text: `{"a":[1,2]}`, Object: map[string]any{"items": []any{map[string]any{"id": "x/y"}}}},
+			goJSON:  `{"text":"{\"a\":[1,2]}","object":{"items":[{"id":"x/y"}]}}`,
+			phpJSON: `{"text":"{\"a\":[1,2]}","object":{"items":[{"id":"x\/y"}]}}`,
+		},
+		{
+			name:    "numbers",
+			payload: encodeTestPayload{Amount: MustParseAmount("15.50"), Count: 3, Rate: 0.00001},
+			goJSON:  `{"amount":"15.50","count":3,"rate":0.00001}`,
+			phpJSON: `{"amount":"15.50","count":3,"rate":1.0e-5}`,
+		},
+		{
+			name:    "float with fraction",
+			payload: encodeTestPayload{Rate: 1234567.5},
+			goJSON:  `{"rate":1234567.5}`,
+			phpJSON: `{"rate":1234567.5}`,
+		},
+	}
+
+	goSDK := New()
+	phpSDK := New(WithJSONEscaping(JSONEscapingPHP))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, mode := range []struct {
+				sdk  *Cryptomus
+				want string
+			}{{goSDK, tt.goJSON}, {phpSDK, tt.phpJSON}} {
+				body, err := mode.sdk.EncodeRequest(tt.payload)
+				if err != nil {
+					t.Fatalf("EncodeRequest: %v", err)
+				}
+				if mode.want == "" {
+					if body != nil {
+						t.Errorf("EncodeRequest(nil) = %q, want nil", body)
+					}
+					continue
+				}
+				if string(body) != mode.want {
+					t.Errorf("EncodeRequest (escaping %d) = %s, want %s", mode.sdk.escaping, body, mode.want)
+				}
+			}
+		})
+	}
+}
+
+func TestPHPJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		in    string
+		assoc bool
+		want  string
+	}{
+		{name: "key order is kept", in: `{"b":1,"a":2}`, want: `{"b":1,"a":2}`},
+		{name: "whitespace is removed", in: "{ \"a\" : [ 1 , 2 ] }", want: `{"a":[1,2]}`},
+		{name: "escaped input is decoded", in: `{"url":"https:\/\/x.io\/<"}`, want: `{"url":"https:\/\/x.io\/<"}`},
+		{name: "unicode escapes become UTF-8", in: `{"name":"Оплата"}`, want: `{"name":"Оплата"}`},
+		{name: "empty object", in: `{"a":{}}`, want: `{"a":{}}`},
+		{name: "empty object as assoc array", in: `{"a":{}}`, assoc: true, want: `{"a":[]}`},
+		{name: "sequential keys as assoc array", in: `{"0":"x","1":"y"}`, assoc: true, want: `["x","y"]`},
+		{name: "non-sequential keys stay an object", in: `{"1":"x","2":"y"}`, assoc: true, want: `{"1":"x","2":"y"}`},
+		{name: "empty array", in: `[]`, want: `[]`},
+		{name: "scalars", in: `[true,false,null,"",0,-1.5,2e-7]`, want: `[true,false,null,"",0,-1.5,2.0e-7]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := phpJSON{assoc: tt.assoc}.encode([]byte(tt.in), "")
+			if err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("encode(%s) = %s, want %s", tt.in, got, tt.want)
+			}
+		})
+	}
+
+	got, omitted, err := phpJSON{}.encode([]byte(`{"type":"payment","sign":"abc","status":"paid"}`), "sign")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != `{"type":"payment","status":"paid"}` || string(omitted) != `"abc"` {
+		t.Errorf("encode with omitted sign = %s, %s", got, omitted)
+	}
+
+	if _, _, err := (phpJSON{}).encode([]byte(`{"a":1} {}`), ""); err == nil {
+		t.Error("encode accepted trailing data")
+	}
+}
@@ -0,0 +1,149 @@
+package cryptomus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// maxLoggedBodySize 是调试日志中请求体与响应体的最大长度，超出部分会被截断。
+const maxLoggedBodySize = 4 << 10
+
+// redacted 替换日志中的敏感值。
+const redacted = "[REDACTED]"
+
+// WithLogger 为 SDK 实例设置结构化日志，默认不记录日志。
+//
+// 每次 HTTP 请求（包括重试）会记录一条日志，包含 method、endpoint、status、latency、attempt
+// 以及 Cryptomus 返回的 state 与 code。成功的请求使用 Info 级别，API 错误使用 Warn 级别，网络错误使用 Error 级别。
+//
+// 当 logger 启用了 Debug 级别时，还会额外记录一条包含请求体与响应体的 Debug 日志。
+// 日志中不会出现 sign、merchant 请求头与密钥，请求体与响应体中的 sign、merchant、地址与 token 字段会被替换为 [REDACTED]。
+//
+// 示例：
+//
+//	logger := slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+//
+//	sdk := cryptomus.New(
+//		cryptomus.WithMerchant(merchantID),
+//		cryptomus.WithPaymentToken(paymentToken),
+//		cryptomus.WithLogger(logger),
+//	)
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Cryptomus) {
+		c.logger = logger
+	}
+}
+
+// logCall 记录一次 HTTP 请求的结果，status 为 0 表示请求没有得到响应。
+func (sdk *Cryptomus) logCall(ctx context.Context, call *Request, attempt, status int, latency time.Duration, body []byte, err error) {
+	logger := sdk.logger
+	if logger == nil {
+		return
+	}
+
+	level := slog.LevelInfo
+	attrs := []slog.Attr{
+		slog.String("method", call.Method),
+		slog.String("endpoint", call.Endpoint.String()),
+		slog.Int("attempt", attempt),
+		slog.Duration("latency", latency),
+	}
+	if status != 0 {
+		attrs = append(attrs, slog.Int("status", status))
+	}
+
+	var apiErr *APIError
+	switch {
+	case errors.As(err, &apiErr):
+		level = slog.LevelWarn
+		attrs = append(attrs, slog.Int("state", apiErr.State))
+		if apiErr.Code != 0 {
+			attrs = append(attrs, slog.Int("code", apiErr.Code))
+		}
+		if apiErr.Message != "" {
+			attrs = append(attrs, slog.String("message", apiErr.Message))
+		}
+	case err != nil:
+		level = slog.LevelError
+		attrs = append(attrs, slog.String("error", err.Error()))
+	default:
+		attrs = append(attrs, slog.Int("state", 0))
+	}
+
+	logger.LogAttrs(ctx, level, "cryptomus: request", attrs...)
+
+	if !logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+
+	logger.LogAttrs(ctx, slog.LevelDebug, "cryptomus: request body",
+		slog.String("method", call.Method),
+		slog.String("endpoint", call.Endpoint.String()),
+		slog.Int("attempt", attempt),
+		slog.String("request", redactBody(call.Body)),
+		slog.String("response", redactBody(body)),
+	)
+}
+
+// redactBody 返回适合写入日志的请求体或响应体：JSON 中的敏感字段会被替换，过长的内容会被截断。
+func redactBody(body []byte) string {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return ""
+	}
+
+	var value any
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err == nil {
+		if redactedBody, err := json.Marshal(redactValue(value)); err == nil {
+			body = redactedBody
+		}
+	}
+
+	if len(body) > maxLoggedBodySize {
+		return string(body[:maxLoggedBodySize]) + "...(truncated)"
+	}
+
+	return string(body)
+}
+
+func redactValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			// 只替换字符串值，例如余额响应中的 merchant 是余额列表，仍需要保留。
+			if s, ok := item.(string); ok && s != "" && sensitiveKey(key) {
+				v[key] = redacted
+				continue
+			}
+			v[key] = redactValue(item)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+	}
+
+	return value
+}
+
+// sensitiveKey 判断 JSON 字段是否包含签名、商户 ID、钱包地址或密钥。
+func sensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+
+	switch key {
+	case "sign", "merchant", "address", "from", "memo":
+		return true
+	}
+
+	return strings.HasSuffix(key, "_address") ||
+		strings.Contains(key, "token") ||
+		strings.Contains(key, "secret") ||
+		strings.Contains(key, "api_key")
+}
@@ -0,0 +1,251 @@
+package cryptomus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/imroc/req/v3"
+)
+
+// ErrUnknownMerchant 表示商户 ID 或标签没有在 MerchantRegistry 中注册。
+var ErrUnknownMerchant = errors.New("cryptomus: unknown merchant")
+
+// MerchantConfig 是注册到 MerchantRegistry 的商户账户。
+type MerchantConfig struct {
+	// Label 是商户的可选标签，例如品牌或地区（"eu"、"brand-a"），可以代替商户 ID 查找商户。
+	Label        string
+	Merchant     string
+	PaymentToken string
+	PayoutToken  string
+}
+
+// MerchantRegistry 管理多个商户账户，按商户 ID 或标签返回对应的 SDK 实例。
+//
+// 所有商户共用同一个 req.Client（连接池），以及创建注册表时传入的选项（日志、重试、API 地址等）。
+// 注册表的 WebhookHandler 按 Webhook 的 merchant_id 选择密钥校验签名。MerchantRegistry 可以在多个 goroutine 中使用。
+//
+// 示例：
+//
+//	registry := cryptomus.NewMerchantRegistry(cryptomus.WithLogger(logger))
+//	registry.Register(cryptomus.MerchantConfig{Label: "eu", Merchant: euMerchantID, PaymentToken: euPaymentToken})
+//	registry.Register(cryptomus.MerchantConfig{Label: "us", Merchant: usMerchantID, PaymentToken: usPaymentToken})
+//
+//	sdk, err := registry.Merchant("eu")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	result, err := sdk.CreateInvoice(payload)
+type MerchantRegistry struct {
+	client  *req.Client
+	options []Option
+
+	mu      sync.RWMutex
+	byID    map[string]*Cryptomus
+	byLabel map[string]*Cryptomus
+	labels  map[string]string
+}
+
+// NewMerchantRegistry 创建商户注册表，options 应用于每个注册的商户。
+//
+// options 中包含 WithHttpClient 时所有商户共用该客户端，否则共用一个 DefaultHTTPClient。
+func NewMerchantRegistry(options ...Option) *MerchantRegistry {
+	return &MerchantRegistry{
+		client:  New(options...).HttpClient,
+		options: options,
+		byID:    map[string]*Cryptomus{},
+		byLabel: map[string]*Cryptomus{},
+		labels:  map[string]string{},
+	}
+}
+
+// Register 注册商户并返回它的 SDK 实例，options 只应用于该商户，在注册表的选项之后应用。
+//
+// 商户 ID 为空，或商户 ID、标签已被注册时返回错误。
+func (r *MerchantRegistry) Register(config MerchantConfig, options ...Option) (*Cryptomus, error) {
+	if config.Merchant == "" {
+		return nil, errors.New("cryptomus: merchant id is required")
+	}
+
+	all := slices.Concat(r.options, []Option{
+		WithHttpClient(r.client),
+		WithMerchant(config.Merchant),
+		WithPaymentToken(config.PaymentToken),
+		WithPayoutToken(config.PayoutToken),
+	}, options)
+	sdk := New(all...)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.byID[config.Merchant]; ok {
+		return nil, fmt.Errorf("cryptomus: merchant %q is already registered", config.Merchant)
+	}
+	if _, ok := r.byLabel[config.Label]; ok && config.Label != "" {
+		return nil, fmt.Errorf("cryptomus: merchant label %q is already registered", config.Label)
+	}
+
+	r.byID[config.Merchant] = sdk
+	if config.Label != "" {
+		r.byLabel[config.Label] = sdk
+		r.labels[config.Merchant] = config.Label
+	}
+
+	return sdk, nil
+}
+
+// Remove 注销商户，idOrLabel 可以是商户 ID 或标签。商户不存在时返回 false。
+func (r *MerchantRegistry) Remove(idOrLabel string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sdk := r.lookup(idOrLabel)
+	if sdk == nil {
+		return false
+	}
+
+	delete(r.byID, sdk.Merchant)
+	if label, ok := r.labels[sdk.Merchant]; ok {
+		delete(r.byLabel, label)
+		delete(r.labels, sdk.Merchant)
+	}
+
+	return true
+}
+
+// Merchant 返回商户的 SDK 实例，idOrLabel 可以是商户 ID 或标签，优先按商户 ID 查找。
+//
+// 商户不存在时返回的错误满足 errors.Is(err, ErrUnknownMerchant)。
+func (r *MerchantRegistry) Merchant(idOrLabel string) (*Cryptomus, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if sdk := r.lookup(idOrLabel); sdk != nil {
+		return sdk, nil
+	}
+
+	return nil, fmt.Errorf("%w %q", ErrUnknownMerchant, idOrLabel)
+}
+
+// Label 返回商户 ID 对应的标签，没有标签时返回空字符串。
+func (r *MerchantRegistry) Label(merchantID string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.labels[merchantID]
+}
+
+// Merchants 返回所有已注册商户的 SDK 实例，按商户 ID 排序。
+func (r *MerchantRegistry) Merchants() []*Cryptomus {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.sorted()
+}
+
+func (r *MerchantRegistry) lookup(idOrLabel string) *Cryptomus {
+	if sdk, ok := r.byID[idOrLabel]; ok {
+		return sdk
+	}
+
+	return r.byLabel[idOrLabel]
+}
+
+func (r *MerchantRegistry) sorted() []*Cryptomus {
+	merchants := make([]*Cryptomus, 0, len(r.byID))
+	for _, sdk := range r.byID {
+		merchants = append(merchants, sdk)
+	}
+	slices.SortFunc(merchants, func(a, b *Cryptomus) int {
+		return strings.Compare(a.Merchant, b.Merchant)
+	})
+
+	return merchants
+}
+
+// ParseWebhook 校验 Webhook 签名并解析请求体，返回 Webhook 所属商户的 SDK 实例。
+//
+// 注册表按 Webhook 的 merchant_id 字段选择商户，只使用该商户的密钥校验签名。
+// 缺少 merchant_id 或商户未注册时返回的错误满足 errors.Is(err, ErrUnknownMerchant)。
+// Webhook 不包含 merchant_id 时，为每个商户使用单独的回调地址，并使用 registry.Merchant(idOrLabel) 返回的 SDK 实例校验。
+//
+// 示例：
+//
+//	sdk, webhook, err := registry.ParseWebhook(body)
+//	if err != nil {
+//	    http.Error(w, err.Error(), http.StatusUnauthorized)
+//	    return
+//	}
+//	log.Printf("merchant %s (%s): %T", sdk.Merchant, registry.Label(sdk.Merchant), webhook)
+func (r *MerchantRegistry) ParseWebhook(body []byte) (*Cryptomus, Webhook, error) {
+	webhook, err := decodeWebhook(body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	merchantID := webhookMerchantID(webhook)
+	if merchantID == "" {
+		return nil, nil, fmt.Errorf("%w: webhook has no merchant_id", ErrUnknownMerchant)
+	}
+
+	r.mu.RLock()
+	sdk, ok := r.byID[merchantID]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, nil, fmt.Errorf("%w %q", ErrUnknownMerchant, merchantID)
+	}
+
+	if err := sdk.VerifyWebhook(body); err != nil {
+		return nil, nil, err
+	}
+
+	return sdk, webhook, nil
+}
+
+func webhookMerchantID(webhook Webhook) string {
+	switch webhook := webhook.(type) {
+	case *PaymentWebhook:
+		return webhook.MerchantID
+	case *WalletWebhook:
+		return webhook.MerchantID
+	case *PayoutWebhook:
+		return webhook.MerchantID
+	case *RecurringWebhook:
+		return webhook.MerchantID
+	}
+
+	return ""
+}
+
+// WebhookHandler 返回接收所有已注册商户 Webhook 的 http.Handler，行为与 Cryptomus.WebhookHandler 相同。
+//
+// 签名按 ParseWebhook 的规则校验，缺少 merchant_id 或对应的商户未注册时响应 401。
+// 回调中可以通过 MerchantFromContext 获取 Webhook 所属的商户。
+//
+// 示例：
+//
+//	http.Handle("/webhook/cryptomus", registry.WebhookHandler(cryptomus.WebhookHandlerOptions{
+//		OnPayment: func(ctx context.Context, webhook *cryptomus.PaymentWebhook) error {
+//			sdk, _ := cryptomus.MerchantFromContext(ctx)
+//			return orders.MarkPaid(ctx, sdk.Merchant, webhook.OrderID, webhook.Status)
+//		},
+//	}))
+func (r *MerchantRegistry) WebhookHandler(opts WebhookHandlerOptions) http.Handler {
+	return newWebhookHandler(opts, r.ParseWebhook)
+}
+
+type merchantContextKey struct{}
+
+func withMerchant(ctx context.Context, sdk *Cryptomus) context.Context {
+	return context.WithValue(ctx, merchantContextKey{}, sdk)
+}
+
+// MerchantFromContext 返回 WebhookHandler 回调的 ctx 中接收该 Webhook 的 SDK 实例。
+func MerchantFromContext(ctx context.Context) (*Cryptomus, bool) {
+	sdk, ok := ctx.Value(merchantContextKey{}).(*Cryptomus)
+	return sdk, ok
+}
@@ -0,0 +1,185 @@
+package cryptomus_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	cryptomus "github.com/difyz9/cryptomus-sdk-go"
+	"github.com/difyz9/cryptomus-sdk-go/cryptomustest"
+)
+
+func TestMerchantRegistry(t *testing.T) {
+	registry := cryptomus.NewMerchantRegistry()
+
+	eu, err := registry.Register(cryptomus.MerchantConfig{Label: "eu", Merchant: "merchant-eu", PaymentToken: "eu-payment"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := registry.Register(cryptomus.MerchantConfig{Label: "us", Merchant: "merchant-us", PaymentToken: "us-payment"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, config := range []cryptomus.MerchantConfig{
+		{Label: "eu-2", Merchant: "merchant-eu"},
+		{Label: "eu", Merchant: "merchant-eu-2"},
+		{Label: "empty"},
+	} {
+		if _, err := registry.Register(config); err == nil {
+			t.Errorf("Register(%+v) succeeded", config)
+		}
+	}
+
+	for _, idOrLabel := range []string{"eu", "merchant-eu"} {
+		if sdk, err := registry.Merchant(idOrLabel); err != nil || sdk != eu {
+			t.Errorf("Merchant(%q) = %v, %v", idOrLabel, sdk, err)
+		}
+	}
+	if eu.HttpClient != registry.Merchants()[1].HttpClient {
+		t.Error("merchants do not share the HTTP client")
+	}
+
+	if !registry.Remove("us") || registry.Remove("us") {
+		t.Error("Remove did not remove the merchant exactly once")
+	}
+	if _, err := registry.Merchant("merchant-us"); !errors.Is(err, cryptomus.ErrUnknownMerchant) {
+		t.Errorf("Merchant after Remove: %v, want ErrUnknownMerchant", err)
+	}
+	if registry.Label("merchant-eu") != "eu" || registry.Label("merchant-us") != "" {
+		t.Error("labels not updated after Remove")
+	}
+}
+
+func TestMerchantRegistryParseWebhook(t *testing.T) {
+	registry := cryptomus.NewMerchantRegistry()
+	for _, config := range []cryptomus.MerchantConfig{
+		{Merchant: "merchant-a", PaymentToken: "a-payment", PayoutToken: "a-payout"},
+		{Merchant: "merchant-b", PaymentToken: "b-payment", PayoutToken: "b-payout"},
+	} {
+		if _, err := registry.Register(config); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sign := func(body, key string) []byte {
+		t.Helper()
+		signed, err := cryptomus.SignWebhook([]byte(body), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	tests := []struct {
+		name     string
+		body     []byte
+		merchant string
+		err      error
+	}{
+		{
+			name:     "payment",
+			body:     sign(`{"type":"payment","uuid":"p1","order_id":"1","status":"paid","merchant_id":"merchant-b"}`, "b-payment"),
+			merchant: "merchant-b",
+		},
+		{
+			name:     "payout",
+			body:     sign(`{"type":"payout","uuid":"p2","order_id":"2","status":"paid","merchant_id":"merchant-a"}`, "a-payout"),
+			merchant: "merchant-a",
+		},
+		{
+			// 只使用 merchant_id 对应商户的密钥，其他商户的密钥签名无效。
+			name: "signed by another merchant",
+			body: sign(`{"type":"payment","uuid":"p3","order_id":"3","status":"paid","merchant_id":"merchant-a"}`, "b-payment"),
+			err:  cryptomus.ErrWebhookSignatureInvalid,
+		},
+		{
+			name: "without merchant_id",
+			body: sign(`{"type":"payment","uuid":"p4","order_id":"4","status":"paid"}`, "a-payment"),
+			err:  cryptomus.ErrUnknownMerchant,
+		},
+		{
+			name: "unknown merchant",
+			body: sign(`{"type":"payment","uuid":"p5","order_id":"5","status":"paid","merchant_id":"merchant-c"}`, "a-payment"),
+			err:  cryptomus.ErrUnknownMerchant,
+		},
+		{
+			name: "unsigned",
+			body: []byte(`{"type":"payment","uuid":"p6","order_id":"6","status":"paid","merchant_id":"merchant-a"}`),
+			err:  cryptomus.ErrWebhookSignatureMissing,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sdk, webhook, err := registry.ParseWebhook(tt.body)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if tt.err != nil {
+				return
+			}
+			if sdk.Merchant != tt.merchant || webhook == nil {
+				t.Errorf("merchant = %s, webhook = %+v, want %s", sdk.Merchant, webhook, tt.merchant)
+			}
+		})
+	}
+}
+
+func TestMerchantRegistryWebhookHandler(t *testing.T) {
+	eu := cryptomustest.NewServer(cryptomustest.WithCredentials("merchant-eu", "eu-payment", "eu-payout"))
+	defer eu.Close()
+	us := cryptomustest.NewServer(cryptomustest.WithCredentials("merchant-us", "us-payment", "us-payout"))
+	defer us.Close()
+
+	registry := cryptomus.NewMerchantRegistry()
+	for label, srv := range map[string]*cryptomustest.Server{"eu": eu, "us": us} {
+		config := cryptomus.MerchantConfig{Label: label, Merchant: srv.Merchant, PaymentToken: srv.PaymentToken, PayoutToken: srv.PayoutToken}
+		if _, err := registry.Register(config, cryptomus.WithBaseURL(srv.URL)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var paid []string
+	callback := httptest.NewServer(registry.WebhookHandler(cryptomus.WebhookHandlerOptions{
+		OnPayment: func(ctx context.Context, webhook *cryptomus.PaymentWebhook) error {
+			sdk, _ := cryptomus.MerchantFromContext(ctx)
+			paid = append(paid, registry.Label(sdk.Merchant)+" "+webhook.OrderID)
+			return nil
+		},
+	}))
+	defer callback.Close()
+
+	for label, srv := range map[string]*cryptomustest.Server{"eu": eu, "us": us} {
+		sdk, err := registry.Merchant(label)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := sdk.CreateInvoice(&cryptomus.CreateInvoiceRequest{
+			Amount:      cryptomus.MustParseAmount("10"),
+			Currency:    "USDT",
+			OrderID:     "order-" + label,
+			URLCallback: callback.URL,
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if err := srv.SetPaymentStatus("order-"+label, cryptomus.PaymentStatusPaid); err != nil {
+			t.Errorf("%s webhook: %v", label, err)
+		}
+	}
+
+	slices.Sort(paid)
+	if !slices.Equal(paid, []string{"eu order-eu", "us order-us"}) {
+		t.Errorf("paid = %v", paid)
+	}
+
+	registry.Remove("us")
+	if err := us.SetPaymentStatus("order-us", cryptomus.PaymentStatusPaid); err == nil {
+		t.Error("webhook of a removed merchant acknowledged")
+	}
+	if deliveries := us.Webhooks(); deliveries[len(deliveries)-1].StatusCode != http.StatusUnauthorized {
+		t.Errorf("removed merchant webhook status = %d, want 401", deliveries[len(deliveries)-1].StatusCode)
+	}
+}
@@ -0,0 +1,167 @@
+package cryptomus
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"slices"
+)
+
+// TokenKind 表示请求使用哪一个密钥签名。
+type TokenKind int
+
+const (
+	TokenNone    TokenKind = iota // 不签名
+	TokenPayment                  // 使用 PaymentToken 签名
+	TokenPayout                   // 使用 PayoutToken 签名
+)
+
+func (k TokenKind) String() string {
+	switch k {
+	case TokenPayment:
+		return "payment"
+	case TokenPayout:
+		return "payout"
+	}
+
+	return "none"
+}
+
+// Request 描述一次 SDK 调用，由中间件处理，不包含 API 密钥与签名。
+type Request struct {
+	Method   string
+	Endpoint Endpoint
+	// Params 用于替换 Endpoint 中的路径参数。
+	Params map[string]string
+	// Query 是查询参数，值为空的参数不会发送。
+	Query map[string]string
+	// Payload 是请求结构体，例如 *CreateInvoiceRequest，没有请求体时为 nil。
+	Payload any
+	// Token 是签名使用的密钥。
+	Token TokenKind
+
+	// Body 是 Payload 序列化后的 JSON，在中间件之前生成，签名与发送都使用它。
+	// 修改 Payload 不会改变发送的内容，中间件需要修改请求时应替换 Body。
+	Body []byte
+
+	// 以下字段记录最后一次尝试的情况，通过 Merchant 与 Attempts 读取。
+	merchant string
+	attempts int
+}
+
+// Merchant 返回最后一次尝试使用的商户 ID，请求尚未发送或不需要签名时为空。
+//
+// 在中间件中调用 next 之后读取，例如用于链路追踪与指标。
+func (r *Request) Merchant() string {
+	return r.merchant
+}
+
+// Attempts 返回已经发送的次数，包括 RetryPolicy 的重试，请求尚未发送时为 0。
+func (r *Request) Attempts() int {
+	return r.attempts
+}
+
+// Response 是 Cryptomus 的响应。
+type Response struct {
+	// HTTPResponse 是 HTTP 响应，Body 已被读取，请使用 Bytes。
+	HTTPResponse *http.Response
+
+	raw []byte
+	// checked 表示已经检查过状态码与 state。
+	checked bool
+}
+
+// NewResponse 创建响应，用于在中间件中返回缓存的结果或在测试中代替真实的请求。
+//
+// 示例：
+//
+//	resp := cryptomus.NewResponse(http.StatusOK, []byte(`{"state":0,"result":{"uuid":"..."}}`))
+func NewResponse(statusCode int, body []byte) *Response {
+	return &Response{
+		HTTPResponse: &http.Response{
+			Status:        http.StatusText(statusCode),
+			StatusCode:    statusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{"Content-Type": {"application/json"}},
+			Body:          io.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+		},
+		raw: body,
+	}
+}
+
+// StatusCode 返回 HTTP 状态码。
+func (r *Response) StatusCode() int {
+	if r == nil || r.HTTPResponse == nil {
+		return 0
+	}
+
+	return r.HTTPResponse.StatusCode
+}
+
+// Header 返回响应头。
+func (r *Response) Header() http.Header {
+	if r == nil || r.HTTPResponse == nil {
+		return nil
+	}
+
+	return r.HTTPResponse.Header
+}
+
+// Bytes 返回原始响应体，不能修改。
+func (r *Response) Bytes() []byte {
+	if r == nil {
+		return nil
+	}
+
+	return r.raw
+}
+
+// Handler 处理一次 SDK 调用。
+//
+// 收到响应时返回的 *Response 不为 nil；HTTP 状态码 >= 400 或 state 不为 0 时同时返回 *APIError。
+type Handler func(ctx context.Context, req *Request) (*Response, error)
+
+// Middleware 包装 Handler，用于日志、指标、缓存、幂等与测试替身等横切逻辑。
+type Middleware func(next Handler) Handler
+
+// WithMiddleware 为 SDK 实例添加中间件，可以多次使用。先添加的中间件在外层，最先处理请求。
+//
+// 中间件在请求校验与序列化之后执行，包裹整个调用（包括 RetryPolicy 的所有重试）；
+// 不调用 next 时请求不会发送，返回的 Response 仍会按照 Cryptomus 的响应格式解析，
+// 因此可以用 NewResponse 返回预先准备的结果。
+//
+// 示例：
+//
+//	logRequests := func(next cryptomus.Handler) cryptomus.Handler {
+//		return func(ctx context.Context, req *cryptomus.Request) (*cryptomus.Response, error) {
+//			start := time.Now()
+//			resp, err := next(ctx, req)
+//			log.Printf("%s %s: %d %v (%s)", req.Method, req.Endpoint, resp.StatusCode(), err, time.Since(start))
+//			return resp, err
+//		}
+//	}
+//
+//	sdk := cryptomus.New(
+//		cryptomus.WithMerchant(merchantID),
+//		cryptomus.WithPaymentToken(paymentToken),
+//		cryptomus.WithMiddleware(logRequests),
+//	)
+func WithMiddleware(middlewares ...Middleware) Option {
+	return func(c *Cryptomus) {
+		c.middlewares = append(c.middlewares, middlewares...)
+	}
+}
+
+// handler 返回包裹了所有中间件的 Handler。
+func (sdk *Cryptomus) handler() Handler {
+	h := Handler(sdk.retry)
+	for _, middleware := range slices.Backward(sdk.middlewares) {
+		h = middleware(h)
+	}
+
+	return h
+}
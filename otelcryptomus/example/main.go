@@ -0,0 +1,73 @@
+// 本示例使用模拟服务器与内存中的导出器离线验证 otelcryptomus 生成的 Span 与指标。
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	cryptomus "github.com/difyz9/cryptomus-sdk-go"
+	"github.com/difyz9/cryptomus-sdk-go/cryptomustest"
+	"github.com/difyz9/cryptomus-sdk-go/otelcryptomus"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func main() {
+	spans := tracetest.NewInMemoryExporter()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(spans))
+
+	reader := sdkmetric.NewManualReader()
+	meterProvider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	server := cryptomustest.NewServer()
+	defer server.Close()
+
+	sdk := server.Client(otelcryptomus.WithTelemetry(
+		otelcryptomus.WithTracerProvider(tracerProvider),
+		otelcryptomus.WithMeterProvider(meterProvider),
+	))
+
+	amount, _ := cryptomus.ParseAmount("15")
+	if _, err := sdk.CreateInvoice(&cryptomus.CreateInvoiceRequest{
+		Amount:   amount,
+		Currency: "USDT",
+		Network:  "tron",
+		OrderID:  "order-1",
+	}); err != nil {
+		log.Fatal(err)
+	}
+
+	// 不存在的发票，生成一个 error.type 为 not_found 的 Span。
+	_, _ = sdk.PaymentInformation(&cryptomus.PaymentInformationRequest{OrderID: "missing"})
+
+	for _, span := range spans.GetSpans() {
+		fmt.Printf("span %s status=%s\n", span.Name, span.Status.Code)
+		for _, attr := range span.Attributes {
+			fmt.Printf("  %s=%s\n", attr.Key, attr.Value.Emit())
+		}
+	}
+
+	var metrics metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &metrics); err != nil {
+		log.Fatal(err)
+	}
+
+	for _, scope := range metrics.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				for _, point := range data.DataPoints {
+					fmt.Printf("metric %s {%s} = %d\n", m.Name, point.Attributes.Encoded(attribute.DefaultEncoder()), point.Value)
+				}
+			case metricdata.Histogram[float64]:
+				for _, point := range data.DataPoints {
+					fmt.Printf("metric %s {%s} count=%d\n", m.Name, point.Attributes.Encoded(attribute.DefaultEncoder()), point.Count)
+				}
+			}
+		}
+	}
+}
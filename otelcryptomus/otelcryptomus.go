@@ -0,0 +1,237 @@
+// Package otelcryptomus 为 Cryptomus SDK 提供 OpenTelemetry 链路追踪与指标。
+//
+// 它是单独的模块，只有使用它的程序才会依赖 OpenTelemetry。遥测以 cryptomus.Middleware 的形式接入，
+// 每次 SDK 调用（包括所有重试）生成一个 Span，
+// 名称为 "cryptomus." 加上 Endpoint.Name()，例如 "cryptomus.payment.create"，并记录以下指标：
+//
+//   - cryptomus.client.calls：调用次数，按 cryptomus.endpoint 与 error.type 分组
+//   - cryptomus.client.call.duration：调用耗时（秒），按 cryptomus.endpoint 与 error.type 分组
+//
+// Span 属性包括商户 ID、order_id、currency、network、HTTP 状态码与 Cryptomus 的 state，
+// 不会记录 API 密钥、签名或其余请求字段。
+//
+// 示例：
+//
+//	sdk := cryptomus.New(
+//		cryptomus.WithMerchant(merchantID),
+//		cryptomus.WithPaymentToken(paymentToken),
+//		otelcryptomus.WithTelemetry(),
+//	)
+//
+// WithTelemetry 应放在其他中间件之前，使 Span 覆盖整个调用，也可以使用 Middleware 自行安排顺序。
+//
+// 离线验证时可以使用 go.opentelemetry.io/otel/sdk/trace/tracetest 的 InMemoryExporter 与
+// go.opentelemetry.io/otel/sdk/metric 的 ManualReader，参考 example 目录。
+package otelcryptomus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	cryptomus "github.com/difyz9/cryptomus-sdk-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName 是 Tracer 与 Meter 的名称。
+const ScopeName = "github.com/difyz9/cryptomus-sdk-go/otelcryptomus"
+
+// 属性名称。
+const (
+	EndpointKey   = attribute.Key("cryptomus.endpoint")
+	MerchantKey   = attribute.Key("cryptomus.merchant")
+	OrderIDKey    = attribute.Key("cryptomus.order_id")
+	CurrencyKey   = attribute.Key("cryptomus.currency")
+	NetworkKey    = attribute.Key("cryptomus.network")
+	StateKey      = attribute.Key("cryptomus.state")
+	AttemptsKey   = attribute.Key("cryptomus.attempts")
+	MethodKey     = attribute.Key("http.request.method")
+	StatusCodeKey = attribute.Key("http.response.status_code")
+	ErrorTypeKey  = attribute.Key("error.type")
+)
+
+// ErrorType 返回错误的分类，用作 error.type 属性：
+// validation、unauthorized、not_found、rate_limited、server_error、api_error、circuit_open、canceled、timeout 或 network。
+// err 为 nil 时返回空字符串。
+func ErrorType(err error) string {
+	apiErr, isAPIErr := cryptomus.AsAPIError(err)
+
+	switch {
+	case err == nil:
+		return ""
+	case cryptomus.IsValidationError(err):
+		return "validation"
+	case cryptomus.IsUnauthorized(err):
+		return "unauthorized"
+	case cryptomus.IsNotFound(err):
+		return "not_found"
+	case cryptomus.IsRateLimited(err):
+		return "rate_limited"
+	case isAPIErr && apiErr.StatusCode >= http.StatusInternalServerError:
+		return "server_error"
+	case isAPIErr:
+		return "api_error"
+	case errors.Is(err, cryptomus.ErrCircuitOpen):
+		return "circuit_open"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	}
+
+	return "network"
+}
+
+// Option 配置 Middleware。
+type Option func(*config)
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+}
+
+// WithTracerProvider 设置创建 Span 使用的 TracerProvider，默认为 otel.GetTracerProvider()。
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = provider
+	}
+}
+
+// WithMeterProvider 设置记录指标使用的 MeterProvider，默认为 otel.GetMeterProvider()。
+func WithMeterProvider(provider metric.MeterProvider) Option {
+	return func(c *config) {
+		c.meterProvider = provider
+	}
+}
+
+// WithTelemetry 返回为 SDK 实例启用链路追踪与指标的选项，等同于 cryptomus.WithMiddleware(Middleware(opts...))。
+func WithTelemetry(opts ...Option) cryptomus.Option {
+	return cryptomus.WithMiddleware(Middleware(opts...))
+}
+
+// Middleware 返回为每次 SDK 调用生成 Span 并记录指标的中间件。
+// 同一个中间件可以用于多个 SDK 实例，例如 MerchantRegistry 中的所有商户。
+func Middleware(opts ...Option) cryptomus.Middleware {
+	c := config{
+		tracerProvider: otel.GetTracerProvider(),
+		meterProvider:  otel.GetMeterProvider(),
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	meter := c.meterProvider.Meter(ScopeName)
+
+	calls, err := meter.Int64Counter("cryptomus.client.calls",
+		metric.WithDescription("Number of Cryptomus API calls."),
+		metric.WithUnit("{call}"))
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	duration, err := meter.Float64Histogram("cryptomus.client.call.duration",
+		metric.WithDescription("Duration of Cryptomus API calls, including retries."),
+		metric.WithUnit("s"))
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	t := &telemetry{
+		tracer:   c.tracerProvider.Tracer(ScopeName),
+		calls:    calls,
+		duration: duration,
+	}
+
+	return func(next cryptomus.Handler) cryptomus.Handler {
+		return func(ctx context.Context, req *cryptomus.Request) (*cryptomus.Response, error) {
+			return t.handle(ctx, req, next)
+		}
+	}
+}
+
+type telemetry struct {
+	tracer   trace.Tracer
+	calls    metric.Int64Counter
+	duration metric.Float64Histogram
+}
+
+func (t *telemetry) handle(ctx context.Context, req *cryptomus.Request, next cryptomus.Handler) (*cryptomus.Response, error) {
+	start := time.Now()
+
+	attrs := append([]attribute.KeyValue{
+		EndpointKey.String(req.Endpoint.String()),
+		MethodKey.String(req.Method),
+	}, bodyAttributes(req.Body)...)
+
+	ctx, span := t.tracer.Start(ctx, "cryptomus."+req.Endpoint.Name(),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...))
+
+	resp, err := next(ctx, req)
+
+	errorType := ErrorType(err)
+
+	span.SetAttributes(AttemptsKey.Int(req.Attempts()))
+	if merchant := req.Merchant(); merchant != "" {
+		span.SetAttributes(MerchantKey.String(merchant))
+	}
+	if status := resp.StatusCode(); status != 0 {
+		state := 0
+		if apiErr, ok := cryptomus.AsAPIError(err); ok {
+			state = apiErr.State
+		}
+		span.SetAttributes(StatusCodeKey.Int(status), StateKey.Int(state))
+	}
+	if err != nil {
+		span.SetAttributes(ErrorTypeKey.String(errorType))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+
+	metricAttrs := []attribute.KeyValue{EndpointKey.String(req.Endpoint.String())}
+	if errorType != "" {
+		metricAttrs = append(metricAttrs, ErrorTypeKey.String(errorType))
+	}
+	set := metric.WithAttributes(metricAttrs...)
+
+	t.calls.Add(ctx, 1, set)
+	t.duration.Record(ctx, time.Since(start).Seconds(), set)
+
+	return resp, err
+}
+
+// bodyAttributes 从请求体中提取 order_id、currency 与 network，其余字段不会被记录。
+func bodyAttributes(body []byte) []attribute.KeyValue {
+	if len(body) == 0 {
+		return nil
+	}
+
+	var fields struct {
+		OrderID  string `json:"order_id"`
+		Currency string `json:"currency"`
+		Network  string `json:"network"`
+	}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil
+	}
+
+	var attrs []attribute.KeyValue
+	if fields.OrderID != "" {
+		attrs = append(attrs, OrderIDKey.String(fields.OrderID))
+	}
+	if fields.Currency != "" {
+		attrs = append(attrs, CurrencyKey.String(fields.Currency))
+	}
+	if fields.Network != "" {
+		attrs = append(attrs, NetworkKey.String(fields.Network))
+	}
+
+	return attrs
+}
@@ -0,0 +1,167 @@
+package otelcryptomus_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	cryptomus "github.com/difyz9/cryptomus-sdk-go"
+	"github.com/difyz9/cryptomus-sdk-go/cryptomustest"
+	"github.com/difyz9/cryptomus-sdk-go/otelcryptomus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTelemetry(t *testing.T) {
+	spans := tracetest.NewInMemoryExporter()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(spans))
+	reader := sdkmetric.NewManualReader()
+	meterProvider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	srv := cryptomustest.NewServer()
+	defer srv.Close()
+
+	// 记录发送的签名，检查它们没有出现在 Span 属性中。
+	var mu sync.Mutex
+	var signs []string
+	sdk := srv.Client(
+		otelcryptomus.WithTelemetry(
+			otelcryptomus.WithTracerProvider(tracerProvider),
+			otelcryptomus.WithMeterProvider(meterProvider),
+		),
+		cryptomus.WithMiddleware(func(next cryptomus.Handler) cryptomus.Handler {
+			return func(ctx context.Context, req *cryptomus.Request) (*cryptomus.Response, error) {
+				mu.Lock()
+				signs = append(signs, cryptomus.SignBody(srv.PaymentToken, req.Body))
+				mu.Unlock()
+				return next(ctx, req)
+			}
+		}),
+	)
+
+	if _, err := sdk.CreateInvoice(&cryptomus.CreateInvoiceRequest{
+		Amount:   cryptomus.MustParseAmount("15"),
+		Currency: "USDT",
+		Network:  "tron",
+		OrderID:  "order-1",
+	}); err != nil {
+		t.Fatalf("CreateInvoice: %v", err)
+	}
+	if _, err := sdk.PaymentInformation(&cryptomus.PaymentInformationRequest{OrderID: "missing"}); err == nil {
+		t.Fatal("PaymentInformation of a missing invoice succeeded")
+	}
+
+	got := spans.GetSpans()
+	if len(got) != 2 {
+		t.Fatalf("spans = %d, want 2", len(got))
+	}
+
+	create := got[0]
+	if create.Name != "cryptomus.payment.create" {
+		t.Errorf("span name = %q, want cryptomus.payment.create", create.Name)
+	}
+	if create.SpanKind != trace.SpanKindClient || create.Status.Code == codes.Error {
+		t.Errorf("span kind = %s, status = %s", create.SpanKind, create.Status.Code)
+	}
+
+	attrs := attribute.NewSet(create.Attributes...)
+	for key, want := range map[attribute.Key]attribute.Value{
+		otelcryptomus.EndpointKey:   attribute.StringValue(cryptomus.CreateInvoiceEndpoint.String()),
+		otelcryptomus.MethodKey:     attribute.StringValue("POST"),
+		otelcryptomus.MerchantKey:   attribute.StringValue(srv.Merchant),
+		otelcryptomus.OrderIDKey:    attribute.StringValue("order-1"),
+		otelcryptomus.CurrencyKey:   attribute.StringValue("USDT"),
+		otelcryptomus.NetworkKey:    attribute.StringValue("tron"),
+		otelcryptomus.StatusCodeKey: attribute.IntValue(200),
+		otelcryptomus.StateKey:      attribute.IntValue(0),
+		otelcryptomus.AttemptsKey:   attribute.IntValue(1),
+	} {
+		if value, ok := attrs.Value(key); !ok || value != want {
+			t.Errorf("attribute %s = %s, want %s", key, value.Emit(), want.Emit())
+		}
+	}
+	if _, ok := attrs.Value(otelcryptomus.ErrorTypeKey); ok {
+		t.Error("successful span has error.type")
+	}
+
+	missing := got[1]
+	if missing.Status.Code != codes.Error {
+		t.Errorf("failed span status = %s, want Error", missing.Status.Code)
+	}
+	missingAttrs := attribute.NewSet(missing.Attributes...)
+	if value, _ := missingAttrs.Value(otelcryptomus.ErrorTypeKey); value.AsString() != "not_found" {
+		t.Errorf("error.type = %q, want not_found", value.AsString())
+	}
+
+	secrets := append([]string{srv.PaymentToken, srv.PayoutToken}, signs...)
+	for _, span := range got {
+		values := append([]attribute.KeyValue(nil), span.Attributes...)
+		for _, event := range span.Events {
+			values = append(values, event.Attributes...)
+		}
+		for _, attr := range values {
+			if strings.Contains(string(attr.Key), "sign") {
+				t.Errorf("span %s has attribute %s", span.Name, attr.Key)
+			}
+			for _, secret := range secrets {
+				if strings.Contains(attr.Value.Emit(), secret) {
+					t.Errorf("span %s attribute %s contains a secret", span.Name, attr.Key)
+				}
+			}
+		}
+	}
+
+	var metrics metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &metrics); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := map[string]int64{}
+	durations := map[string]uint64{}
+	for _, scope := range metrics.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				for _, point := range data.DataPoints {
+					calls[point.Attributes.Encoded(attribute.DefaultEncoder())] += point.Value
+				}
+			case metricdata.Histogram[float64]:
+				for _, point := range data.DataPoints {
+					durations[point.Attributes.Encoded(attribute.DefaultEncoder())] += point.Count
+				}
+			}
+		}
+	}
+
+	createKey := "cryptomus.endpoint=" + cryptomus.CreateInvoiceEndpoint.String()
+	missingKey := "cryptomus.endpoint=" + cryptomus.PaymentInformationEndpoint.String() + ",error.type=not_found"
+	for _, key := range []string{createKey, missingKey} {
+		if calls[key] != 1 || durations[key] != 1 {
+			t.Errorf("metrics for {%s}: calls = %d, durations = %d, want 1", key, calls[key], durations[key])
+		}
+	}
+}
+
+func TestErrorType(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{nil, ""},
+		{context.Canceled, "canceled"},
+		{context.DeadlineExceeded, "timeout"},
+		{cryptomus.ErrCircuitOpen, "circuit_open"},
+	}
+
+	for _, tt := range tests {
+		if got := otelcryptomus.ErrorType(tt.err); got != tt.want {
+			t.Errorf("ErrorType(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
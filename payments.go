@@ -2,6 +2,7 @@ package cryptomus
 
 import (
 	"context"
+	"net/http"
 	"time"
 )
 
@@ -13,7 +14,7 @@ type Currency struct {
 type Currencies []Currency
 
 type CreateInvoiceRequest struct {
-	Amount                 string     `json:"amount"`
+	Amount                 Amount     `json:"amount"`
 	Currency               string     `json:"currency"`
 	OrderID                string     `json:"order_id"`
 	Network                string     `json:"network"`
@@ -34,29 +35,52 @@ type CreateInvoiceRequest struct {
 	IsRefresh              bool       `json:"is_refresh"`
 }
 
+// Validate 校验 CreateInvoice 的请求参数。
+//
+// 除非使用 WithValidation(false) 关闭校验，SDK 会在签名之前自动调用 Validate，未通过时返回 *ValidationError。
+func (r *CreateInvoiceRequest) Validate() error {
+	var v validation
+
+	v.positiveAmount("amount", r.Amount)
+	v.required("currency", r.Currency)
+	v.orderID("order_id", r.OrderID, true)
+	if r.Lifetime != 0 {
+		v.between("lifetime", r.Lifetime, MinInvoiceLifetime, MaxInvoiceLifetime)
+	}
+	v.between("subtract", r.Subtract, 0, 100)
+	v.between("accuracy_payment_percent", r.AccuracyPaymentPercent, 0, MaxAccuracyPaymentPercent)
+	v.between("discount_percent", r.DiscountPercent, MinDiscountPercent, MaxDiscountPercent)
+	v.maxLength("additional_data", r.AdditionalData, 255)
+	if len(r.Currencies) > 0 && len(r.ExceptCurrencies) > 0 {
+		v.add("except_currencies", "The except currencies field is prohibited when currencies is present.")
+	}
+
+	return v.err()
+}
+
 type CreateInvoiceData struct {
-	UUID            string    `json:"uuid"`
-	OrderID         string    `json:"order_id"`
-	Amount          string    `json:"amount"`
-	PaymentAmount   int       `json:"payment_amount"`
-	PayerAmount     int       `json:"payer_amount"`
-	DiscountPercent int       `json:"discount_percent"`
-	Discount        string    `json:"discount"`
-	PayerCurrency   string    `json:"payer_currency"`
-	Currency        string    `json:"currency"`
-	MerchantAmount  int       `json:"merchant_amount"`
-	Network         string    `json:"network"`
-	Address         string    `json:"address"`
-	From            string    `json:"from"`
-	Txid            string    `json:"txid"`
-	PaymentStatus   string    `json:"payment_status"`
-	URL             string    `json:"url"`
-	ExpiredAt       int       `json:"expired_at"`
-	Status          string    `json:"status"`
-	IsFinal         bool      `json:"is_final"`
-	AdditionalData  string    `json:"additional_data"`
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
+	UUID            string        `json:"uuid"`
+	OrderID         string        `json:"order_id"`
+	Amount          Amount        `json:"amount"`
+	PaymentAmount   Amount        `json:"payment_amount"`
+	PayerAmount     Amount        `json:"payer_amount"`
+	DiscountPercent int           `json:"discount_percent"`
+	Discount        Amount        `json:"discount"`
+	PayerCurrency   string        `json:"payer_currency"`
+	Currency        string        `json:"currency"`
+	MerchantAmount  Amount        `json:"merchant_amount"`
+	Network         string        `json:"network"`
+	Address         string        `json:"address"`
+	From            string        `json:"from"`
+	Txid            string        `json:"txid"`
+	PaymentStatus   PaymentStatus `json:"payment_status"`
+	URL             string        `json:"url"`
+	ExpiredAt       int           `json:"expired_at"`
+	Status          PaymentStatus `json:"status"`
+	IsFinal         bool          `json:"is_final"`
+	AdditionalData  string        `json:"additional_data"`
+	CreatedAt       time.Time     `json:"created_at"`
+	UpdatedAt       time.Time     `json:"updated_at"`
 }
 
 type CreateInvoiceResponse struct {
@@ -73,7 +97,7 @@ type CreateInvoiceResponse struct {
 // 示例：
 //
 //	result, err := sdk.CreateInvoice(&CreateInvoiceRequest{
-//		Amount:     "15",
+//		Amount:     MustParseAmount("15"),
 //		Currency:   "USD",
 //		OrderID: 	"9d7f7b9b-3b7b-4b7b-9b7b-7b9d7f7b9b7b",
 //	})
@@ -93,7 +117,7 @@ func (sdk *Cryptomus) CreateInvoice(payload *CreateInvoiceRequest) (*CreateInvoi
 // 示例：
 //
 //	result, err := sdk.CreateInvoiceWithContext(ctx, &CreateInvoiceRequest{
-//		Amount:     "15",
+//		Amount:     MustParseAmount("15"),
 //		Currency:   "USD",
 //		OrderID: 	"9d7f7b9b-3b7b-4b7b-9b7b-7b9d7f7b9b7b",
 //	})
@@ -103,15 +127,12 @@ func (sdk *Cryptomus) CreateInvoice(payload *CreateInvoiceRequest) (*CreateInvoi
 func (sdk *Cryptomus) CreateInvoiceWithContext(ctx context.Context, payload *CreateInvoiceRequest) (*CreateInvoiceResponse, error) {
 	var result CreateInvoiceResponse
 
-	req := sdk.HttpClient.NewRequest().
-		SetContext(ctx).
-		SetHeader("merchant", sdk.Merchant).
-		SetHeader("sign", Sign(sdk.PaymentToken, payload)).
-		SetBody(payload).
-		SetSuccessResult(&result).
-		SetErrorResult(&result)
-
-	if _, err := req.Post(CreateInvoiceEndpoint.URL()); err != nil {
+	if err := sdk.send(ctx, &Request{
+		Method:   http.MethodPost,
+		Endpoint: CreateInvoiceEndpoint,
+		Token:    TokenPayment,
+		Payload:  payload,
+	}, &result); err != nil {
 		return nil, err
 	}
 
@@ -126,6 +147,17 @@ type CreateStaticWalletRequest struct {
 	FromReferralCode string `json:"from_referral_code,omitempty"`
 }
 
+// Validate 校验 CreateStaticWallet 的请求参数。
+func (r *CreateStaticWalletRequest) Validate() error {
+	var v validation
+
+	v.required("currency", r.Currency)
+	v.required("network", r.Network)
+	v.orderID("order_id", r.OrderID, true)
+
+	return v.err()
+}
+
 type CreateStaticWalletData struct {
 	WalletUUID string `json:"wallet_uuid"`
 	UUID       string `json:"uuid"`
@@ -177,15 +209,12 @@ func (sdk *Cryptomus) CreateStaticWallet(payload *CreateStaticWalletRequest) (*C
 func (sdk *Cryptomus) CreateStaticWalletWithContext(ctx context.Context, payload *CreateStaticWalletRequest) (*CreateStaticWalletResponse, error) {
 	var result CreateStaticWalletResponse
 
-	req := sdk.HttpClient.NewRequest().
-		SetContext(ctx).
-		SetHeader("merchant", sdk.Merchant).
-		SetHeader("sign", Sign(sdk.PaymentToken, payload)).
-		SetBody(payload).
-		SetSuccessResult(&result).
-		SetErrorResult(&result)
-
-	if _, err := req.Post(CreateStaticWalletEndpoint.URL()); err != nil {
+	if err := sdk.send(ctx, &Request{
+		Method:   http.MethodPost,
+		Endpoint: CreateStaticWalletEndpoint,
+		Token:    TokenPayment,
+		Payload:  payload,
+	}, &result); err != nil {
 		return nil, err
 	}
 
@@ -196,6 +225,15 @@ type GenerateQRCodeWalletRequest struct {
 	WalletAddressUUID string `json:"wallet_address_uuid"`
 }
 
+// Validate 校验 GenerateQRCodeWallet 的请求参数。
+func (r *GenerateQRCodeWalletRequest) Validate() error {
+	var v validation
+
+	v.required("wallet_address_uuid", r.WalletAddressUUID)
+
+	return v.err()
+}
+
 type GenerateQRCodeWalletData struct {
 	Image string `json:"image"`
 }
@@ -240,15 +278,12 @@ func (sdk *Cryptomus) GenerateQRStaticWallet(payload *GenerateQRCodeWalletReques
 func (sdk *Cryptomus) GenerateQRStaticWalletWithContext(ctx context.Context, payload *GenerateQRCodeWalletRequest) (*GenerateQRCodeWalletResponse, error) {
 	var result GenerateQRCodeWalletResponse
 
-	req := sdk.HttpClient.NewRequest().
-		SetContext(ctx).
-		SetHeader("merchant", sdk.Merchant).
-		SetHeader("sign", Sign(sdk.PaymentToken, payload)).
-		SetBody(payload).
-		SetSuccessResult(&result).
-		SetErrorResult(&result)
-
-	if _, err := req.Post(GenerateQRCodeWalletEndpoint.URL()); err != nil {
+	if err := sdk.send(ctx, &Request{
+		Method:   http.MethodPost,
+		Endpoint: GenerateQRCodeWalletEndpoint,
+		Token:    TokenPayment,
+		Payload:  payload,
+	}, &result); err != nil {
 		return nil, err
 	}
 
@@ -259,6 +294,15 @@ type GenerateQRCodeInvoiceRequest struct {
 	MerchantPaymentUUID string `json:"merchant_payment_uuid"`
 }
 
+// Validate 校验 GenerateQRCodeInvoice 的请求参数。
+func (r *GenerateQRCodeInvoiceRequest) Validate() error {
+	var v validation
+
+	v.required("merchant_payment_uuid", r.MerchantPaymentUUID)
+
+	return v.err()
+}
+
 type GenerateQRCodeInvoiceData struct {
 	Image string `json:"image"`
 }
@@ -303,15 +347,12 @@ func (sdk *Cryptomus) GenerateQRCodeInvoice(payload *GenerateQRCodeInvoiceReques
 func (sdk *Cryptomus) GenerateQRCodeInvoiceWithContext(ctx context.Context, payload *GenerateQRCodeInvoiceRequest) (*GenerateQRCodeInvoiceResponse, error) {
 	var result GenerateQRCodeInvoiceResponse
 
-	req := sdk.HttpClient.NewRequest().
-		SetContext(ctx).
-		SetHeader("merchant", sdk.Merchant).
-		SetHeader("sign", Sign(sdk.PaymentToken, payload)).
-		SetBody(payload).
-		SetSuccessResult(&result).
-		SetErrorResult(&result)
-
-	if _, err := req.Post(GenerateQRCodeInvoiceEndpoint.URL()); err != nil {
+	if err := sdk.send(ctx, &Request{
+		Method:   http.MethodPost,
+		Endpoint: GenerateQRCodeInvoiceEndpoint,
+		Token:    TokenPayment,
+		Payload:  payload,
+	}, &result); err != nil {
 		return nil, err
 	}
 
@@ -324,6 +365,15 @@ type BlockStaticWalletRequest struct {
 	IsForceRefund bool   `json:"is_force_refund"`
 }
 
+// Validate 校验 BlockStaticWallet 的请求参数。
+func (r *BlockStaticWalletRequest) Validate() error {
+	var v validation
+
+	v.uuidOrOrderID(r.UUID, r.OrderID)
+
+	return v.err()
+}
+
 type BlockStaticWalletStatus string
 
 const (
@@ -381,15 +431,12 @@ func (sdk *Cryptomus) BlockStaticWallet(payload *BlockStaticWalletRequest) (*Blo
 func (sdk *Cryptomus) BlockStaticWalletWithContext(ctx context.Context, payload *BlockStaticWalletRequest) (*BlockStaticWalletResponse, error) {
 	var result BlockStaticWalletResponse
 
-	req := sdk.HttpClient.NewRequest().
-		SetContext(ctx).
-		SetHeader("merchant", sdk.Merchant).
-		SetHeader("sign", Sign(sdk.PaymentToken, payload)).
-		SetBody(payload).
-		SetSuccessResult(&result).
-		SetErrorResult(&result)
-
-	if _, err := req.Post(BlockStaticWalletEndpoint.URL()); err != nil {
+	if err := sdk.send(ctx, &Request{
+		Method:   http.MethodPost,
+		Endpoint: BlockStaticWalletEndpoint,
+		Token:    TokenPayment,
+		Payload:  payload,
+	}, &result); err != nil {
 		return nil, err
 	}
 
@@ -402,9 +449,19 @@ type RefundPaymentOnBlockedAddressRequest struct {
 	Address string `json:"address"`
 }
 
+// Validate 校验 RefundPaymentOnBlockedAddress 的请求参数。
+func (r *RefundPaymentOnBlockedAddressRequest) Validate() error {
+	var v validation
+
+	v.uuidOrOrderID(r.UUID, r.OrderID)
+	v.required("address", r.Address)
+
+	return v.err()
+}
+
 type RefundPaymentOnBlockedAddressData struct {
-	Commission string `json:"commission"`
-	Amount     string `json:"amount"`
+	Commission Amount `json:"commission"`
+	Amount     Amount `json:"amount"`
 }
 
 type RefundPaymentOnBlockedAddressResponse struct {
@@ -453,15 +510,12 @@ func (sdk *Cryptomus) RefundPaymentOnBlockedAddress(payload *RefundPaymentOnBloc
 func (sdk *Cryptomus) RefundPaymentOnBlockedAddressWithContext(ctx context.Context, payload *RefundPaymentOnBlockedAddressRequest) (*RefundPaymentOnBlockedAddressResponse, error) {
 	var result RefundPaymentOnBlockedAddressResponse
 
-	req := sdk.HttpClient.NewRequest().
-		SetContext(ctx).
-		SetHeader("merchant", sdk.Merchant).
-		SetHeader("sign", Sign(sdk.PaymentToken, payload)).
-		SetBody(payload).
-		SetSuccessResult(&result).
-		SetErrorResult(&result)
-
-	if _, err := req.Post(RefundPaymentOnBlockedAddressEndpoint.URL()); err != nil {
+	if err := sdk.send(ctx, &Request{
+		Method:   http.MethodPost,
+		Endpoint: RefundPaymentOnBlockedAddressEndpoint,
+		Token:    TokenPayment,
+		Payload:  payload,
+	}, &result); err != nil {
 		return nil, err
 	}
 
@@ -473,6 +527,15 @@ type PaymentInformationRequest struct {
 	OrderID string `json:"order_id,omitempty"`
 }
 
+// Validate 校验 PaymentInformation 的请求参数。
+func (r *PaymentInformationRequest) Validate() error {
+	var v validation
+
+	v.uuidOrOrderID(r.UUID, r.OrderID)
+
+	return v.err()
+}
+
 type PaymentInformationData struct {
 	*CreateInvoiceData
 }
@@ -517,15 +580,12 @@ func (sdk *Cryptomus) PaymentInformation(payload *PaymentInformationRequest) (*P
 func (sdk *Cryptomus) PaymentInformationWithContext(ctx context.Context, payload *PaymentInformationRequest) (*PaymentInformationResponse, error) {
 	var result PaymentInformationResponse
 
-	req := sdk.HttpClient.NewRequest().
-		SetContext(ctx).
-		SetHeader("merchant", sdk.Merchant).
-		SetHeader("sign", Sign(sdk.PaymentToken, payload)).
-		SetBody(payload).
-		SetSuccessResult(&result).
-		SetErrorResult(&result)
-
-	if _, err := req.Post(PaymentInformationEndpoint.URL()); err != nil {
+	if err := sdk.send(ctx, &Request{
+		Method:   http.MethodPost,
+		Endpoint: PaymentInformationEndpoint,
+		Token:    TokenPayment,
+		Payload:  payload,
+	}, &result); err != nil {
 		return nil, err
 	}
 
@@ -539,6 +599,16 @@ type RefundRequest struct {
 	OrderID    string `json:"order_id,omitempty"`
 }
 
+// Validate 校验 Refund 的请求参数。
+func (r *RefundRequest) Validate() error {
+	var v validation
+
+	v.uuidOrOrderID(r.UUID, r.OrderID)
+	v.required("address", r.Address)
+
+	return v.err()
+}
+
 type RefundData struct{}
 
 type RefundResponse struct {
@@ -585,15 +655,12 @@ func (sdk *Cryptomus) Refund(payload *RefundRequest) (*RefundResponse, error) {
 func (sdk *Cryptomus) RefundWithContext(ctx context.Context, payload *RefundRequest) (*RefundResponse, error) {
 	var result RefundResponse
 
-	req := sdk.HttpClient.NewRequest().
-		SetContext(ctx).
-		SetHeader("merchant", sdk.Merchant).
-		SetHeader("sign", Sign(sdk.PaymentToken, payload)).
-		SetBody(payload).
-		SetSuccessResult(&result).
-		SetErrorResult(&result)
-
-	if _, err := req.Post(RefundEndpoint.URL()); err != nil {
+	if err := sdk.send(ctx, &Request{
+		Method:   http.MethodPost,
+		Endpoint: RefundEndpoint,
+		Token:    TokenPayment,
+		Payload:  payload,
+	}, &result); err != nil {
 		return nil, err
 	}
 
@@ -620,13 +687,13 @@ const (
 )
 
 type PaymentListOfServiceLimit struct {
-	MinAmount string `json:"min_amount"`
-	MaxAmount string `json:"max_amount"`
+	MinAmount Amount `json:"min_amount"`
+	MaxAmount Amount `json:"max_amount"`
 }
 
 type PaymentListOfServiceCommission struct {
-	FeeAmount string `json:"fee_amount"`
-	Percent   string `json:"percent"`
+	FeeAmount Amount `json:"fee_amount"`
+	Percent   Amount `json:"percent"`
 }
 
 type PaymentListOfServicesData struct {
@@ -673,14 +740,11 @@ func (sdk *Cryptomus) PaymentListOfServices() (*PaymentListOfServicesResponse, e
 func (sdk *Cryptomus) PaymentListOfServicesWithContext(ctx context.Context) (*PaymentListOfServicesResponse, error) {
 	var result PaymentListOfServicesResponse
 
-	req := sdk.HttpClient.NewRequest().
-		SetContext(ctx).
-		SetHeader("merchant", sdk.Merchant).
-		SetHeader("sign", Sign(sdk.PaymentToken, nil)).
-		SetSuccessResult(&result).
-		SetErrorResult(&result)
-
-	if _, err := req.Post(PaymentListOfServicesEndpoint.URL()); err != nil {
+	if err := sdk.send(ctx, &Request{
+		Method:   http.MethodPost,
+		Endpoint: PaymentListOfServicesEndpoint,
+		Token:    TokenPayment,
+	}, &result); err != nil {
 		return nil, err
 	}
 
@@ -693,6 +757,16 @@ type PaymentHistoryRequest struct {
 	Cursor   string `json:"-"`
 }
 
+// Validate 校验 PaymentHistory 的请求参数。
+func (r *PaymentHistoryRequest) Validate() error {
+	var v validation
+
+	v.historyDate("date_from", r.DateFrom)
+	v.historyDate("date_to", r.DateTo)
+
+	return v.err()
+}
+
 type PaymentHistoryData struct {
 	Items    []CreateInvoiceData `json:"items"`
 	Paginate *Pagination         `json:"paginate"`
@@ -740,16 +814,13 @@ func (sdk *Cryptomus) PaymentHistory(payload *PaymentHistoryRequest) (*PaymentHi
 func (sdk *Cryptomus) PaymentHistoryWithContext(ctx context.Context, payload *PaymentHistoryRequest) (*PaymentHistoryResponse, error) {
 	var result PaymentHistoryResponse
 
-	req := sdk.HttpClient.NewRequest().
-		SetContext(ctx).
-		SetHeader("merchant", sdk.Merchant).
-		SetHeader("sign", Sign(sdk.PaymentToken, payload)).
-		SetBody(payload).
-		SetQueryParam("cursor", payload.Cursor).
-		SetSuccessResult(&result).
-		SetErrorResult(&result)
-
-	if _, err := req.Post(PaymentHistoryEndpoint.URL()); err != nil {
+	if err := sdk.send(ctx, &Request{
+		Method:   http.MethodPost,
+		Endpoint: PaymentHistoryEndpoint,
+		Token:    TokenPayment,
+		Payload:  payload,
+		Query:    map[string]string{"cursor": payload.Cursor},
+	}, &result); err != nil {
 		return nil, err
 	}
 
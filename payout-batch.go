@@ -0,0 +1,665 @@
+package cryptomus
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrCheckpointMismatch 表示检查点中某个 order_id 的提现与本次输入不同，例如修改了金额或地址后使用旧的检查点再次运行。
+var ErrCheckpointMismatch = errors.New("cryptomus: payout checkpoint does not match the input")
+
+// PayoutItemState 是批量提现中单笔提现的状态。
+type PayoutItemState string
+
+const (
+	PayoutItemPending    PayoutItemState = "pending"    // 尚未提交
+	PayoutItemSubmitting PayoutItemState = "submitting" // 已提交，结果尚未确认
+	PayoutItemCreated    PayoutItemState = "created"    // Cryptomus 已创建提现，状态见 Payout.Status
+	PayoutItemFailed     PayoutItemState = "failed"     // Cryptomus 拒绝了请求
+	PayoutItemSkipped    PayoutItemState = "skipped"    // 没有通过限额或余额检查，没有提交
+	PayoutItemUnknown    PayoutItemState = "unknown"    // 结果无法确定，见 ErrPayoutOutcomeUnknown
+)
+
+// PayoutBatchItem 是批量提现中的一笔提现及其结果。
+type PayoutBatchItem struct {
+	Request CreatePayoutRequest `json:"request"`
+	State   PayoutItemState     `json:"state"`
+	// Payout 是 Cryptomus 返回的提现，State 为 PayoutItemCreated 时不为 nil。
+	Payout *PayoutData `json:"payout,omitempty"`
+	Error  string      `json:"error,omitempty"`
+	// BalanceUnchecked 为 true 表示提交前没有检查余额：提现从 FromCurrency 的余额中扣除，无法在本地换算金额。
+	BalanceUnchecked bool `json:"balance_unchecked,omitempty"`
+}
+
+// PayoutBatchOptions 配置 PayoutBatch。
+type PayoutBatchOptions struct {
+	// Concurrency 是同时提交的提现数，默认为 4。
+	Concurrency int
+	// RateLimit 限制提交提现的速度，Rate 为 0 时不限制。SDK 实例的 WithRateLimit 同样生效。
+	RateLimit RateLimit
+	// Checkpoint 是检查点文件的路径，为空时不保存。文件每行是一笔提现的状态（JSON Lines），
+	// 运行开始时写入所有提现的当前状态，之后每次状态变化追加一行并同步到磁盘。
+	// 进程崩溃后使用相同的文件再次运行时，已创建与被拒绝的提现不会重复提交，结果未确认的提现会先按 order_id 查询，
+	// 被跳过的提现重新检查。检查点中同一 order_id 的提现与输入不同时 Run 返回 ErrCheckpointMismatch。
+	Checkpoint string
+	// SkipChecks 为 true 时不检查 PayoutListOfServices 的限额与当前余额。
+	// 检查时 FromCurrency 与 Currency 不同的提现只检查限额，不检查余额，见 PayoutBatchItem.BalanceUnchecked。
+	SkipChecks bool
+	// WaitForFinal 为 true 时，提交完成后通过 PayoutInformation 等待每笔提现进入最终状态，ctx 结束时停止等待。
+	WaitForFinal bool
+	// Watch 配置 WaitForFinal 的轮询间隔。
+	Watch WatchOptions
+	// OnItem 在每笔提现的状态变化时调用，可能在多个 goroutine 中同时调用。
+	OnItem func(item PayoutBatchItem)
+}
+
+// PayoutBatchReport 是批量提现的结果汇总。
+type PayoutBatchReport struct {
+	// Items 与输入的顺序相同。
+	Items []PayoutBatchItem `json:"items"`
+	// Counts 是各状态的提现数。
+	Counts map[PayoutItemState]int `json:"counts"`
+	// Paid、Failed 是按最终状态统计的提现数，只包含已创建的提现。
+	Paid   int `json:"paid"`
+	Failed int `json:"failed"`
+	// Totals 是已创建的提现按币种汇总的金额。
+	Totals   map[string]Amount `json:"totals"`
+	Duration time.Duration     `json:"duration"`
+}
+
+// WriteSummary 输出易读的汇总，包括各状态的数量、按币种的金额，没有成功创建的提现及其原因，以及等待最终状态失败的提现。
+func (r *PayoutBatchReport) WriteSummary(w io.Writer) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "payouts: %d in %s\n", len(r.Items), r.Duration.Round(time.Millisecond))
+	for _, state := range []PayoutItemState{PayoutItemCreated, PayoutItemFailed, PayoutItemSkipped, PayoutItemUnknown, PayoutItemPending, PayoutItemSubmitting} {
+		if n := r.Counts[state]; n > 0 {
+			fmt.Fprintf(&b, "  %-10s %d\n", state, n)
+		}
+	}
+	if r.Paid > 0 || r.Failed > 0 {
+		fmt.Fprintf(&b, "final status: %d paid, %d failed\n", r.Paid, r.Failed)
+	}
+
+	if len(r.Totals) > 0 {
+		b.WriteString("created totals:\n")
+		for _, currency := range slices.Sorted(maps.Keys(r.Totals)) {
+			fmt.Fprintf(&b, "  %-10s %s\n", currency, r.Totals[currency])
+		}
+	}
+
+	var problems []PayoutBatchItem
+	for _, item := range r.Items {
+		if item.State != PayoutItemCreated || item.Error != "" {
+			problems = append(problems, item)
+		}
+	}
+	if len(problems) > 0 {
+		b.WriteString("problems:\n")
+		for _, item := range problems {
+			fmt.Fprintf(&b, "  %s %s %s %s: %s\n", item.Request.OrderID, item.Request.Amount, item.Request.Currency, item.State, item.Error)
+		}
+	}
+
+	var unchecked []string
+	for _, item := range r.Items {
+		if item.BalanceUnchecked && item.State != PayoutItemSkipped {
+			unchecked = append(unchecked, item.Request.OrderID)
+		}
+	}
+	if len(unchecked) > 0 {
+		fmt.Fprintf(&b, "balance not checked (paid from another currency): %s\n", strings.Join(unchecked, ", "))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// PayoutBatch 批量提交提现，例如联盟佣金与平台结算。
+type PayoutBatch struct {
+	sdk  *Cryptomus
+	opts PayoutBatchOptions
+
+	mu      sync.Mutex
+	items   []PayoutBatchItem
+	journal *os.File
+}
+
+// PayoutBatch 返回使用该 SDK 实例的 PayoutBatch。
+//
+// 示例：
+//
+//	requests, err := cryptomus.ReadPayoutsCSV(file)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//
+//	report, err := sdk.PayoutBatch(cryptomus.PayoutBatchOptions{
+//		Concurrency: 4,
+//		RateLimit:   cryptomus.RateLimit{Rate: 2, Burst: 4},
+//		Checkpoint:  "settlement-2024-06.checkpoint.json",
+//	}).Run(ctx, requests)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	report.WriteSummary(os.Stdout)
+func (sdk *Cryptomus) PayoutBatch(opts PayoutBatchOptions) *PayoutBatch {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 4
+	}
+
+	return &PayoutBatch{sdk: sdk, opts: opts}
+}
+
+// Run 检查并提交 requests 中的提现，每笔提现必须有唯一的 order_id。
+//
+// 提现按 order_id 幂等地创建（见 IdempotentClient），ctx 结束时停止提交新的提现并返回已有的结果与 ctx 的错误。
+// 只有输入或检查点无效、无法获取限额与余额，或无法写入检查点时才返回其他错误，单笔提现的失败记录在报告中。
+func (b *PayoutBatch) Run(ctx context.Context, requests []CreatePayoutRequest) (*PayoutBatchReport, error) {
+	start := time.Now()
+
+	if err := b.init(requests); err != nil {
+		return nil, err
+	}
+
+	if !b.opts.SkipChecks {
+		if err := b.check(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := b.openCheckpoint(); err != nil {
+		return nil, err
+	}
+	defer b.closeCheckpoint()
+
+	if err := b.submit(ctx); err != nil {
+		return b.report(start), err
+	}
+
+	if b.opts.WaitForFinal {
+		b.wait(ctx)
+	}
+
+	return b.report(start), ctx.Err()
+}
+
+// init 根据输入与检查点初始化每笔提现的状态。
+func (b *PayoutBatch) init(requests []CreatePayoutRequest) error {
+	seen := map[string]bool{}
+	for i, r := range requests {
+		if r.OrderID == "" {
+			return fmt.Errorf("cryptomus: payout %d: order_id is required", i+1)
+		}
+		if seen[r.OrderID] {
+			return fmt.Errorf("cryptomus: duplicate payout order_id %q", r.OrderID)
+		}
+		seen[r.OrderID] = true
+	}
+
+	saved := map[string]PayoutBatchItem{}
+	if b.opts.Checkpoint != "" {
+		var err error
+		if saved, err = loadCheckpoint(b.opts.Checkpoint); err != nil {
+			return err
+		}
+	}
+
+	b.items = make([]PayoutBatchItem, len(requests))
+	for i, r := range requests {
+		item, ok := saved[r.OrderID]
+		if !ok {
+			item = PayoutBatchItem{Request: r, State: PayoutItemPending}
+		}
+		if ok && !samePayoutRequest(item.Request, r) {
+			return fmt.Errorf("%w: order_id %q", ErrCheckpointMismatch, r.OrderID)
+		}
+		if item.State == PayoutItemSkipped {
+			// 限额与余额可能已经变化，重新检查。
+			item = PayoutBatchItem{Request: r, State: PayoutItemPending}
+		}
+		b.items[i] = item
+	}
+
+	return nil
+}
+
+// check 使用 PayoutListOfServices 与 Balance 检查待提交的提现，没有通过的提现标记为 PayoutItemSkipped。
+func (b *PayoutBatch) check(ctx context.Context) error {
+	services, err := b.sdk.PayoutListOfServicesWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("cryptomus: payout services: %w", err)
+	}
+
+	balances, err := b.sdk.BalanceWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("cryptomus: balance: %w", err)
+	}
+
+	available := map[string]Amount{}
+	for _, result := range balances.Result {
+		for _, wallet := range result.Balance.Merchant {
+			currency := strings.ToUpper(wallet.CurrencyCode)
+			available[currency] = available[currency].Add(wallet.Balance)
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for i := range b.items {
+		item := &b.items[i]
+		if item.State != PayoutItemPending {
+			continue
+		}
+		item.BalanceUnchecked = false
+
+		r := item.Request
+		if err := r.Validate(); err != nil {
+			b.skip(item, err.Error())
+			continue
+		}
+
+		service := findPayoutService(services.Result, r.Currency, r.Network)
+		if service == nil {
+			b.skip(item, fmt.Sprintf("payouts of %s on %s are not available", r.Currency, r.Network))
+			continue
+		}
+
+		quote := payoutQuote(service, r.Amount, r.IsSubtract)
+		if err := quote.Check(); err != nil {
+			b.skip(item, err.Error())
+			continue
+		}
+
+		// 提现从 from_currency（没有时为 currency）的余额中扣除，扣除的金额为 quote.Total。
+		// 使用其他币种支付时无法在本地换算，只检查同币种的提现，其余的在结果中标记为未检查余额。
+		if r.FromCurrency != "" && !strings.EqualFold(r.FromCurrency, r.Currency) {
+			item.BalanceUnchecked = true
+			continue
+		}
+
+		currency := strings.ToUpper(r.Currency)
+		debit := quote.Total
+		if available[currency].Cmp(debit) < 0 {
+			b.skip(item, fmt.Sprintf("insufficient %s balance: %s available, %s required", currency, available[currency], debit))
+			continue
+		}
+		available[currency] = available[currency].Sub(debit)
+	}
+
+	return nil
+}
+
+func (b *PayoutBatch) skip(item *PayoutBatchItem, reason string) {
+	item.State = PayoutItemSkipped
+	item.Error = reason
+	b.notify(*item)
+}
+
+// submit 以有限的并发提交待提交与结果未确认的提现。
+func (b *PayoutBatch) submit(ctx context.Context) error {
+	store := NewMemoryInFlightStore()
+	client := b.sdk.Idempotent(IdempotencyOptions{Store: store})
+
+	var limiter *tokenBucket
+	if b.opts.RateLimit.Rate > 0 {
+		limiter = newTokenBucket(b.opts.RateLimit, time.Now)
+	}
+
+	var queue []int
+	for i, item := range b.items {
+		switch item.State {
+		case PayoutItemSubmitting, PayoutItemUnknown:
+			// 之前的运行提交后没有得到结果，先按 order_id 查询。
+			if err := store.Add(ctx, idempotencyKey(b.sdk.Merchant, CreatePayoutEndpoint, item.Request.OrderID)); err != nil {
+				return err
+			}
+			queue = append(queue, i)
+		case PayoutItemPending:
+			queue = append(queue, i)
+		}
+	}
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		saveErr  error
+		sem      = make(chan struct{}, b.opts.Concurrency)
+		failSave = func(err error) { errOnce.Do(func() { saveErr = err }) }
+	)
+
+loop:
+	for _, i := range queue {
+		select {
+		case <-ctx.Done():
+			break loop
+		case sem <- struct{}{}:
+		}
+		if limiter != nil {
+			if err := limiter.wait(ctx); err != nil {
+				<-sem
+				break loop
+			}
+		}
+
+		r := b.items[i].Request
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if err := b.update(i, func(item *PayoutBatchItem) { item.State, item.Error = PayoutItemSubmitting, "" }); err != nil {
+				failSave(err)
+				return
+			}
+
+			resp, err := client.CreatePayout(ctx, &r)
+
+			err = b.update(i, func(item *PayoutBatchItem) {
+				switch {
+				case err == nil:
+					item.State, item.Payout = PayoutItemCreated, resp.Result
+				case errors.Is(err, ErrPayoutOutcomeUnknown):
+					item.State, item.Error = PayoutItemUnknown, err.Error()
+				default:
+					item.State, item.Error = PayoutItemFailed, err.Error()
+				}
+			})
+			if err != nil {
+				failSave(err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return saveErr
+}
+
+// wait 等待已创建的提现进入最终状态，等待失败时把错误记录在 PayoutBatchItem.Error 中。
+func (b *PayoutBatch) wait(ctx context.Context) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, b.opts.Concurrency)
+
+	for i, item := range b.items {
+		if item.State != PayoutItemCreated || item.Payout == nil || item.Payout.Status.IsFinal() {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			payout, err := b.sdk.WaitForPayout(ctx, item.Payout.UUID, b.opts.Watch)
+			_ = b.update(i, func(item *PayoutBatchItem) {
+				if err != nil {
+					item.Error = fmt.Sprintf("wait for final status: %s", err)
+					return
+				}
+				item.Payout, item.Error = payout, ""
+			})
+		}()
+	}
+
+	wg.Wait()
+}
+
+// update 修改第 i 笔提现并追加到检查点。
+func (b *PayoutBatch) update(i int, fn func(item *PayoutBatchItem)) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	fn(&b.items[i])
+	b.notify(b.items[i])
+
+	return b.saveLocked(b.items[i])
+}
+
+func (b *PayoutBatch) notify(item PayoutBatchItem) {
+	if b.opts.OnItem != nil {
+		b.opts.OnItem(item)
+	}
+}
+
+func (b *PayoutBatch) report(start time.Time) *PayoutBatchReport {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	report := &PayoutBatchReport{
+		Items:    slices.Clone(b.items),
+		Counts:   map[PayoutItemState]int{},
+		Totals:   map[string]Amount{},
+		Duration: time.Since(start),
+	}
+
+	for _, item := range b.items {
+		report.Counts[item.State]++
+		if item.State != PayoutItemCreated {
+			continue
+		}
+
+		currency := strings.ToUpper(item.Request.Currency)
+		report.Totals[currency] = report.Totals[currency].Add(item.Request.Amount)
+
+		if item.Payout != nil {
+			switch {
+			case item.Payout.Status.IsSuccess():
+				report.Paid++
+			case item.Payout.Status.IsFailed():
+				report.Failed++
+			}
+		}
+	}
+
+	return report
+}
+
+// samePayoutRequest 比较两笔提现的所有字段，金额按数值比较。
+func samePayoutRequest(a, b CreatePayoutRequest) bool {
+	if !a.Amount.Equal(b.Amount) {
+		return false
+	}
+	a.Amount, b.Amount = Amount{}, Amount{}
+
+	return a == b
+}
+
+// openCheckpoint 把所有提现的当前状态写入检查点，替换之前运行追加的记录，然后打开文件用于追加。
+func (b *PayoutBatch) openCheckpoint() error {
+	if b.opts.Checkpoint == "" {
+		return nil
+	}
+
+	var data bytes.Buffer
+	encoder := json.NewEncoder(&data)
+	for _, item := range b.items {
+		if err := encoder.Encode(item); err != nil {
+			return err
+		}
+	}
+
+	if err := writeFileAtomic(b.opts.Checkpoint, data.Bytes()); err != nil {
+		return fmt.Errorf("cryptomus: write checkpoint: %w", err)
+	}
+
+	file, err := os.OpenFile(b.opts.Checkpoint, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return fmt.Errorf("cryptomus: open checkpoint: %w", err)
+	}
+	b.journal = file
+
+	return nil
+}
+
+func (b *PayoutBatch) closeCheckpoint() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.journal != nil {
+		_ = b.journal.Close()
+		b.journal = nil
+	}
+}
+
+// saveLocked 把一笔提现的新状态追加到检查点，返回前同步到磁盘，调用时必须持有 b.mu。
+func (b *PayoutBatch) saveLocked(item PayoutBatchItem) error {
+	if b.journal == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+
+	if _, err := b.journal.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("cryptomus: write checkpoint: %w", err)
+	}
+	if err := b.journal.Sync(); err != nil {
+		return fmt.Errorf("cryptomus: write checkpoint: %w", err)
+	}
+
+	return nil
+}
+
+// loadCheckpoint 读取检查点，同一 order_id 以最后一行为准。
+//
+// 最后一行不完整时（追加时进程崩溃）忽略该行，该笔提现按之前一行的状态恢复。
+func loadCheckpoint(path string) (map[string]PayoutBatchItem, error) {
+	items := map[string]PayoutBatchItem{}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return items, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	lines := bytes.Split(data, []byte("\n"))
+	for i, line := range lines {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		var item PayoutBatchItem
+		if err := json.Unmarshal(line, &item); err != nil {
+			if i == len(lines)-1 {
+				break
+			}
+			return nil, fmt.Errorf("cryptomus: invalid checkpoint %s, line %d: %w", path, i+1, err)
+		}
+		items[item.Request.OrderID] = item
+	}
+
+	return items, nil
+}
+
+// payoutColumns 是 ReadPayoutsCSV 支持的列，列名与 CreatePayoutRequest 的 JSON 字段相同。
+var payoutColumns = []string{
+	"amount", "currency", "order_id", "address", "is_subtract", "network",
+	"url_callback", "to_currency", "course_source", "from_currency", "priority", "memo",
+}
+
+func setPayoutColumn(r *CreatePayoutRequest, column, value string) (err error) {
+	switch column {
+	case "amount":
+		r.Amount, err = ParseAmount(value)
+	case "currency":
+		r.Currency = value
+	case "order_id":
+		r.OrderID = value
+	case "address":
+		r.Address = value
+	case "is_subtract":
+		if value != "" {
+			r.IsSubtract, err = strconv.ParseBool(value)
+		}
+	case "network":
+		r.Network = value
+	case "url_callback":
+		r.URLCallback = value
+	case "to_currency":
+		r.ToCurrency = value
+	case "course_source":
+		r.CourseSource = value
+	case "from_currency":
+		r.FromCurrency = value
+	case "priority":
+		r.Priority = value
+	case "memo":
+		r.Memo = value
+	}
+
+	return err
+}
+
+// ReadPayoutsCSV 读取 CSV 格式的提现列表。第一行是列名，与 CreatePayoutRequest 的 JSON 字段相同，
+// 例如 order_id,amount,currency,network,address,is_subtract，未知的列名会返回错误。
+func ReadPayoutsCSV(r io.Reader) ([]CreatePayoutRequest, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("cryptomus: read payouts csv header: %w", err)
+	}
+
+	for i, name := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(name))
+		if !slices.Contains(payoutColumns, header[i]) {
+			return nil, fmt.Errorf("cryptomus: unknown payouts csv column %q", name)
+		}
+	}
+
+	var requests []CreatePayoutRequest
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return requests, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cryptomus: read payouts csv: %w", err)
+		}
+
+		var request CreatePayoutRequest
+		for i, value := range record {
+			if err := setPayoutColumn(&request, header[i], strings.TrimSpace(value)); err != nil {
+				line, _ := reader.FieldPos(i)
+				return nil, fmt.Errorf("cryptomus: payouts csv line %d, column %q: %w", line, header[i], err)
+			}
+		}
+		requests = append(requests, request)
+	}
+}
+
+// ReadPayoutsJSON 读取 JSON 数组格式的提现列表，元素的字段与 CreatePayoutRequest 相同。
+func ReadPayoutsJSON(r io.Reader) ([]CreatePayoutRequest, error) {
+	var requests []CreatePayoutRequest
+	if err := json.NewDecoder(r).Decode(&requests); err != nil {
+		return nil, fmt.Errorf("cryptomus: read payouts json: %w", err)
+	}
+
+	return requests, nil
+}
@@ -0,0 +1,294 @@
+package cryptomus_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	cryptomus "github.com/difyz9/cryptomus-sdk-go"
+	"github.com/difyz9/cryptomus-sdk-go/cryptomustest"
+)
+
+func batchRequests() []cryptomus.CreatePayoutRequest {
+	return []cryptomus.CreatePayoutRequest{
+		{Amount: cryptomus.MustParseAmount("10"), Currency: "USDT", Network: "tron", Address: "TA", OrderID: "batch-1"},
+		{Amount: cryptomus.MustParseAmount("20"), Currency: "USDT", Network: "tron", Address: "TB", OrderID: "batch-2"},
+		{Amount: cryptomus.MustParseAmount("30"), Currency: "USDT", Network: "tron", Address: "TC", OrderID: "batch-3"},
+	}
+}
+
+// countCreates 返回统计 CreatePayout 请求数的中间件，failOrderID 不为空时拒绝该 order_id 的提现。
+func countCreates(creates *atomic.Int32, failOrderID *atomic.Value) cryptomus.Middleware {
+	return func(next cryptomus.Handler) cryptomus.Handler {
+		return func(ctx context.Context, req *cryptomus.Request) (*cryptomus.Response, error) {
+			if req.Endpoint == cryptomus.CreatePayoutEndpoint {
+				creates.Add(1)
+				if id, _ := failOrderID.Load().(string); id != "" && strings.Contains(string(req.Body), `"order_id":"`+id+`"`) {
+					return nil, context.Canceled
+				}
+			}
+			return next(ctx, req)
+		}
+	}
+}
+
+func TestPayoutBatchCheckpointResume(t *testing.T) {
+	srv := cryptomustest.NewServer()
+	defer srv.Close()
+	srv.SetBalance("USDT", cryptomus.MustParseAmount("1000"))
+
+	var creates atomic.Int32
+	var failOrderID atomic.Value
+	failOrderID.Store("batch-3")
+	sdk := srv.Client(cryptomus.WithMiddleware(countCreates(&creates, &failOrderID)))
+
+	checkpoint := filepath.Join(t.TempDir(), "batch.checkpoint.json")
+	opts := cryptomus.PayoutBatchOptions{Concurrency: 1, Checkpoint: checkpoint}
+
+	// 第一次运行：batch-3 的请求在发送前失败，模拟进程在提交过程中被中断。
+	report, err := sdk.PayoutBatch(opts).Run(context.Background(), batchRequests())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if report.Counts[cryptomus.PayoutItemCreated] != 2 || report.Items[2].State == cryptomus.PayoutItemCreated {
+		t.Fatalf("first run counts = %v, items[2] = %+v", report.Counts, report.Items[2])
+	}
+	if got, want := srv.Balance("USDT"), cryptomus.MustParseAmount("970"); !got.Equal(want) {
+		t.Fatalf("balance after first run = %s, want %s", got, want)
+	}
+
+	// 第二次运行只提交 batch-3，已创建的提现不会重复提交。
+	failOrderID.Store("")
+	creates.Store(0)
+	report, err = sdk.PayoutBatch(opts).Run(context.Background(), batchRequests())
+	if err != nil {
+		t.Fatalf("resumed Run: %v", err)
+	}
+	if report.Counts[cryptomus.PayoutItemCreated] != 3 {
+		t.Errorf("resumed run counts = %v, want 3 created", report.Counts)
+	}
+	if got := creates.Load(); got != 1 {
+		t.Errorf("resumed run create requests = %d, want 1", got)
+	}
+	if got, want := srv.Balance("USDT"), cryptomus.MustParseAmount("940"); !got.Equal(want) {
+		t.Errorf("balance after resumed run = %s, want %s", got, want)
+	}
+	if got, want := report.Totals["USDT"], cryptomus.MustParseAmount("60"); !got.Equal(want) {
+		t.Errorf("totals = %s, want %s", got, want)
+	}
+
+	// 修改已经记录在检查点中的提现后再次运行会被拒绝。
+	changed := batchRequests()
+	changed[1].Address = "TOTHER"
+	creates.Store(0)
+	if _, err := sdk.PayoutBatch(opts).Run(context.Background(), changed); !errors.Is(err, cryptomus.ErrCheckpointMismatch) {
+		t.Errorf("Run with a changed request error = %v, want ErrCheckpointMismatch", err)
+	}
+	if got := creates.Load(); got != 0 {
+		t.Errorf("create requests after mismatch = %d, want 0", got)
+	}
+
+	// 金额按数值比较，"20" 与 "20.00" 相同。
+	same := batchRequests()
+	same[1].Amount = cryptomus.MustParseAmount("20.00")
+	if _, err := sdk.PayoutBatch(opts).Run(context.Background(), same); err != nil {
+		t.Errorf("Run with an equal amount: %v", err)
+	}
+}
+
+func TestPayoutBatchCheckpointJournal(t *testing.T) {
+	srv := cryptomustest.NewServer()
+	defer srv.Close()
+	srv.SetBalance("USDT", cryptomus.MustParseAmount("1000"))
+
+	var creates atomic.Int32
+	var failOrderID atomic.Value
+	sdk := srv.Client(cryptomus.WithMiddleware(countCreates(&creates, &failOrderID)))
+
+	checkpoint := filepath.Join(t.TempDir(), "batch.checkpoint.json")
+	opts := cryptomus.PayoutBatchOptions{Concurrency: 2, Checkpoint: checkpoint}
+	requests := batchRequests()
+
+	lines := func() []string {
+		t.Helper()
+		data, err := os.ReadFile(checkpoint)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	}
+
+	if _, err := sdk.PayoutBatch(opts).Run(context.Background(), requests); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	// 运行开始时每笔提现一行，之后每次状态变化（submitting、created）只追加一行。
+	if got, want := len(lines()), 3*len(requests); got != want {
+		t.Errorf("checkpoint has %d lines, want %d", got, want)
+	}
+
+	// 追加时崩溃留下的不完整的最后一行被忽略，再次运行时压缩为每笔提现一行。
+	file, err := os.OpenFile(checkpoint, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.WriteString(`{"request":{"order_id":"batch-1"`); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	creates.Store(0)
+	report, err := sdk.PayoutBatch(opts).Run(context.Background(), requests)
+	if err != nil {
+		t.Fatalf("Run with a torn checkpoint: %v", err)
+	}
+	if report.Counts[cryptomus.PayoutItemCreated] != 3 || creates.Load() != 0 {
+		t.Errorf("counts = %v, create requests = %d", report.Counts, creates.Load())
+	}
+	if got := len(lines()); got != len(requests) {
+		t.Errorf("compacted checkpoint has %d lines, want %d", got, len(requests))
+	}
+
+	// 中间的行无效时拒绝运行。
+	if err := os.WriteFile(checkpoint, []byte("not json\n"+strings.Join(lines(), "\n")+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sdk.PayoutBatch(opts).Run(context.Background(), requests); err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("Run with an invalid checkpoint line: %v", err)
+	}
+}
+
+func TestPayoutBatchBalanceUnchecked(t *testing.T) {
+	srv := cryptomustest.NewServer()
+	defer srv.Close()
+	srv.SetBalance("USDT", cryptomus.MustParseAmount("100"))
+	sdk := srv.Client()
+
+	requests := batchRequests()[:2]
+	requests[1].FromCurrency = "BTC"
+
+	report, err := sdk.PayoutBatch(cryptomus.PayoutBatchOptions{}).Run(context.Background(), requests)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	first, second := report.Items[0], report.Items[1]
+	if first.BalanceUnchecked || first.State != cryptomus.PayoutItemCreated {
+		t.Errorf("items[0] = %+v, want created with the balance checked", first)
+	}
+	if !second.BalanceUnchecked || second.State == cryptomus.PayoutItemSkipped {
+		t.Errorf("items[1] = %+v, want submitted with the balance unchecked", second)
+	}
+
+	var summary strings.Builder
+	if err := report.WriteSummary(&summary); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(summary.String(), "balance not checked (paid from another currency): batch-2") {
+		t.Errorf("summary does not mention the unchecked payout:\n%s", summary.String())
+	}
+}
+
+func TestPayoutBatchChecks(t *testing.T) {
+	srv := cryptomustest.NewServer()
+	defer srv.Close()
+	srv.SetBalance("USDT", cryptomus.MustParseAmount("25"))
+	sdk := srv.Client()
+
+	report, err := sdk.PayoutBatch(cryptomus.PayoutBatchOptions{}).Run(context.Background(), batchRequests())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	want := []cryptomus.PayoutItemState{cryptomus.PayoutItemCreated, cryptomus.PayoutItemSkipped, cryptomus.PayoutItemSkipped}
+	for i, item := range report.Items {
+		if item.State != want[i] {
+			t.Errorf("items[%d].State = %s, want %s (%s)", i, item.State, want[i], item.Error)
+		}
+	}
+	if !strings.Contains(report.Items[1].Error, "insufficient USDT balance") {
+		t.Errorf("items[1].Error = %q", report.Items[1].Error)
+	}
+
+	if _, err := sdk.PayoutBatch(cryptomus.PayoutBatchOptions{}).Run(context.Background(), []cryptomus.CreatePayoutRequest{
+		{OrderID: "a"}, {OrderID: "a"},
+	}); err == nil {
+		t.Error("Run accepted a duplicate order_id")
+	}
+}
+
+func TestPayoutBatchWaitError(t *testing.T) {
+	srv := cryptomustest.NewServer()
+	defer srv.Close()
+	srv.SetBalance("USDT", cryptomus.MustParseAmount("1000"))
+
+	sdk := srv.Client(cryptomus.WithMiddleware(func(next cryptomus.Handler) cryptomus.Handler {
+		return func(ctx context.Context, req *cryptomus.Request) (*cryptomus.Response, error) {
+			if req.Endpoint == cryptomus.PayoutInformationEndpoint {
+				return cryptomus.NewResponse(http.StatusUnauthorized, []byte(`{"state":1,"message":"Unauthorized"}`)), nil
+			}
+			return next(ctx, req)
+		}
+	}))
+
+	report, err := sdk.PayoutBatch(cryptomus.PayoutBatchOptions{WaitForFinal: true}).Run(context.Background(), batchRequests()[:1])
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	item := report.Items[0]
+	if item.State != cryptomus.PayoutItemCreated || !strings.Contains(item.Error, "wait for final status") {
+		t.Errorf("item = %+v, want created with the wait error", item)
+	}
+
+	var summary strings.Builder
+	if err := report.WriteSummary(&summary); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(summary.String(), "batch-1") {
+		t.Errorf("summary does not mention the wait error:\n%s", summary.String())
+	}
+}
+
+func TestReadPayoutsCSV(t *testing.T) {
+	requests, err := cryptomus.ReadPayoutsCSV(strings.NewReader(
+		"Order_ID, amount, currency, network, address, is_subtract, memo\n" +
+			"p-1, 10.5, USDT, tron, TA, true,\n" +
+			"p-2, 1e-3, BTC, btc, bc1q, , \"memo, with comma\"\n"))
+	if err != nil {
+		t.Fatalf("ReadPayoutsCSV: %v", err)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("requests = %d, want 2", len(requests))
+	}
+
+	first, second := requests[0], requests[1]
+	if first.OrderID != "p-1" || !first.Amount.Equal(cryptomus.MustParseAmount("10.5")) || first.Currency != "USDT" ||
+		first.Network != "tron" || first.Address != "TA" || !first.IsSubtract || first.Memo != "" {
+		t.Errorf("requests[0] = %+v", first)
+	}
+	if second.OrderID != "p-2" || !second.Amount.Equal(cryptomus.MustParseAmount("0.001")) || second.IsSubtract || second.Memo != "memo, with comma" {
+		t.Errorf("requests[1] = %+v", second)
+	}
+
+	for name, input := range map[string]string{
+		"empty":          "",
+		"unknown column": "order_id,amount,wallet\np-1,1,x\n",
+		"invalid amount": "order_id,amount\np-1,ten\n",
+		"invalid bool":   "order_id,is_subtract\np-1,maybe\n",
+		"field count":    "order_id,amount\np-1,1,extra\n",
+	} {
+		if _, err := cryptomus.ReadPayoutsCSV(strings.NewReader(input)); err == nil {
+			t.Errorf("%s: ReadPayoutsCSV succeeded", name)
+		}
+	}
+
+	if _, err := cryptomus.ReadPayoutsCSV(strings.NewReader("order_id,amount\np-1,ten\n")); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("invalid amount error = %v, want the line number", err)
+	}
+}
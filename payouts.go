@@ -2,6 +2,7 @@ package cryptomus
 
 import (
 	"context"
+	"net/http"
 	"time"
 )
 
@@ -20,7 +21,7 @@ const (
 )
 
 type CreatePayoutRequest struct {
-	Amount       string `json:"amount"`
+	Amount       Amount `json:"amount"`
 	Currency     string `json:"currency" validate:"required"`
 	OrderID      string `json:"order_id"`
 	Address      string `json:"address"`
@@ -34,18 +35,30 @@ type CreatePayoutRequest struct {
 	Memo         string `json:"memo"`
 }
 
+// Validate 校验 CreatePayout 的请求参数。
+func (r *CreatePayoutRequest) Validate() error {
+	var v validation
+
+	v.positiveAmount("amount", r.Amount)
+	v.required("currency", r.Currency)
+	v.orderID("order_id", r.OrderID, true)
+	v.required("address", r.Address)
+
+	return v.err()
+}
+
 type PayoutData struct {
 	UUID          string       `json:"uuid"`
-	Amount        string       `json:"amount"`
+	Amount        Amount       `json:"amount"`
 	Currency      string       `json:"currency"`
 	Network       string       `json:"network"`
 	Address       string       `json:"address"`
 	TxID          string       `json:"txid"`
 	Status        PayoutStatus `json:"status"`
 	IsFinal       bool         `json:"is_final"`
-	Balance       int64        `json:"balance"`
+	Balance       Amount       `json:"balance"`
 	PayerCurrency string       `json:"payer_currency"`
-	PayerAmount   int64        `json:"payer_amount"`
+	PayerAmount   Amount       `json:"payer_amount"`
 	CreatedAt     time.Time    `json:"created_at"`
 	UpdatedAt     time.Time    `json:"updated_at"`
 }
@@ -74,7 +87,7 @@ type CreatePayoutResponse struct {
 // 示例：
 //
 //	result, err := sdk.CreatePayout(&CreatePayoutRequest{
-//		Amount:   "5",
+//		Amount:   MustParseAmount("5"),
 //		Currency: "USDT",
 //		Network:  "TRON",
 //		Address:  "TJ5Zrj8z6bJ7bk9Kf8fz1yQFbJ7b7bJ7b7b",
@@ -108,7 +121,7 @@ func (sdk *Cryptomus) CreatePayout(payload *CreatePayoutRequest) (*CreatePayoutR
 // 示例：
 //
 //	result, err := sdk.CreatePayout(ctx, &CreatePayoutRequest{
-//		Amount:   "5",
+//		Amount:   MustParseAmount("5"),
 //		Currency: "USDT",
 //		Network:  "TRON",
 //		Address:  "TJ5Zrj8z6bJ7bk9Kf8fz1yQFbJ7b7bJ7b7b",
@@ -122,15 +135,12 @@ func (sdk *Cryptomus) CreatePayout(payload *CreatePayoutRequest) (*CreatePayoutR
 func (sdk *Cryptomus) CreatePayoutWithContext(ctx context.Context, payload *CreatePayoutRequest) (*CreatePayoutResponse, error) {
 	var result CreatePayoutResponse
 
-	req := sdk.HttpClient.NewRequest().
-		SetContext(ctx).
-		SetHeader("merchant", sdk.Merchant).
-		SetHeader("sign", Sign(sdk.PayoutToken, payload)).
-		SetBody(payload).
-		SetSuccessResult(&result).
-		SetErrorResult(&result)
-
-	if _, err := req.Post(CreatePayoutEndpoint.URL()); err != nil {
+	if err := sdk.send(ctx, &Request{
+		Method:   http.MethodPost,
+		Endpoint: CreatePayoutEndpoint,
+		Token:    TokenPayout,
+		Payload:  payload,
+	}, &result); err != nil {
 		return nil, err
 	}
 
@@ -142,6 +152,15 @@ type PayoutInformationRequest struct {
 	OrderID string `json:"order_id,omitempty"`
 }
 
+// Validate 校验 PayoutInformation 的请求参数。
+func (r *PayoutInformationRequest) Validate() error {
+	var v validation
+
+	v.uuidOrOrderID(r.UUID, r.OrderID)
+
+	return v.err()
+}
+
 type PayoutInformationResponse struct {
 	*HTTPResponse
 	Result *PayoutData `json:"result"`
@@ -182,15 +201,12 @@ func (sdk *Cryptomus) PayoutInformation(payload *PayoutInformationRequest) (*Pay
 func (sdk *Cryptomus) PayoutInformationWithContext(ctx context.Context, payload *PayoutInformationRequest) (*PayoutInformationResponse, error) {
 	var result PayoutInformationResponse
 
-	req := sdk.HttpClient.NewRequest().
-		SetContext(ctx).
-		SetHeader("merchant", sdk.Merchant).
-		SetHeader("sign", Sign(sdk.PayoutToken, payload)).
-		SetBody(payload).
-		SetSuccessResult(&result).
-		SetErrorResult(&result)
-
-	if _, err := req.Post(PayoutInformationEndpoint.URL()); err != nil {
+	if err := sdk.send(ctx, &Request{
+		Method:   http.MethodPost,
+		Endpoint: PayoutInformationEndpoint,
+		Token:    TokenPayout,
+		Payload:  payload,
+	}, &result); err != nil {
 		return nil, err
 	}
 
@@ -200,6 +216,17 @@ func (sdk *Cryptomus) PayoutInformationWithContext(ctx context.Context, payload
 type PayoutHistoryRequest struct {
 	DateFrom string `json:"date_from,omitempty"`
 	DateTo   string `json:"date_to,omitempty"`
+	Cursor   string `json:"-"`
+}
+
+// Validate 校验 PayoutHistory 的请求参数。
+func (r *PayoutHistoryRequest) Validate() error {
+	var v validation
+
+	v.historyDate("date_from", r.DateFrom)
+	v.historyDate("date_to", r.DateTo)
+
+	return v.err()
 }
 
 type PayoutHistoryData struct {
@@ -217,6 +244,8 @@ type PayoutHistoryResponse struct {
 //
 // Details: https://doc.cryptomus.com/business/payouts/payout-history
 //
+// To get next/previous page entries, specify the next/previous cursor hash
+//
 // Example:
 //
 //	result, err := sdk.PayoutHistory(&PayoutHistoryRequest{
@@ -234,6 +263,8 @@ func (sdk *Cryptomus) PayoutHistory(payload *PayoutHistoryRequest) (*PayoutHisto
 //
 // Details: https://doc.cryptomus.com/business/payouts/payout-history
 //
+// To get next/previous page entries, specify the next/previous cursor hash
+//
 // Example:
 //
 //	result, err := sdk.PayoutHistoryWithContext(ctx, &PayoutHistoryRequest{
@@ -246,15 +277,13 @@ func (sdk *Cryptomus) PayoutHistory(payload *PayoutHistoryRequest) (*PayoutHisto
 func (sdk *Cryptomus) PayoutHistoryWithContext(ctx context.Context, payload *PayoutHistoryRequest) (*PayoutHistoryResponse, error) {
 	var result PayoutHistoryResponse
 
-	req := sdk.HttpClient.NewRequest().
-		SetContext(ctx).
-		SetHeader("merchant", sdk.Merchant).
-		SetHeader("sign", Sign(sdk.PayoutToken, payload)).
-		SetBody(payload).
-		SetSuccessResult(&result).
-		SetErrorResult(&result)
-
-	if _, err := req.Post(PayoutHistoryEndpoint.URL()); err != nil {
+	if err := sdk.send(ctx, &Request{
+		Method:   http.MethodPost,
+		Endpoint: PayoutHistoryEndpoint,
+		Token:    TokenPayout,
+		Payload:  payload,
+		Query:    map[string]string{"cursor": payload.Cursor},
+	}, &result); err != nil {
 		return nil, err
 	}
 
@@ -262,21 +291,21 @@ func (sdk *Cryptomus) PayoutHistoryWithContext(ctx context.Context, payload *Pay
 }
 
 type PayoutListOfServiceLimit struct {
-	MinAmount string `json:"min_amount"`
-	MaxAmount string `json:"max_amount"`
+	MinAmount Amount `json:"min_amount"`
+	MaxAmount Amount `json:"max_amount"`
 }
 
 type PayoutListOfServiceCommission struct {
-	FeeAmount string `json:"fee_amount"`
-	Percent   string `json:"percent"`
+	FeeAmount Amount `json:"fee_amount"`
+	Percent   Amount `json:"percent"`
 }
 
 type PayoutListOfServicesData struct {
-	Network     string                         `json:"network"`
-	Currency    string                         `json:"currency"`
-	IsAvailable bool                           `json:"is_available"`
-	Limit       PaymentListOfServiceLimit      `json:"limit"`
-	Commission  PaymentListOfServiceCommission `json:"commission"`
+	Network     string                        `json:"network"`
+	Currency    string                        `json:"currency"`
+	IsAvailable bool                          `json:"is_available"`
+	Limit       PayoutListOfServiceLimit      `json:"limit"`
+	Commission  PayoutListOfServiceCommission `json:"commission"`
 }
 
 type PayoutListOfServicesResponse struct {
@@ -315,14 +344,11 @@ func (sdk *Cryptomus) PayoutListOfServices() (*PayoutListOfServicesResponse, err
 func (sdk *Cryptomus) PayoutListOfServicesWithContext(ctx context.Context) (*PayoutListOfServicesResponse, error) {
 	var result PayoutListOfServicesResponse
 
-	req := sdk.HttpClient.NewRequest().
-		SetContext(ctx).
-		SetHeader("merchant", sdk.Merchant).
-		SetHeader("sign", Sign(sdk.PayoutToken, nil)).
-		SetSuccessResult(&result).
-		SetErrorResult(&result)
-
-	if _, err := req.Get(PayoutListOfServicesEndpoint.URL()); err != nil {
+	if err := sdk.send(ctx, &Request{
+		Method:   http.MethodGet,
+		Endpoint: PayoutListOfServicesEndpoint,
+		Token:    TokenPayout,
+	}, &result); err != nil {
 		return nil, err
 	}
 
@@ -330,15 +356,25 @@ func (sdk *Cryptomus) PayoutListOfServicesWithContext(ctx context.Context) (*Pay
 }
 
 type TransferToPersonalWalletRequest struct {
-	Amount   string `json:"amount" validate:"required"`
+	Amount   Amount `json:"amount" validate:"required"`
 	Currency string `json:"currency" validate:"required"`
 }
 
+// Validate 校验 TransferToPersonalWallet 的请求参数。
+func (r *TransferToPersonalWalletRequest) Validate() error {
+	var v validation
+
+	v.positiveAmount("amount", r.Amount)
+	v.required("currency", r.Currency)
+
+	return v.err()
+}
+
 type TransferToPersonalWalletData struct {
 	UserWalletTransactionUUID string `json:"user_wallet_transaction_uuid"`
-	UserWalletBalance         string `json:"user_wallet_balance"`
+	UserWalletBalance         Amount `json:"user_wallet_balance"`
 	MerchantTransactionUUID   string `json:"merchant_transaction_uuid"`
-	MerchantBalance           string `json:"merchant_balance"`
+	MerchantBalance           Amount `json:"merchant_balance"`
 }
 
 type TransferToPersonalWalletResponse struct {
@@ -353,7 +389,7 @@ type TransferToPersonalWalletResponse struct {
 // Example:
 //
 //	result, err := sdk.TransferToPersonalWallet(&TransferToPersonalWalletRequest{
-//		Amount:   "5",
+//		Amount:   MustParseAmount("5"),
 //		Currency: "USDT",
 //	})
 //	if err != nil {
@@ -370,7 +406,7 @@ func (sdk *Cryptomus) TransferToPersonalWallet(payload *TransferToPersonalWallet
 // Example:
 //
 //	result, err := sdk.TransferToPersonalWalletWithContext(ctx, &TransferToPersonalWalletRequest{
-//		Amount:   "5",
+//		Amount:   MustParseAmount("5"),
 //		Currency: "USDT",
 //	})
 //	if err != nil {
@@ -379,15 +415,12 @@ func (sdk *Cryptomus) TransferToPersonalWallet(payload *TransferToPersonalWallet
 func (sdk *Cryptomus) TransferToPersonalWalletWithContext(ctx context.Context, payload *TransferToPersonalWalletRequest) (*TransferToPersonalWalletResponse, error) {
 	var result TransferToPersonalWalletResponse
 
-	req := sdk.HttpClient.NewRequest().
-		SetContext(ctx).
-		SetHeader("merchant", sdk.Merchant).
-		SetHeader("sign", Sign(sdk.PayoutToken, payload)).
-		SetBody(payload).
-		SetSuccessResult(&result).
-		SetErrorResult(&result)
-
-	if _, err := req.Post(TransferToPersonalWalletEndpoint.URL()); err != nil {
+	if err := sdk.send(ctx, &Request{
+		Method:   http.MethodPost,
+		Endpoint: TransferToPersonalWalletEndpoint,
+		Token:    TokenPayout,
+		Payload:  payload,
+	}, &result); err != nil {
 		return nil, err
 	}
 
@@ -395,15 +428,25 @@ func (sdk *Cryptomus) TransferToPersonalWalletWithContext(ctx context.Context, p
 }
 
 type TransferToBusinessWalletRequest struct {
-	Amount   string `json:"amount" validate:"required"`
+	Amount   Amount `json:"amount" validate:"required"`
 	Currency string `json:"currency" validate:"required"`
 }
 
+// Validate 校验 TransferToBusinessWallet 的请求参数。
+func (r *TransferToBusinessWalletRequest) Validate() error {
+	var v validation
+
+	v.positiveAmount("amount", r.Amount)
+	v.required("currency", r.Currency)
+
+	return v.err()
+}
+
 type TransferToBusinessWalletData struct {
 	UserWalletTransactionUUID string `json:"user_wallet_transaction_uuid"`
-	UserWalletBalance         string `json:"user_wallet_balance"`
+	UserWalletBalance         Amount `json:"user_wallet_balance"`
 	MerchantTransactionUUID   string `json:"merchant_transaction_uuid"`
-	MerchantBalance           string `json:"merchant_balance"`
+	MerchantBalance           Amount `json:"merchant_balance"`
 }
 
 type TransferToBusinessWalletResponse struct {
@@ -418,7 +461,7 @@ type TransferToBusinessWalletResponse struct {
 // Example:
 //
 //	result, err := sdk.TransferToBusinessWallet(&TransferToBusinessWalletRequest{
-//		Amount:   "5",
+//		Amount:   MustParseAmount("5"),
 //		Currency: "USDT",
 //	})
 //	if err != nil {
@@ -435,7 +478,7 @@ func (sdk *Cryptomus) TransferToBusinessWallet(payload *TransferToBusinessWallet
 // Example:
 //
 //	result, err := sdk.TransferToBusinessWalletWithContext(ctx, &TransferToBusinessWalletRequest{
-//		Amount:   "5",
+//		Amount:   MustParseAmount("5"),
 //		Currency: "USDT",
 //	})
 //	if err != nil {
@@ -444,15 +487,12 @@ func (sdk *Cryptomus) TransferToBusinessWallet(payload *TransferToBusinessWallet
 func (sdk *Cryptomus) TransferToBusinessWalletWithContext(ctx context.Context, payload *TransferToBusinessWalletRequest) (*TransferToBusinessWalletResponse, error) {
 	var result TransferToBusinessWalletResponse
 
-	req := sdk.HttpClient.NewRequest().
-		SetContext(ctx).
-		SetHeader("merchant", sdk.Merchant).
-		SetHeader("sign", Sign(sdk.PayoutToken, payload)).
-		SetBody(payload).
-		SetSuccessResult(&result).
-		SetErrorResult(&result)
-
-	if _, err := req.Post(TransferToBusinessWalletEndpoint.URL()); err != nil {
+	if err := sdk.send(ctx, &Request{
+		Method:   http.MethodPost,
+		Endpoint: TransferToBusinessWalletEndpoint,
+		Token:    TokenPayout,
+		Payload:  payload,
+	}, &result); err != nil {
 		return nil, err
 	}
 
@@ -0,0 +1,261 @@
+package cryptomus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrServiceNotFound 表示 Cryptomus 不支持该币种与网络。
+var ErrServiceNotFound = errors.New("cryptomus: service not found")
+
+// DefaultServicesCacheTTL 是 QuotePayout 与 QuoteInvoice 缓存服务列表的默认时间。
+const DefaultServicesCacheTTL = 5 * time.Minute
+
+// WithServicesCacheTTL 设置 QuotePayout 与 QuoteInvoice 缓存服务列表（限额与手续费）的时间，
+// 默认为 DefaultServicesCacheTTL，ttl <= 0 时每次都重新获取。
+func WithServicesCacheTTL(ttl time.Duration) Option {
+	return func(c *Cryptomus) {
+		c.services.ttl = ttl
+	}
+}
+
+// Quote 是按服务列表中的限额与手续费估算的结果，实际的手续费以 Cryptomus 创建时返回的为准。
+type Quote struct {
+	Currency string `json:"currency"`
+	Network  string `json:"network"`
+	// Amount 是报价的金额。
+	Amount Amount `json:"amount"`
+	// Fee 是预计的手续费，包括固定手续费与按比例计算的手续费。
+	Fee Amount `json:"fee"`
+	// Total 是付款方支付的金额：提现为从商户余额中扣除的金额，发票为客户支付的金额。
+	Total Amount `json:"total"`
+	// Net 是收款方收到的金额：提现为收款地址收到的金额，发票为商户入账的金额。
+	Net Amount `json:"net"`
+	// MinAmount、MaxAmount 是该币种与网络的限额，为 0 时不限制。
+	MinAmount Amount `json:"min_amount"`
+	MaxAmount Amount `json:"max_amount"`
+	// Available 表示该币种与网络当前是否可用。
+	Available bool `json:"available"`
+	// WithinLimits 表示 Amount 在 MinAmount 与 MaxAmount 之间。
+	WithinLimits bool `json:"within_limits"`
+}
+
+// Check 在币种与网络不可用、金额超出限额或不足以支付手续费时返回错误，否则返回 nil。
+func (q *Quote) Check() error {
+	switch {
+	case !q.Available:
+		return fmt.Errorf("cryptomus: %s on %s is not available", q.Currency, q.Network)
+	case q.MinAmount.Sign() > 0 && q.Amount.Cmp(q.MinAmount) < 0:
+		return fmt.Errorf("cryptomus: amount %s is below the minimum of %s", q.Amount, q.MinAmount)
+	case q.MaxAmount.Sign() > 0 && q.Amount.Cmp(q.MaxAmount) > 0:
+		return fmt.Errorf("cryptomus: amount %s is above the maximum of %s", q.Amount, q.MaxAmount)
+	case q.Net.Sign() <= 0:
+		return fmt.Errorf("cryptomus: amount %s does not cover the fee of %s", q.Amount, q.Fee)
+	}
+
+	return nil
+}
+
+// QuotePayout 按 PayoutListOfServices 估算提现的手续费、收款地址收到的金额与是否在限额之内，
+// 参数与 CreatePayoutRequest 的同名字段相同。服务列表按 WithServicesCacheTTL 缓存。
+//
+// isSubtract 为 true 时手续费从 amount 中扣除，收款地址收到 amount - 手续费；
+// 否则收款地址收到 amount，商户余额另外扣除手续费。不支持该币种与网络时返回 ErrServiceNotFound。
+//
+// 示例：
+//
+//	quote, err := sdk.QuotePayout(ctx, "USDT", "tron", cryptomus.MustParseAmount("100"), true)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	if err := quote.Check(); err != nil {
+//		return err // 金额超出限额，不提交提现
+//	}
+//	log.Println("fee:", quote.Fee, "received:", quote.Net)
+func (sdk *Cryptomus) QuotePayout(ctx context.Context, currency, network string, amount Amount, isSubtract bool) (*Quote, error) {
+	services, err := sdk.services.payout.get(ctx, sdk.services.ttl, func(ctx context.Context) ([]*PayoutListOfServicesData, error) {
+		result, err := sdk.PayoutListOfServicesWithContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return result.Result, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	service := findPayoutService(services, currency, network)
+	if service == nil {
+		return nil, fmt.Errorf("cryptomus: no payout service for %s on %s: %w", currency, network, ErrServiceNotFound)
+	}
+
+	return payoutQuote(service, amount, isSubtract), nil
+}
+
+// QuoteInvoice 按 PaymentListOfServices 估算客户以 currency 在 network 上支付 amount 时的手续费、
+// 商户入账的金额与是否在限额之内。服务列表按 WithServicesCacheTTL 缓存。
+//
+// subtract 与 CreateInvoiceRequest.Subtract 相同，是由客户承担的手续费百分比（0 到 100），
+// 客户支付 amount 加上其承担的部分，商户入账 amount 减去其余部分。不支持该币种与网络时返回 ErrServiceNotFound。
+//
+// 示例：
+//
+//	quote, err := sdk.QuoteInvoice(ctx, "USDT", "tron", cryptomus.MustParseAmount("15"), 0)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	if !quote.WithinLimits {
+//		// 提示客户选择其他币种或调整金额
+//	}
+func (sdk *Cryptomus) QuoteInvoice(ctx context.Context, currency, network string, amount Amount, subtract int) (*Quote, error) {
+	if subtract < 0 || subtract > 100 {
+		return nil, fmt.Errorf("cryptomus: subtract must be between 0 and 100, got %d", subtract)
+	}
+
+	services, err := sdk.services.payment.get(ctx, sdk.services.ttl, func(ctx context.Context) ([]PaymentListOfServicesData, error) {
+		result, err := sdk.PaymentListOfServicesWithContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return result.Result, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, service := range services {
+		if !matchService(service.Currency, service.Network, currency, network) {
+			continue
+		}
+
+		quote := newQuote(service.Currency, service.Network, amount, service.IsAvailable,
+			service.Limit.MinAmount, service.Limit.MaxAmount, service.Commission.FeeAmount, service.Commission.Percent)
+
+		payer := quote.Fee.Mul(AmountFromInt(int64(subtract))).Div(AmountFromInt(100), CurrencyDecimals(quote.Currency))
+		quote.Total = amount.Add(payer)
+		quote.Net = amount.Sub(quote.Fee.Sub(payer))
+
+		return quote, nil
+	}
+
+	return nil, fmt.Errorf("cryptomus: no payment service for %s on %s: %w", currency, network, ErrServiceNotFound)
+}
+
+// payoutQuote 按服务的限额与手续费计算提现的报价。
+func payoutQuote(service *PayoutListOfServicesData, amount Amount, isSubtract bool) *Quote {
+	quote := newQuote(service.Currency, service.Network, amount, service.IsAvailable,
+		service.Limit.MinAmount, service.Limit.MaxAmount, service.Commission.FeeAmount, service.Commission.Percent)
+
+	if isSubtract {
+		quote.Total, quote.Net = amount, amount.Sub(quote.Fee)
+	} else {
+		quote.Total, quote.Net = amount.Add(quote.Fee), amount
+	}
+
+	return quote
+}
+
+// newQuote 计算手续费与限额，Total 与 Net 由调用方填写。
+func newQuote(currency, network string, amount Amount, available bool, minAmount, maxAmount, feeAmount, percent Amount) *Quote {
+	fee := feeAmount.Add(amount.Mul(percent).Div(AmountFromInt(100), CurrencyDecimals(currency)))
+
+	return &Quote{
+		Currency:  currency,
+		Network:   network,
+		Amount:    amount,
+		Fee:       fee,
+		MinAmount: minAmount,
+		MaxAmount: maxAmount,
+		Available: available,
+		WithinLimits: (minAmount.Sign() <= 0 || amount.Cmp(minAmount) >= 0) &&
+			(maxAmount.Sign() <= 0 || amount.Cmp(maxAmount) <= 0),
+	}
+}
+
+func findPayoutService(services []*PayoutListOfServicesData, currency, network string) *PayoutListOfServicesData {
+	for _, service := range services {
+		if service != nil && matchService(service.Currency, service.Network, currency, network) {
+			return service
+		}
+	}
+
+	return nil
+}
+
+// matchService 按币种与网络匹配服务，不区分大小写，network 为空时只匹配币种。
+func matchService(serviceCurrency, serviceNetwork, currency, network string) bool {
+	return strings.EqualFold(serviceCurrency, currency) && (network == "" || strings.EqualFold(serviceNetwork, network))
+}
+
+// servicesCache 缓存 QuotePayout 与 QuoteInvoice 使用的服务列表。
+type servicesCache struct {
+	ttl     time.Duration
+	payment cached[[]PaymentListOfServicesData]
+	payout  cached[[]*PayoutListOfServicesData]
+}
+
+// servicesFetchTimeout 是获取服务列表的超时时间。获取由多个调用共享，不受单个调用的 ctx 取消影响。
+const servicesFetchTimeout = 10 * time.Second
+
+// cached 是一个在 ttl 后过期的值。同一时间只有一个调用获取新值，其他调用等待它的结果，
+// 每个调用的 ctx 结束时立即返回，不会被其他调用的获取阻塞。
+type cached[T any] struct {
+	mu      sync.Mutex
+	value   T
+	expires time.Time
+	// fetch 不为 nil 时表示正在获取。
+	fetch *cachedFetch[T]
+}
+
+type cachedFetch[T any] struct {
+	done  chan struct{}
+	value T
+	err   error
+}
+
+func (c *cached[T]) get(ctx context.Context, ttl time.Duration, fetch func(context.Context) (T, error)) (T, error) {
+	c.mu.Lock()
+	if ttl > 0 && time.Now().Before(c.expires) {
+		value := c.value
+		c.mu.Unlock()
+		return value, nil
+	}
+
+	f := c.fetch
+	if f == nil {
+		f = &cachedFetch[T]{done: make(chan struct{})}
+		c.fetch = f
+
+		go func() {
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), servicesFetchTimeout)
+			defer cancel()
+
+			value, err := fetch(ctx)
+
+			c.mu.Lock()
+			if err == nil {
+				c.value, c.expires = value, time.Now().Add(ttl)
+			}
+			c.fetch = nil
+			c.mu.Unlock()
+
+			f.value, f.err = value, err
+			close(f.done)
+		}()
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.value, f.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
@@ -0,0 +1,77 @@
+package cryptomus_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cryptomus "github.com/difyz9/cryptomus-sdk-go"
+	"github.com/difyz9/cryptomus-sdk-go/cryptomustest"
+)
+
+// TestQuotePayoutSharesFetch 检查并发的 QuotePayout 只获取一次服务列表，
+// 并且等待中的调用在自己的 ctx 结束时立即返回。
+func TestQuotePayoutSharesFetch(t *testing.T) {
+	srv := cryptomustest.NewServer()
+	defer srv.Close()
+
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	sdk := srv.Client(cryptomus.WithMiddleware(func(next cryptomus.Handler) cryptomus.Handler {
+		return func(ctx context.Context, req *cryptomus.Request) (*cryptomus.Response, error) {
+			if req.Endpoint == cryptomus.PayoutListOfServicesEndpoint {
+				fetches.Add(1)
+				<-release
+			}
+			return next(ctx, req)
+		}
+	}))
+
+	amount := cryptomus.MustParseAmount("100")
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 4)
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := sdk.QuotePayout(context.Background(), "USDT", "tron", amount, false)
+			errs <- err
+		}()
+	}
+
+	// 获取阻塞时，带超时的调用按自己的 ctx 返回。
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := sdk.QuotePayout(ctx, "USDT", "tron", amount, false); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("QuotePayout with an expired ctx error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("QuotePayout with an expired ctx returned after %s", elapsed)
+	}
+
+	close(release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("QuotePayout: %v", err)
+		}
+	}
+
+	if got := fetches.Load(); got != 1 {
+		t.Errorf("service list requests = %d, want 1", got)
+	}
+
+	// 缓存有效期内不再请求。
+	if _, err := sdk.QuotePayout(context.Background(), "USDT", "tron", amount, false); err != nil {
+		t.Fatalf("QuotePayout: %v", err)
+	}
+	if got := fetches.Load(); got != 1 {
+		t.Errorf("service list requests after the cache was filled = %d, want 1", got)
+	}
+}
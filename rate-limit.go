@@ -0,0 +1,330 @@
+package cryptomus
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// EndpointGroup 是限流使用的接口分组，同一分组的接口共用一个令牌桶。
+type EndpointGroup string
+
+const (
+	EndpointGroupPayment      EndpointGroup = "payment"       // 发票、退款、支付服务、折扣与支付 Webhook 测试
+	EndpointGroupPayout       EndpointGroup = "payout"        // 提现、转账与提现 Webhook 测试
+	EndpointGroupStaticWallet EndpointGroup = "static_wallet" // 静态钱包与钱包 Webhook 测试
+	EndpointGroupOther        EndpointGroup = "other"         // 余额、汇率、循环支付等其余接口
+)
+
+// endpointGroups 是各接口所属的分组，未列出的接口属于 EndpointGroupOther。
+var endpointGroups = map[Endpoint]EndpointGroup{
+	CreateInvoiceEndpoint:              EndpointGroupPayment,
+	GenerateQRCodeInvoiceEndpoint:      EndpointGroupPayment,
+	PaymentInformationEndpoint:         EndpointGroupPayment,
+	RefundEndpoint:                     EndpointGroupPayment,
+	ResendWebhookEndpoint:              EndpointGroupPayment,
+	PaymentListOfServicesEndpoint:      EndpointGroupPayment,
+	PaymentHistoryEndpoint:             EndpointGroupPayment,
+	ListOfDiscountsEndpoint:            EndpointGroupPayment,
+	SetDiscountToPaymentMethodEndpoint: EndpointGroupPayment,
+	TestingWebhookPaymentEndpoint:      EndpointGroupPayment,
+
+	CreatePayoutEndpoint:             EndpointGroupPayout,
+	PayoutInformationEndpoint:        EndpointGroupPayout,
+	PayoutHistoryEndpoint:            EndpointGroupPayout,
+	PayoutListOfServicesEndpoint:     EndpointGroupPayout,
+	TransferToPersonalWalletEndpoint: EndpointGroupPayout,
+	TransferToBusinessWalletEndpoint: EndpointGroupPayout,
+	TestingWebhookPayoutEndpoint:     EndpointGroupPayout,
+
+	CreateStaticWalletEndpoint:            EndpointGroupStaticWallet,
+	GenerateQRCodeWalletEndpoint:          EndpointGroupStaticWallet,
+	BlockStaticWalletEndpoint:             EndpointGroupStaticWallet,
+	RefundPaymentOnBlockedAddressEndpoint: EndpointGroupStaticWallet,
+	TestingWebhookWalletEndpoint:          EndpointGroupStaticWallet,
+}
+
+// Group 返回接口所属的限流分组。
+func (e Endpoint) Group() EndpointGroup {
+	if group, ok := endpointGroups[e]; ok {
+		return group
+	}
+
+	return EndpointGroupOther
+}
+
+// RateLimit 是一个令牌桶：每秒补充 Rate 个令牌，最多积累 Burst 个。
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimits 是每个分组的限流配置，没有配置的分组不限流。
+type RateLimits map[EndpointGroup]RateLimit
+
+// DefaultRateLimits 返回默认的限流配置。Cryptomus 没有公开具体的限额，这些值是保守的估计，
+// 请根据实际收到的 429 调整。
+func DefaultRateLimits() RateLimits {
+	return RateLimits{
+		EndpointGroupPayment:      {Rate: 10, Burst: 20},
+		EndpointGroupPayout:       {Rate: 5, Burst: 10},
+		EndpointGroupStaticWallet: {Rate: 5, Burst: 10},
+		EndpointGroupOther:        {Rate: 10, Burst: 20},
+	}
+}
+
+// WithRateLimit 为 SDK 实例启用客户端限流，每个分组使用一个令牌桶，由使用同一实例的所有 goroutine 共享。
+//
+// 每次 HTTP 请求（包括重试）前都会等待令牌，ctx 被取消时立即返回 ctx 的错误。
+// 收到 HTTP 429 时令牌的补充速度减半，之后每次成功的请求逐步恢复，已经在等待的请求会按新的速度重新计算等待时间；
+// HTTP 429 或 503 的响应包含 Retry-After 时，该分组的所有请求（包括已经在等待的请求）都会等待到指定的时间之后。
+//
+// 示例：
+//
+//	sdk := cryptomus.New(
+//		cryptomus.WithMerchant(merchantID),
+//		cryptomus.WithPaymentToken(paymentToken),
+//		cryptomus.WithRateLimit(cryptomus.DefaultRateLimits()),
+//	)
+func WithRateLimit(limits RateLimits) Option {
+	return func(c *Cryptomus) {
+		limiter := &rateLimiter{buckets: map[EndpointGroup]*tokenBucket{}}
+		for group, limit := range limits {
+			if limit.Rate <= 0 {
+				continue
+			}
+			limiter.buckets[group] = newTokenBucket(limit, time.Now)
+		}
+
+		c.rateLimiter = limiter
+	}
+}
+
+// rateLimiter 为每个分组维护一个令牌桶，nil 表示不限流。
+type rateLimiter struct {
+	buckets map[EndpointGroup]*tokenBucket
+}
+
+// wait 等待 endpoint 所属分组的令牌。
+func (l *rateLimiter) wait(ctx context.Context, endpoint Endpoint) error {
+	if l == nil {
+		return nil
+	}
+
+	bucket, ok := l.buckets[endpoint.Group()]
+	if !ok {
+		return nil
+	}
+
+	return bucket.wait(ctx)
+}
+
+// observe 根据响应调整 endpoint 所属分组的速度。
+func (l *rateLimiter) observe(endpoint Endpoint, status int, header http.Header) {
+	if l == nil {
+		return
+	}
+
+	bucket, ok := l.buckets[endpoint.Group()]
+	if !ok {
+		return
+	}
+
+	bucket.observe(status, header)
+}
+
+// minRateFactor 限制 429 之后速度下降的下限，为配置速度的 1/minRateFactor。
+const minRateFactor = 16
+
+type tokenBucket struct {
+	limit RateLimit
+	// now 返回当前时间，测试中可以替换。
+	now func() time.Time
+
+	mu     sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+	// pausedUntil 是 Retry-After 指定的恢复时间。
+	pausedUntil time.Time
+	// waiting 是正在等待令牌的预留，速度或恢复时间变化时更新它们的 at 并关闭 changed 通知等待者。
+	waiting map[*reservation]struct{}
+	changed chan struct{}
+}
+
+// reservation 是一个正在等待的令牌预留，at 是令牌可用的时间，由 mu 保护。
+type reservation struct {
+	at time.Time
+}
+
+func newTokenBucket(limit RateLimit, now func() time.Time) *tokenBucket {
+	limit.Burst = max(limit.Burst, 1)
+
+	return &tokenBucket{
+		limit:   limit,
+		now:     now,
+		rate:    limit.Rate,
+		tokens:  float64(limit.Burst),
+		last:    now(),
+		waiting: map[*reservation]struct{}{},
+		changed: make(chan struct{}),
+	}
+}
+
+// wait 预留一个令牌并等待到它可用。令牌数可以为负，等待的请求按预留的顺序依次通过。
+func (b *tokenBucket) wait(ctx context.Context) error {
+	r := b.reserve()
+	if r == nil {
+		return nil
+	}
+
+	for {
+		delay, changed := b.delay(r)
+		if delay <= 0 {
+			b.done(r)
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			b.cancel(r)
+			return ctx.Err()
+		case <-changed:
+			// 速度或恢复时间变化，重新计算等待时间。
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// reserve 预留一个令牌，令牌立即可用时返回 nil。
+func (b *tokenBucket) reserve() *reservation {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	b.refill(now)
+	b.tokens--
+
+	var delay time.Duration
+	if b.tokens < 0 {
+		delay = durationSeconds(-b.tokens / b.rate)
+	}
+
+	at := now.Add(delay)
+	if b.pausedUntil.After(at) {
+		at = b.pausedUntil
+	}
+	if !at.After(now) {
+		return nil
+	}
+
+	r := &reservation{at: at}
+	b.waiting[r] = struct{}{}
+
+	return r
+}
+
+// delay 返回 r 还需要等待的时间，以及在等待时间变化时会被关闭的通道。
+func (b *tokenBucket) delay(r *reservation) (time.Duration, <-chan struct{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return r.at.Sub(b.now()), b.changed
+}
+
+// done 在预留的令牌可用后移除预留。
+func (b *tokenBucket) done(r *reservation) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.waiting, r)
+}
+
+// cancel 归还被取消的请求预留的令牌，排在它之后的请求提前 1/rate 秒。
+func (b *tokenBucket) cancel(r *reservation) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.waiting, r)
+	b.tokens = min(b.tokens+1, float64(b.limit.Burst))
+
+	step := durationSeconds(1 / b.rate)
+	for other := range b.waiting {
+		if other.at.After(r.at) {
+			other.at = maxTime(other.at.Add(-step), b.pausedUntil)
+		}
+	}
+	b.notify()
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = min(b.tokens+elapsed.Seconds()*b.rate, float64(b.limit.Burst))
+		b.last = now
+	}
+}
+
+func (b *tokenBucket) observe(status int, header http.Header) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	b.refill(now)
+
+	rate := b.rate
+	switch {
+	case status == http.StatusTooManyRequests:
+		// 速度减半并清空积累的令牌，避免积压的请求再次触发 429。
+		b.rate = max(b.rate/2, b.limit.Rate/minRateFactor)
+		b.tokens = min(b.tokens, 0)
+	case status > 0 && status < http.StatusBadRequest && b.rate < b.limit.Rate:
+		// 每次成功的请求恢复配置速度的 1/10。
+		b.rate = min(b.rate+b.limit.Rate/10, b.limit.Rate)
+	}
+
+	paused := false
+	if wait, ok := retryAfter(header); ok && (status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable) {
+		if until := now.Add(wait); until.After(b.pausedUntil) {
+			b.pausedUntil, paused = until, true
+		}
+	}
+
+	if rate == b.rate && !paused {
+		return
+	}
+
+	// 已经在等待的请求按新的速度重新计算剩余的等待时间，并且不早于 Retry-After 指定的时间。
+	for r := range b.waiting {
+		if remaining := r.at.Sub(now); remaining > 0 {
+			r.at = now.Add(time.Duration(float64(remaining) * rate / b.rate))
+		}
+		r.at = maxTime(r.at, b.pausedUntil)
+	}
+	b.notify()
+}
+
+// notify 唤醒所有等待者重新计算等待时间，调用时必须持有 mu。
+func (b *tokenBucket) notify() {
+	if len(b.waiting) == 0 {
+		return
+	}
+
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+func durationSeconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+
+	return b
+}
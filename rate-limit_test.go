@@ -0,0 +1,249 @@
+package cryptomus
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func newTestBucket(limit RateLimit) (*tokenBucket, *testClock) {
+	clock := &testClock{t: time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)}
+	return newTokenBucket(limit, clock.now), clock
+}
+
+// after 返回预留相对当前时间的等待时间，令牌立即可用时为 0。
+func after(b *tokenBucket, r *reservation) time.Duration {
+	if r == nil {
+		return 0
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return r.at.Sub(b.now())
+}
+
+func TestTokenBucketReserve(t *testing.T) {
+	b, clock := newTestBucket(RateLimit{Rate: 2, Burst: 2})
+
+	var got []time.Duration
+	for range 4 {
+		got = append(got, after(b, b.reserve()))
+	}
+	want := []time.Duration{0, 0, 500 * time.Millisecond, time.Second}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("delays = %v, want %v", got, want)
+		}
+	}
+
+	// 两秒后补充四个令牌，先还清两个预留，最多积累 Burst 个。
+	clock.advance(2 * time.Second)
+	for range 2 {
+		if r := b.reserve(); r != nil {
+			t.Errorf("reservation after refill waits %v", after(b, r))
+		}
+	}
+	if d := after(b, b.reserve()); d != 500*time.Millisecond {
+		t.Errorf("delay = %v, want 500ms", d)
+	}
+}
+
+func TestTokenBucketCancel(t *testing.T) {
+	b, _ := newTestBucket(RateLimit{Rate: 2, Burst: 1})
+
+	b.reserve()
+	first, second, third := b.reserve(), b.reserve(), b.reserve()
+
+	b.cancel(second)
+	if d := after(b, first); d != 500*time.Millisecond {
+		t.Errorf("earlier reservation moved to %v", d)
+	}
+	if d := after(b, third); d != time.Second {
+		t.Errorf("later reservation = %v, want 1s", d)
+	}
+	if len(b.waiting) != 2 {
+		t.Errorf("waiting = %d, want 2", len(b.waiting))
+	}
+
+	// 归还的令牌让新的预留同样提前。
+	if d := after(b, b.reserve()); d != 1500*time.Millisecond {
+		t.Errorf("new reservation = %v, want 1.5s", d)
+	}
+}
+
+func TestTokenBucketRateLimitedRescalesQueued(t *testing.T) {
+	b, _ := newTestBucket(RateLimit{Rate: 2, Burst: 1})
+
+	b.reserve()
+	first, second := b.reserve(), b.reserve()
+	changed := b.changed
+
+	b.observe(http.StatusTooManyRequests, nil)
+
+	select {
+	case <-changed:
+	default:
+		t.Error("waiters not notified")
+	}
+	if b.rate != 1 {
+		t.Errorf("rate = %v, want 1", b.rate)
+	}
+	if d := after(b, first); d != time.Second {
+		t.Errorf("first = %v, want 1s", d)
+	}
+	if d := after(b, second); d != 2*time.Second {
+		t.Errorf("second = %v, want 2s", d)
+	}
+	if d := after(b, b.reserve()); d != 3*time.Second {
+		t.Errorf("new reservation = %v, want 3s", d)
+	}
+
+	// 成功的请求逐步恢复速度，等待的请求相应提前。
+	b.observe(http.StatusOK, nil)
+	if want := 5 * time.Second / 3; b.rate != 1.2 || (after(b, second)-want).Abs() > time.Microsecond {
+		t.Errorf("rate = %v, second = %v, want 1.2 and %v", b.rate, after(b, second), want)
+	}
+}
+
+func TestTokenBucketRetryAfter(t *testing.T) {
+	header := http.Header{"Retry-After": {"10"}}
+
+	tests := []struct {
+		status int
+		pause  bool
+	}{
+		{status: http.StatusTooManyRequests, pause: true},
+		{status: http.StatusServiceUnavailable, pause: true},
+		{status: http.StatusInternalServerError},
+		{status: http.StatusBadGateway},
+		{status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			b, clock := newTestBucket(RateLimit{Rate: 1, Burst: 1})
+
+			b.reserve()
+			queued := b.reserve()
+			b.observe(tt.status, header)
+
+			want := time.Second
+			if tt.status == http.StatusTooManyRequests {
+				want = 2 * time.Second
+			}
+			if tt.pause {
+				want = 10 * time.Second
+			}
+			if d := after(b, queued); d != want {
+				t.Errorf("queued reservation = %v, want %v", d, want)
+			}
+
+			clock.advance(5 * time.Second)
+			if d, pause := after(b, b.reserve()), 5*time.Second; tt.pause && d != pause {
+				t.Errorf("new reservation = %v, want %v", d, pause)
+			}
+		})
+	}
+}
+
+func TestTokenBucketWaitWakesOnChange(t *testing.T) {
+	b, clock := newTestBucket(RateLimit{Rate: 1.0 / 3600, Burst: 1})
+
+	b.reserve()
+	first := b.reserve()
+
+	errc := make(chan error, 1)
+	go func() { errc <- b.wait(context.Background()) }()
+
+	for {
+		b.mu.Lock()
+		n := len(b.waiting)
+		b.mu.Unlock()
+		if n == 2 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// 一小时后取消排在前面的预留，等待者被唤醒并发现令牌已经可用。
+	b.mu.Lock()
+	clock.advance(time.Hour)
+	b.mu.Unlock()
+	b.cancel(first)
+
+	select {
+	case err := <-errc:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("waiter not woken")
+	}
+	if len(b.waiting) != 0 {
+		t.Errorf("waiting = %d, want 0", len(b.waiting))
+	}
+}
+
+func TestTokenBucketWaitCanceled(t *testing.T) {
+	b, _ := newTestBucket(RateLimit{Rate: 1.0 / 3600, Burst: 1})
+	b.reserve()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := b.wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("wait error = %v, want context.Canceled", err)
+	}
+	if len(b.waiting) != 0 || b.tokens != 0 {
+		t.Errorf("waiting = %d, tokens = %v, want the token returned", len(b.waiting), b.tokens)
+	}
+}
+
+func TestRateLimiterGroups(t *testing.T) {
+	sdk := New(WithRateLimit(RateLimits{
+		EndpointGroupPayment: {Rate: 1, Burst: 1},
+		EndpointGroupOther:   {Rate: 0, Burst: 10},
+	}))
+	limiter := sdk.rateLimiter
+
+	if len(limiter.buckets) != 1 {
+		t.Fatalf("buckets = %v, want only the payment group", limiter.buckets)
+	}
+	payments := limiter.buckets[EndpointGroupPayment]
+	clock := &testClock{t: payments.last}
+	payments.now = clock.now
+
+	// 同一分组的接口共用令牌，其他分组不限流。
+	if err := limiter.wait(context.Background(), CreateInvoiceEndpoint); err != nil {
+		t.Fatal(err)
+	}
+	if r := payments.reserve(); after(payments, r) != time.Second {
+		t.Errorf("PaymentInformation shares the bucket: delay = %v, want 1s", after(payments, r))
+	}
+	if err := limiter.wait(context.Background(), CreatePayoutEndpoint); err != nil {
+		t.Fatal(err)
+	}
+
+	limiter.observe(BalanceEndpoint, http.StatusTooManyRequests, nil)
+	if payments.rate != 1 {
+		t.Errorf("429 from another group changed the rate to %v", payments.rate)
+	}
+	limiter.observe(PaymentInformationEndpoint, http.StatusTooManyRequests, nil)
+	if payments.rate != 0.5 {
+		t.Errorf("rate = %v, want 0.5", payments.rate)
+	}
+
+	for endpoint, want := range map[Endpoint]EndpointGroup{
+		CreateInvoiceEndpoint:            EndpointGroupPayment,
+		TransferToPersonalWalletEndpoint: EndpointGroupPayout,
+		BlockStaticWalletEndpoint:        EndpointGroupStaticWallet,
+		BalanceEndpoint:                  EndpointGroupOther,
+	} {
+		if got := endpoint.Group(); got != want {
+			t.Errorf("%s.Group() = %s, want %s", endpoint, got, want)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package cryptomus
 
 import (
 	"context"
+	"net/http"
 	"time"
 )
 
@@ -14,7 +15,7 @@ const (
 )
 
 type CreateRecurringPaymentRequest struct {
-	Amount         string                 `json:"amount"`
+	Amount         Amount                 `json:"amount"`
 	Currency       string                 `json:"currency"`
 	Name           string                 `json:"name"`
 	Period         RecurringPaymentPeriod `json:"period"`
@@ -22,10 +23,32 @@ type CreateRecurringPaymentRequest struct {
 	OrderID        string                 `json:"order_id,omitempty"`
 	URLCallback    string                 `json:"url_callback,omitempty"`
 	DiscountDays   int                    `json:"discount_days,omitempty"`
-	DiscountAmount string                 `json:"discount_amount,omitempty"`
+	DiscountAmount Amount                 `json:"discount_amount,omitzero"`
 	AdditionalData string                 `json:"additional_data,omitempty"`
 }
 
+// Validate 校验 CreateRecurringPayment 的请求参数。
+func (r CreateRecurringPaymentRequest) Validate() error {
+	var v validation
+
+	v.positiveAmount("amount", r.Amount)
+	v.required("currency", r.Currency)
+	v.required("name", r.Name)
+	switch r.Period {
+	case RecurringPaymentPeriodWeekly, RecurringPaymentPeriodMonthly, RecurringPaymentPeriodThreeMonth:
+	case "":
+		v.required("period", "")
+	default:
+		v.add("period", "The selected period is invalid.")
+	}
+	v.orderID("order_id", r.OrderID, false)
+	v.between("discount_days", r.DiscountDays, 0, 365)
+	v.nonNegativeAmount("discount_amount", r.DiscountAmount)
+	v.maxLength("additional_data", r.AdditionalData, 255)
+
+	return v.err()
+}
+
 type CreateRecurringPaymentResponse struct {
 	*HTTPResponse
 	Result RecurringPaymentData `json:"result,omitempty"`
@@ -35,11 +58,11 @@ type RecurringPaymentData struct {
 	UUID           string     `json:"uuid"`
 	Name           string     `json:"name"`
 	OrderID        string     `json:"order_id,omitempty"`
-	Amount         string     `json:"amount"`
+	Amount         Amount     `json:"amount"`
 	Currency       string     `json:"currency"`
 	PayerCurrency  string     `json:"payer_currency,omitempty"`
-	PayerAmountUSD string     `json:"payer_amount_usd"`
-	PayerAmount    string     `json:"payer_amount,omitempty"`
+	PayerAmountUSD Amount     `json:"payer_amount_usd"`
+	PayerAmount    Amount     `json:"payer_amount,omitzero"`
 	URLCallback    string     `json:"url_callback,omitempty"`
 	Period         string     `json:"period"`
 	Status         string     `json:"status"`
@@ -54,7 +77,7 @@ type RecurringPaymentData struct {
 // 示例：
 //
 //	result, err := sdk.CreateRecurringPayment(CreateRecurringPaymentRequest{
-//		Amount:   "0.0001",
+//		Amount:   MustParseAmount("0.0001"),
 //		Currency: "BTC",
 //		Name:     "Test recurring payment",
 //		Period:   RecurringPaymentPeriodWeekly,
@@ -77,7 +100,7 @@ func (sdk *Cryptomus) CreateRecurringPayment(request CreateRecurringPaymentReque
 // 示例：
 //
 //	result, err := sdk.CreateRecurringPaymentWithContext(ctx, CreateRecurringPaymentRequest{
-//		Amount:   "0.0001",
+//		Amount:   MustParseAmount("0.0001"),
 //		Currency: "BTC",
 //		Name:     "Test recurring payment",
 //		Period:   RecurringPaymentPeriodWeekly,
@@ -88,20 +111,12 @@ func (sdk *Cryptomus) CreateRecurringPayment(request CreateRecurringPaymentReque
 func (sdk *Cryptomus) CreateRecurringPaymentWithContext(ctx context.Context, payload CreateRecurringPaymentRequest) (*CreateRecurringPaymentResponse, error) {
 	var result CreateRecurringPaymentResponse
 
-	reqByte, err := ToJSON(payload)
-	if err != nil {
-		return nil, err
-	}
-
-	req := sdk.HttpClient.NewRequest().
-		SetContext(ctx).
-		SetHeader("merchant", sdk.Merchant).
-		SetHeader("sign", Sign(sdk.PaymentToken, payload)).
-		SetBody(reqByte).
-		SetSuccessResult(&result).
-		SetErrorResult(&result)
-
-	if _, err := req.Post(CreateRecurringPaymentEndpoint.URL()); err != nil {
+	if err := sdk.send(ctx, &Request{
+		Method:   http.MethodPost,
+		Endpoint: CreateRecurringPaymentEndpoint,
+		Token:    TokenPayment,
+		Payload:  payload,
+	}, &result); err != nil {
 		return nil, err
 	}
 
@@ -113,6 +128,15 @@ type RecurringPaymentInformationRequest struct {
 	OrderID string `json:"order_id,omitempty"`
 }
 
+// Validate 校验 RecurringPaymentInformation 的请求参数。
+func (r RecurringPaymentInformationRequest) Validate() error {
+	var v validation
+
+	v.uuidOrOrderID(r.UUID, r.OrderID)
+
+	return v.err()
+}
+
 type RecurringPaymentInformationResponse struct {
 	*HTTPResponse
 	Result RecurringPaymentData `json:"result,omitempty"`
@@ -151,20 +175,12 @@ func (sdk *Cryptomus) RecurringPaymentInformation(request RecurringPaymentInform
 func (sdk *Cryptomus) RecurringPaymentInformationWithContext(ctx context.Context, payload RecurringPaymentInformationRequest) (*RecurringPaymentInformationResponse, error) {
 	var result RecurringPaymentInformationResponse
 
-	reqByte, err := ToJSON(payload)
-	if err != nil {
-		return nil, err
-	}
-
-	req := sdk.HttpClient.NewRequest().
-		SetContext(ctx).
-		SetHeader("merchant", sdk.Merchant).
-		SetHeader("sign", Sign(sdk.PaymentToken, payload)).
-		SetBody(reqByte).
-		SetSuccessResult(&result).
-		SetErrorResult(&result)
-
-	if _, err := req.Post(RecurringPaymentInformationEndpoint.URL()); err != nil {
+	if err := sdk.send(ctx, &Request{
+		Method:   http.MethodPost,
+		Endpoint: RecurringPaymentInformationEndpoint,
+		Token:    TokenPayment,
+		Payload:  payload,
+	}, &result); err != nil {
 		return nil, err
 	}
 
@@ -172,7 +188,12 @@ func (sdk *Cryptomus) RecurringPaymentInformationWithContext(ctx context.Context
 }
 
 type ListRecurringPaymentsRequest struct {
-	Cursor string `json:"cursor,omitempty"`
+	Cursor string `json:"-"`
+}
+
+// Validate 校验 ListRecurringPayments 的请求参数。
+func (r ListRecurringPaymentsRequest) Validate() error {
+	return nil
 }
 
 type ListRecurringPaymentsResponse struct {
@@ -216,15 +237,13 @@ func (sdk *Cryptomus) ListRecurringPayments(request ListRecurringPaymentsRequest
 func (sdk *Cryptomus) ListRecurringPaymentsWithContext(ctx context.Context, payload ListRecurringPaymentsRequest) (*ListRecurringPaymentsResponse, error) {
 	var result ListRecurringPaymentsResponse
 
-	req := sdk.HttpClient.NewRequest().
-		SetContext(ctx).
-		SetHeader("merchant", sdk.Merchant).
-		SetHeader("sign", Sign(sdk.PaymentToken, nil)).
-		SetQueryParam("cursor", payload.Cursor).
-		SetSuccessResult(&result).
-		SetErrorResult(&result)
-
-	if _, err := req.Post(ListRecurringPaymentsEndpoint.URL()); err != nil {
+	if err := sdk.send(ctx, &Request{
+		Method:   http.MethodPost,
+		Endpoint: ListRecurringPaymentsEndpoint,
+		Token:    TokenPayment,
+		Payload:  payload,
+		Query:    map[string]string{"cursor": payload.Cursor},
+	}, &result); err != nil {
 		return nil, err
 	}
 
@@ -236,6 +255,15 @@ type CancelRecurringPaymentRequest struct {
 	OrderID string `json:"order_id,omitempty"`
 }
 
+// Validate 校验 CancelRecurringPayment 的请求参数。
+func (r CancelRecurringPaymentRequest) Validate() error {
+	var v validation
+
+	v.uuidOrOrderID(r.UUID, r.OrderID)
+
+	return v.err()
+}
+
 type CancelRecurringPaymentResponse struct {
 	*HTTPResponse
 	Result RecurringPaymentData `json:"result,omitempty"`
@@ -276,20 +304,12 @@ func (sdk *Cryptomus) CancelRecurringPayment(payload CancelRecurringPaymentReque
 func (sdk *Cryptomus) CancelRecurringPaymentWithContext(ctx context.Context, payload CancelRecurringPaymentRequest) (*CancelRecurringPaymentResponse, error) {
 	var result CancelRecurringPaymentResponse
 
-	reqByte, err := ToJSON(payload)
-	if err != nil {
-		return nil, err
-	}
-
-	req := sdk.HttpClient.NewRequest().
-		SetContext(ctx).
-		SetHeader("merchant", sdk.Merchant).
-		SetHeader("sign", Sign(sdk.PaymentToken, payload)).
-		SetBody(reqByte).
-		SetSuccessResult(&result).
-		SetErrorResult(&result)
-
-	if _, err := req.Post(CancelRecurringPaymentEndpoint.URL()); err != nil {
+	if err := sdk.send(ctx, &Request{
+		Method:   http.MethodPost,
+		Endpoint: CancelRecurringPaymentEndpoint,
+		Token:    TokenPayment,
+		Payload:  payload,
+	}, &result); err != nil {
 		return nil, err
 	}
 
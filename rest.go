@@ -25,13 +25,25 @@ type Pagination struct {
 	PerPage        int    `json:"perPage"`
 }
 
+// Sign 使用 json.Marshal 序列化 data 并计算签名：md5(base64(body) + apiKey)。data 为 nil 或空字符串时只对 apiKey 计算 md5。
+//
+// SDK 发送请求时使用 SignBody 对实际发送的字节签名；只有在请求体确实由 json.Marshal 生成时，Sign 的结果才与其一致。
 func Sign(apiKey string, data interface{}) string {
-	combined := apiKey
+	if data == nil || data == "" {
+		return SignBody(apiKey, nil)
+	}
 
-	if data != nil && data != "" {
-		body, _ := json.Marshal(data)
-		encodedData := base64.StdEncoding.EncodeToString(body)
-		combined = encodedData + apiKey
+	body, _ := json.Marshal(data)
+	return SignBody(apiKey, body)
+}
+
+// SignBody 对请求体的原始字节计算签名：md5(base64(body) + apiKey)，body 为空时只对 apiKey 计算 md5。
+//
+// 签名必须基于实际发送的字节计算，否则 Cryptomus 会返回签名错误。
+func SignBody(apiKey string, body []byte) string {
+	combined := apiKey
+	if len(body) > 0 {
+		combined = base64.StdEncoding.EncodeToString(body) + apiKey
 	}
 
 	hash := md5.Sum([]byte(combined))
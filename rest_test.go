@@ -0,0 +1,37 @@
+package cryptomus
+
+import "testing"
+
+func TestSignBody(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{name: "empty body", body: "", want: "5ebe2294ecd0e0f08eab7690d2a6ee69"},
+		{name: "object", body: `{"amount":"15","currency":"USDT","order_id":"1"}`, want: "98bc1cd6818a5c8661441a38980866a1"},
+		{name: "escaped slashes", body: `{"url_callback":"https:\/\/example.com\/cb"}`, want: "800f885e8c36309514ed341239fc8958"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SignBody("secret", []byte(tt.body)); got != tt.want {
+				t.Errorf("SignBody = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	// 签名基于发送的字节，Go 与 PHP 转义规则得到的请求体签名不同。
+	payload := map[string]string{"url_callback": "https://example.com/cb"}
+	goBody, _ := New().EncodeRequest(payload)
+	phpBody, _ := New(WithJSONEscaping(JSONEscapingPHP)).EncodeRequest(payload)
+	if SignBody("secret", phpBody) != "800f885e8c36309514ed341239fc8958" {
+		t.Errorf("PHP body %s has a different signature", phpBody)
+	}
+	if SignBody("secret", goBody) == SignBody("secret", phpBody) {
+		t.Error("Go and PHP bodies have the same signature")
+	}
+	if Sign("secret", nil) != SignBody("secret", nil) {
+		t.Error("Sign(nil) differs from SignBody(nil)")
+	}
+}
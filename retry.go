@@ -0,0 +1,176 @@
+package cryptomus
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// DefaultRetryableEndpoints 是默认允许重试的只读接口。
+var DefaultRetryableEndpoints = []Endpoint{
+	PaymentInformationEndpoint,
+	PaymentListOfServicesEndpoint,
+	PaymentHistoryEndpoint,
+	PayoutInformationEndpoint,
+	PayoutHistoryEndpoint,
+	PayoutListOfServicesEndpoint,
+	RecurringPaymentInformationEndpoint,
+	ListRecurringPaymentsEndpoint,
+	ExchangeRateListEndpoint,
+	ListOfDiscountsEndpoint,
+	BalanceEndpoint,
+}
+
+// RetryPolicy 配置失败请求的重试策略。
+//
+// 网络错误、HTTP 429 与 5xx（500、502、503、504）会被重试。
+// 只有 RetryableEndpoints 中的接口会被重试；CreateInvoice、CreateStaticWallet 与 CreateRecurringPayment
+// 等会产生副作用的接口，只有在请求中设置了 order_id 时才会重试，因为相同 order_id 的请求不会重复创建。
+//
+// CreatePayout 不会被重试，即使列在 RetryableEndpoints 中：如果第一次请求实际已经成功，重试会因为
+// order_id 重复而失败，调用方无法区分提现是否已经发出。需要自动恢复时使用 IdempotentClient，
+// 它会按 order_id 查询结果，无法确定时返回 ErrPayoutOutcomeUnknown。
+type RetryPolicy struct {
+	// MaxAttempts 是包括首次请求在内的最大尝试次数，<= 1 表示不重试。
+	MaxAttempts int
+	// InitialBackoff 是第一次重试前的等待时间，之后每次翻倍。
+	InitialBackoff time.Duration
+	// MaxBackoff 是单次等待时间的上限，同样限制 Retry-After。
+	MaxBackoff time.Duration
+	// RetryableEndpoints 是允许重试的接口，为 nil 时使用 DefaultRetryableEndpoints。
+	RetryableEndpoints []Endpoint
+}
+
+// DefaultRetryPolicy 返回默认的重试策略：最多 3 次尝试，等待时间从 200ms 开始指数增长，最长 5s。
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 200 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+	}
+}
+
+// WithRetryPolicy 为 SDK 实例设置重试策略。
+//
+// 示例：
+//
+//	sdk := cryptomus.New(
+//		cryptomus.WithMerchant(merchantID),
+//		cryptomus.WithPaymentToken(paymentToken),
+//		cryptomus.WithRetryPolicy(cryptomus.DefaultRetryPolicy()),
+//	)
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Cryptomus) {
+		if policy.InitialBackoff <= 0 {
+			policy.InitialBackoff = DefaultRetryPolicy().InitialBackoff
+		}
+		if policy.MaxBackoff <= 0 {
+			policy.MaxBackoff = DefaultRetryPolicy().MaxBackoff
+		}
+		if policy.RetryableEndpoints == nil {
+			policy.RetryableEndpoints = DefaultRetryableEndpoints
+		}
+
+		c.retryPolicy = &policy
+	}
+}
+
+// idempotentRequest 由带有 order_id 的创建类请求实现。
+type idempotentRequest interface {
+	idempotencyKey() string
+}
+
+func (r *CreateInvoiceRequest) idempotencyKey() string {
+	return r.OrderID
+}
+
+func (r *CreateStaticWalletRequest) idempotencyKey() string {
+	return r.OrderID
+}
+
+func (r CreateRecurringPaymentRequest) idempotencyKey() string {
+	return r.OrderID
+}
+
+func (p *RetryPolicy) shouldRetry(ctx context.Context, call *Request, attempt int, err error) bool {
+	if p == nil || attempt >= p.MaxAttempts || ctx.Err() != nil {
+		return false
+	}
+
+	if !p.retryable(call) || errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+
+	// 其余为网络错误，例如连接被重置或超时。
+	return !errors.Is(err, context.Canceled)
+}
+
+func (p *RetryPolicy) retryable(call *Request) bool {
+	if call.Endpoint == CreatePayoutEndpoint {
+		return false
+	}
+
+	if slices.Contains(p.RetryableEndpoints, call.Endpoint) {
+		return true
+	}
+
+	if req, ok := call.Payload.(idempotentRequest); ok {
+		return req.idempotencyKey() != ""
+	}
+
+	return false
+}
+
+// delay 返回第 attempt 次尝试失败后的等待时间：优先使用 Retry-After，否则使用带抖动的指数退避。
+func (p *RetryPolicy) delay(attempt int, err error) time.Duration {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		if wait, ok := retryAfter(apiErr.Header); ok {
+			return min(wait, p.MaxBackoff)
+		}
+	}
+
+	backoff := p.InitialBackoff << (attempt - 1)
+	if backoff <= 0 || backoff > p.MaxBackoff {
+		backoff = p.MaxBackoff
+	}
+
+	// 在 [backoff/2, backoff) 之间随机取值，避免多个客户端同时重试。
+	half := backoff / 2
+	return half + rand.N(half+1)
+}
+
+// retryAfter 解析 Retry-After 响应头，支持秒数与 HTTP 日期两种格式。
+func retryAfter(header http.Header) (time.Duration, bool) {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0), true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,176 @@
+package cryptomus
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := &RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	tests := []struct {
+		attempt int
+		backoff time.Duration
+	}{
+		{attempt: 1, backoff: 100 * time.Millisecond},
+		{attempt: 2, backoff: 200 * time.Millisecond},
+		{attempt: 4, backoff: 800 * time.Millisecond},
+		{attempt: 5, backoff: time.Second},
+		{attempt: 64, backoff: time.Second},
+	}
+
+	for _, tt := range tests {
+		// 抖动在 [backoff/2, backoff] 之间。
+		for range 100 {
+			if d := policy.delay(tt.attempt, errors.New("connection reset")); d < tt.backoff/2 || d > tt.backoff {
+				t.Fatalf("attempt %d: delay = %v, want within [%v, %v]", tt.attempt, d, tt.backoff/2, tt.backoff)
+			}
+		}
+	}
+
+	retryAfter := func(value string) error {
+		return &APIError{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": {value}}}
+	}
+	if d := policy.delay(1, retryAfter("0")); d != 0 {
+		t.Errorf("Retry-After: 0 gives %v", d)
+	}
+	if d := policy.delay(1, retryAfter("10")); d != time.Second {
+		t.Errorf("Retry-After beyond MaxBackoff gives %v, want %v", d, time.Second)
+	}
+	if d := policy.delay(1, retryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))); d != time.Second {
+		t.Errorf("Retry-After date gives %v, want %v", d, time.Second)
+	}
+	if d := policy.delay(1, retryAfter("soon")); d < 50*time.Millisecond || d > 100*time.Millisecond {
+		t.Errorf("invalid Retry-After gives %v, want the backoff", d)
+	}
+}
+
+func TestRetryPolicyRetryable(t *testing.T) {
+	policy := New(WithRetryPolicy(DefaultRetryPolicy())).retryPolicy
+
+	tests := []struct {
+		name string
+		call *Request
+		want bool
+	}{
+		{name: "read only", call: &Request{Endpoint: PaymentInformationEndpoint}, want: true},
+		{name: "invoice with order_id", call: &Request{Endpoint: CreateInvoiceEndpoint, Payload: &CreateInvoiceRequest{OrderID: "o1"}}, want: true},
+		{name: "invoice without order_id", call: &Request{Endpoint: CreateInvoiceEndpoint, Payload: &CreateInvoiceRequest{}}},
+		{name: "static wallet with order_id", call: &Request{Endpoint: CreateStaticWalletEndpoint, Payload: &CreateStaticWalletRequest{OrderID: "w1"}}, want: true},
+		{name: "recurring with order_id", call: &Request{Endpoint: CreateRecurringPaymentEndpoint, Payload: CreateRecurringPaymentRequest{OrderID: "r1"}}, want: true},
+		{name: "payout with order_id", call: &Request{Endpoint: CreatePayoutEndpoint, Payload: &CreatePayoutRequest{OrderID: "p1"}}},
+		{name: "refund", call: &Request{Endpoint: RefundEndpoint, Payload: &RefundRequest{OrderID: "o1"}}},
+		{name: "transfer", call: &Request{Endpoint: TransferToPersonalWalletEndpoint}},
+	}
+
+	for _, tt := range tests {
+		if got := policy.retryable(tt.call); got != tt.want {
+			t.Errorf("%s: retryable = %t, want %t", tt.name, got, tt.want)
+		}
+	}
+
+	// 显式列出 CreatePayout 同样不会重试。
+	policy.RetryableEndpoints = []Endpoint{CreatePayoutEndpoint}
+	if policy.retryable(&Request{Endpoint: CreatePayoutEndpoint, Payload: &CreatePayoutRequest{OrderID: "p1"}}) {
+		t.Error("CreatePayout retried when listed in RetryableEndpoints")
+	}
+}
+
+func TestRetryPolicyShouldRetry(t *testing.T) {
+	policy := New(WithRetryPolicy(DefaultRetryPolicy())).retryPolicy
+	call := &Request{Endpoint: BalanceEndpoint}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		attempt int
+		err     error
+		want    bool
+	}{
+		{name: "network error", err: errors.New("connection reset"), want: true},
+		{name: "429", err: &APIError{StatusCode: http.StatusTooManyRequests}, want: true},
+		{name: "500", err: &APIError{StatusCode: http.StatusInternalServerError}, want: true},
+		{name: "502", err: &APIError{StatusCode: http.StatusBadGateway}, want: true},
+		{name: "503", err: &APIError{StatusCode: http.StatusServiceUnavailable}, want: true},
+		{name: "504", err: &APIError{StatusCode: http.StatusGatewayTimeout}, want: true},
+		{name: "501", err: &APIError{StatusCode: http.StatusNotImplemented}},
+		{name: "422", err: &APIError{StatusCode: http.StatusUnprocessableEntity}},
+		{name: "401", err: &APIError{StatusCode: http.StatusUnauthorized}},
+		{name: "request canceled", err: context.Canceled},
+		{name: "circuit open", err: &CircuitOpenError{Endpoint: BalanceEndpoint}},
+		{name: "context done", ctx: canceled, err: errors.New("connection reset")},
+		{name: "last attempt", attempt: 3, err: errors.New("connection reset")},
+	}
+
+	for _, tt := range tests {
+		ctx := tt.ctx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		attempt := max(tt.attempt, 1)
+
+		if got := policy.shouldRetry(ctx, call, attempt, tt.err); got != tt.want {
+			t.Errorf("%s: shouldRetry = %t, want %t", tt.name, got, tt.want)
+		}
+	}
+
+	var none *RetryPolicy
+	if none.shouldRetry(context.Background(), call, 1, errors.New("connection reset")) {
+		t.Error("nil policy retried")
+	}
+}
+
+func TestRetryPolicySend(t *testing.T) {
+	hits := map[string]*atomic.Int32{}
+	for _, endpoint := range []Endpoint{BalanceEndpoint, CreatePayoutEndpoint} {
+		hits[endpoint.String()] = &atomic.Int32{}
+	}
+
+	// 前两次请求返回 503，之后成功。
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits[r.URL.Path].Add(1) <= 2 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"state":0,"result":[]}`))
+	}))
+	defer srv.Close()
+
+	sdk := New(
+		WithBaseURL(srv.URL),
+		WithMerchant("merchant"),
+		WithPaymentToken("payment"),
+		WithPayoutToken("payout"),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}),
+	)
+
+	if _, err := sdk.BalanceWithContext(context.Background()); err != nil {
+		t.Errorf("Balance: %v", err)
+	}
+	if n := hits[BalanceEndpoint.String()].Load(); n != 3 {
+		t.Errorf("Balance sent %d times, want 3", n)
+	}
+
+	_, err := sdk.CreatePayoutWithContext(context.Background(), &CreatePayoutRequest{
+		Amount:   MustParseAmount("10"),
+		Currency: "USDT",
+		Network:  "tron",
+		Address:  "TAddress",
+		OrderID:  "payout-1",
+	})
+	if apiErr, ok := AsAPIError(err); !ok || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("CreatePayout error = %v, want the first 503", err)
+	}
+	if n := hits[CreatePayoutEndpoint.String()].Load(); n != 1 {
+		t.Errorf("CreatePayout sent %d times, want 1", n)
+	}
+}
@@ -1,6 +1,9 @@
 package cryptomus
 
 import (
+	"log/slog"
+	"strings"
+
 	"github.com/imroc/req/v3"
 )
 
@@ -9,6 +12,22 @@ type Cryptomus struct {
 	Merchant     string
 	PaymentToken string
 	PayoutToken  string
+
+	baseURL     string
+	retryPolicy *RetryPolicy
+	rateLimiter *rateLimiter
+	breaker     *breaker
+	services    servicesCache
+	middlewares []Middleware
+	logger      *slog.Logger
+	escaping    JSONEscaping
+
+	skipValidation bool
+
+	credentialsProvider CredentialsProvider
+	keyring             keyring
+
+	webhookHub webhookHub
 }
 
 type Option func(*Cryptomus)
@@ -33,6 +52,9 @@ func New(options ...Option) *Cryptomus {
 func DefaultCryptomus() *Cryptomus {
 	return &Cryptomus{
 		HttpClient: DefaultHTTPClient(),
+		baseURL:    strings.TrimRight(Host, "/"),
+		keyring:    keyring{grace: DefaultCredentialsGracePeriod},
+		services:   servicesCache{ttl: DefaultServicesCacheTTL},
 	}
 }
 
@@ -59,3 +81,29 @@ func WithPayoutToken(token string) Option {
 		c.PayoutToken = token
 	}
 }
+
+// WithBaseURL 设置 SDK 实例使用的 API 地址，默认为 DefaultBaseURL。
+//
+// 每个实例的地址相互独立，可用于在同一进程中访问不同的环境，或指向测试服务器，例如 cryptomustest.Server。
+//
+// 示例：
+//
+//	sdk := cryptomus.New(
+//		cryptomus.WithBaseURL("https://api.cryptomus.com"),
+//		cryptomus.WithMerchant(merchantID),
+//		cryptomus.WithPaymentToken(paymentToken),
+//	)
+func WithBaseURL(baseURL string) Option {
+	return func(c *Cryptomus) {
+		c.baseURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// BaseURL 返回该实例使用的 API 地址。
+func (sdk *Cryptomus) BaseURL() string {
+	if sdk.baseURL == "" {
+		return DefaultBaseURL
+	}
+
+	return sdk.baseURL
+}
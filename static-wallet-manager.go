@@ -0,0 +1,540 @@
+package cryptomus
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrWalletNotFound 表示 StaticWalletManager 中没有对应的静态钱包。
+var ErrWalletNotFound = errors.New("cryptomus: static wallet not found")
+
+// StaticWalletKey 标识一个客户在某个币种与网络上的静态钱包。
+type StaticWalletKey struct {
+	CustomerID string `json:"customer_id"`
+	Currency   string `json:"currency"`
+	Network    string `json:"network"`
+}
+
+// normalize 将币种转换为大写、网络转换为小写。
+func (k StaticWalletKey) normalize() StaticWalletKey {
+	return StaticWalletKey{
+		CustomerID: k.CustomerID,
+		Currency:   strings.ToUpper(k.Currency),
+		Network:    strings.ToLower(k.Network),
+	}
+}
+
+// StaticWallet 是 StaticWalletManager 分配给客户的静态钱包。
+type StaticWallet struct {
+	StaticWalletKey
+	// OrderID 是创建钱包时使用的 order_id，Webhook 按它找到钱包的所有者。
+	OrderID    string `json:"order_id"`
+	WalletUUID string `json:"wallet_uuid"`
+	UUID       string `json:"uuid"`
+	Address    string `json:"address"`
+	URL        string `json:"url"`
+	// Status 是钱包的状态，创建时为 BlockStaticWalletStatusActive，封锁后为 BlockStaticWalletStatusBlocked。
+	Status BlockStaticWalletStatus `json:"status"`
+	// Refund 是封锁后的退款，没有退款时为 nil。每个钱包只能退款一次。
+	Refund    *StaticWalletRefund `json:"refund,omitempty"`
+	CreatedAt time.Time           `json:"created_at"`
+	UpdatedAt time.Time           `json:"updated_at"`
+}
+
+// StaticWalletRefund 是封锁的静态钱包上的退款。
+type StaticWalletRefund struct {
+	Address    string    `json:"address"`
+	Amount     Amount    `json:"amount"`
+	Commission Amount    `json:"commission"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// StaticWalletStore 保存 StaticWalletManager 分配的静态钱包，实现必须可以在多个 goroutine 中使用。
+//
+// 查询不到时返回 false 与 nil 错误。
+type StaticWalletStore interface {
+	Get(ctx context.Context, key StaticWalletKey) (StaticWallet, bool, error)
+	GetByOrderID(ctx context.Context, orderID string) (StaticWallet, bool, error)
+	// List 返回客户的所有钱包。
+	List(ctx context.Context, customerID string) ([]StaticWallet, error)
+	// Put 保存钱包，已存在相同 StaticWalletKey 的钱包时替换它。
+	Put(ctx context.Context, wallet StaticWallet) error
+}
+
+// staticWallets 是 MemoryStaticWalletStore 与 FileStaticWalletStore 共用的索引，调用方负责加锁。
+type staticWallets map[StaticWalletKey]StaticWallet
+
+func (w staticWallets) byOrderID(orderID string) (StaticWallet, bool) {
+	for _, wallet := range w {
+		if wallet.OrderID == orderID {
+			return wallet, true
+		}
+	}
+
+	return StaticWallet{}, false
+}
+
+func (w staticWallets) customer(customerID string) []StaticWallet {
+	var wallets []StaticWallet
+	for _, wallet := range w {
+		if wallet.CustomerID == customerID {
+			wallets = append(wallets, wallet)
+		}
+	}
+	sortStaticWallets(wallets)
+
+	return wallets
+}
+
+func sortStaticWallets(wallets []StaticWallet) {
+	slices.SortFunc(wallets, func(a, b StaticWallet) int {
+		return cmp.Or(
+			cmp.Compare(a.CustomerID, b.CustomerID),
+			cmp.Compare(a.Currency, b.Currency),
+			cmp.Compare(a.Network, b.Network),
+		)
+	})
+}
+
+// MemoryStaticWalletStore 是保存在内存中的 StaticWalletStore，进程退出后记录会丢失。
+type MemoryStaticWalletStore struct {
+	mu      sync.Mutex
+	wallets staticWallets
+}
+
+// NewMemoryStaticWalletStore 创建 MemoryStaticWalletStore。
+func NewMemoryStaticWalletStore() *MemoryStaticWalletStore {
+	return &MemoryStaticWalletStore{wallets: staticWallets{}}
+}
+
+func (s *MemoryStaticWalletStore) Get(ctx context.Context, key StaticWalletKey) (StaticWallet, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wallet, ok := s.wallets[key]
+	return wallet, ok, nil
+}
+
+func (s *MemoryStaticWalletStore) GetByOrderID(ctx context.Context, orderID string) (StaticWallet, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wallet, ok := s.wallets.byOrderID(orderID)
+	return wallet, ok, nil
+}
+
+func (s *MemoryStaticWalletStore) List(ctx context.Context, customerID string) ([]StaticWallet, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.wallets.customer(customerID), nil
+}
+
+func (s *MemoryStaticWalletStore) Put(ctx context.Context, wallet StaticWallet) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.wallets[wallet.StaticWalletKey] = wallet
+	return nil
+}
+
+// FileStaticWalletStore 是保存在 JSON 文件中的 StaticWalletStore，每次修改都会先写入临时文件再重命名。
+//
+// 同一个文件只能由一个进程使用。
+type FileStaticWalletStore struct {
+	path string
+
+	mu      sync.Mutex
+	wallets staticWallets
+}
+
+// NewFileStaticWalletStore 创建使用 path 的 FileStaticWalletStore，文件不存在时会在第一次修改时创建。
+func NewFileStaticWalletStore(path string) *FileStaticWalletStore {
+	return &FileStaticWalletStore{path: path}
+}
+
+func (s *FileStaticWalletStore) Get(ctx context.Context, key StaticWalletKey) (StaticWallet, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(); err != nil {
+		return StaticWallet{}, false, err
+	}
+
+	wallet, ok := s.wallets[key]
+	return wallet, ok, nil
+}
+
+func (s *FileStaticWalletStore) GetByOrderID(ctx context.Context, orderID string) (StaticWallet, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(); err != nil {
+		return StaticWallet{}, false, err
+	}
+
+	wallet, ok := s.wallets.byOrderID(orderID)
+	return wallet, ok, nil
+}
+
+func (s *FileStaticWalletStore) List(ctx context.Context, customerID string) ([]StaticWallet, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	return s.wallets.customer(customerID), nil
+}
+
+func (s *FileStaticWalletStore) Put(ctx context.Context, wallet StaticWallet) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(); err != nil {
+		return err
+	}
+
+	wallets := make([]StaticWallet, 0, len(s.wallets)+1)
+	for key, w := range s.wallets {
+		if key != wallet.StaticWalletKey {
+			wallets = append(wallets, w)
+		}
+	}
+	wallets = append(wallets, wallet)
+	sortStaticWallets(wallets)
+
+	data, err := json.MarshalIndent(wallets, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(s.path, data); err != nil {
+		return err
+	}
+
+	s.wallets[wallet.StaticWalletKey] = wallet
+	return nil
+}
+
+func (s *FileStaticWalletStore) load() error {
+	if s.wallets != nil {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		s.wallets = staticWallets{}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var wallets []StaticWallet
+	if err := json.Unmarshal(data, &wallets); err != nil {
+		return fmt.Errorf("cryptomus: invalid static wallet file %s: %w", s.path, err)
+	}
+
+	s.wallets = make(staticWallets, len(wallets))
+	for _, wallet := range wallets {
+		s.wallets[wallet.StaticWalletKey] = wallet
+	}
+
+	return nil
+}
+
+// StaticWalletManagerOptions 配置 StaticWalletManager。
+type StaticWalletManagerOptions struct {
+	// Store 保存分配的钱包，默认为 NewMemoryStaticWalletStore()。
+	Store StaticWalletStore
+	// OrderID 返回创建钱包使用的 order_id，必须对每个 StaticWalletKey 唯一且不变，
+	// 默认为 "<客户 ID>_<币种>_<网络>"，例如 "user-42_USDT_tron"。
+	OrderID func(key StaticWalletKey) string
+	// URLCallback 是创建钱包时设置的 Webhook 地址，为空时使用商户设置中的地址。
+	URLCallback string
+	// OnUnknownWallet 在 OnWallet 收到不是由该 StaticWalletManager 创建的钱包的 Webhook 时调用，
+	// 例如在商户后台手动创建的钱包。为 nil 时忽略这类 Webhook 并响应 200，避免 Cryptomus 无休止地重试；
+	// 返回错误时 WebhookHandler 响应 500，Cryptomus 稍后重试。
+	OnUnknownWallet func(ctx context.Context, webhook *WalletWebhook) error
+}
+
+// StaticWalletManager 为每个客户在每个币种与网络上分配唯一的静态钱包，记录钱包的封锁与退款，
+// 并将钱包的 Webhook 交给所属的客户。StaticWalletManager 可以在多个 goroutine 中使用。
+type StaticWalletManager struct {
+	sdk    *Cryptomus
+	opts   StaticWalletManagerOptions
+	create *IdempotentClient
+
+	mu    sync.Mutex
+	locks map[StaticWalletKey]*walletLock
+}
+
+type walletLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// StaticWalletManager 返回使用该 SDK 实例的 StaticWalletManager。
+//
+// 示例：
+//
+//	wallets := sdk.StaticWalletManager(cryptomus.StaticWalletManagerOptions{
+//		Store: cryptomus.NewFileStaticWalletStore("/var/lib/shop/cryptomus-wallets.json"),
+//	})
+//
+//	wallet, err := wallets.Ensure(ctx, cryptomus.StaticWalletKey{CustomerID: user.ID, Currency: "USDT", Network: "tron"})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	log.Println("deposit address:", wallet.Address)
+func (sdk *Cryptomus) StaticWalletManager(opts StaticWalletManagerOptions) *StaticWalletManager {
+	if opts.Store == nil {
+		opts.Store = NewMemoryStaticWalletStore()
+	}
+	if opts.OrderID == nil {
+		opts.OrderID = func(key StaticWalletKey) string {
+			return key.CustomerID + "_" + key.Currency + "_" + key.Network
+		}
+	}
+
+	return &StaticWalletManager{
+		sdk:    sdk,
+		opts:   opts,
+		create: sdk.Idempotent(IdempotencyOptions{}),
+		locks:  map[StaticWalletKey]*walletLock{},
+	}
+}
+
+// Ensure 返回客户在该币种与网络上的钱包，没有时创建。
+//
+// 同一个 key 的并发调用只会创建一个钱包；已封锁的钱包原样返回，不会创建新的钱包。
+func (m *StaticWalletManager) Ensure(ctx context.Context, key StaticWalletKey) (StaticWallet, error) {
+	key = key.normalize()
+	if key.CustomerID == "" || key.Currency == "" || key.Network == "" {
+		return StaticWallet{}, errors.New("cryptomus: static wallet customer id, currency and network are required")
+	}
+
+	unlock := m.lock(key)
+	defer unlock()
+
+	wallet, ok, err := m.opts.Store.Get(ctx, key)
+	if err != nil || ok {
+		return wallet, err
+	}
+
+	orderID := m.opts.OrderID(key)
+	result, err := m.create.CreateStaticWallet(ctx, &CreateStaticWalletRequest{
+		Currency:    key.Currency,
+		Network:     key.Network,
+		OrderID:     orderID,
+		URLCallback: m.opts.URLCallback,
+	})
+	if err != nil {
+		return StaticWallet{}, err
+	}
+
+	now := time.Now().UTC()
+	wallet = StaticWallet{
+		StaticWalletKey: key,
+		OrderID:         orderID,
+		WalletUUID:      result.Result.WalletUUID,
+		UUID:            result.Result.UUID,
+		Address:         result.Result.Address,
+		URL:             result.Result.URL,
+		Status:          BlockStaticWalletStatusActive,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if err := m.opts.Store.Put(ctx, wallet); err != nil {
+		return StaticWallet{}, err
+	}
+
+	return wallet, nil
+}
+
+// Get 返回客户在该币种与网络上的钱包，没有时返回 ErrWalletNotFound。
+func (m *StaticWalletManager) Get(ctx context.Context, key StaticWalletKey) (StaticWallet, error) {
+	key = key.normalize()
+
+	wallet, ok, err := m.opts.Store.Get(ctx, key)
+	if err != nil {
+		return StaticWallet{}, err
+	}
+	if !ok {
+		return StaticWallet{}, fmt.Errorf("cryptomus: %s %s wallet of customer %q: %w", key.Currency, key.Network, key.CustomerID, ErrWalletNotFound)
+	}
+
+	return wallet, nil
+}
+
+// List 返回客户的所有钱包。
+func (m *StaticWalletManager) List(ctx context.Context, customerID string) ([]StaticWallet, error) {
+	return m.opts.Store.List(ctx, customerID)
+}
+
+// QRCode 返回钱包地址的二维码（data URI 格式的 PNG），没有钱包时创建。
+//
+// 每次调用都会请求 Cryptomus，二维码不会改变，需要频繁展示时请自行缓存。
+func (m *StaticWalletManager) QRCode(ctx context.Context, key StaticWalletKey) (string, error) {
+	wallet, err := m.Ensure(ctx, key)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := m.sdk.GenerateQRStaticWalletWithContext(ctx, &GenerateQRCodeWalletRequest{WalletAddressUUID: wallet.UUID})
+	if err != nil {
+		return "", err
+	}
+
+	return result.Result.Image, nil
+}
+
+// Block 封锁客户在该币种与网络上的钱包，之后的充值不会计入商户余额，可以通过 Refund 退款一次。
+// forceRefund 与 BlockStaticWalletRequest.IsForceRefund 相同。
+func (m *StaticWalletManager) Block(ctx context.Context, key StaticWalletKey, forceRefund bool) (StaticWallet, error) {
+	key = key.normalize()
+
+	unlock := m.lock(key)
+	defer unlock()
+
+	wallet, err := m.Get(ctx, key)
+	if err != nil {
+		return StaticWallet{}, err
+	}
+	if wallet.Status == BlockStaticWalletStatusBlocked {
+		return wallet, nil
+	}
+
+	result, err := m.sdk.BlockStaticWalletWithContext(ctx, &BlockStaticWalletRequest{UUID: wallet.UUID, IsForceRefund: forceRefund})
+	if err != nil {
+		return StaticWallet{}, err
+	}
+
+	wallet.Status = result.Result.Status
+	wallet.UpdatedAt = time.Now().UTC()
+	if err := m.opts.Store.Put(ctx, wallet); err != nil {
+		return StaticWallet{}, err
+	}
+
+	return wallet, nil
+}
+
+// BlockCustomer 封锁客户的所有钱包，例如在关闭账户时调用。返回封锁后的钱包，部分钱包失败时同时返回合并的错误。
+func (m *StaticWalletManager) BlockCustomer(ctx context.Context, customerID string, forceRefund bool) ([]StaticWallet, error) {
+	wallets, err := m.List(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	for i, wallet := range wallets {
+		blocked, err := m.Block(ctx, wallet.StaticWalletKey, forceRefund)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("cryptomus: block %s %s wallet: %w", wallet.Currency, wallet.Network, err))
+			continue
+		}
+		wallets[i] = blocked
+	}
+
+	return wallets, errors.Join(errs...)
+}
+
+// Refund 将封锁后的钱包收到的资金退还到 address，每个钱包只能退款一次。
+func (m *StaticWalletManager) Refund(ctx context.Context, key StaticWalletKey, address string) (StaticWallet, error) {
+	key = key.normalize()
+
+	unlock := m.lock(key)
+	defer unlock()
+
+	wallet, err := m.Get(ctx, key)
+	if err != nil {
+		return StaticWallet{}, err
+	}
+	switch {
+	case wallet.Status != BlockStaticWalletStatusBlocked:
+		return StaticWallet{}, fmt.Errorf("cryptomus: %s %s wallet of customer %q is not blocked", key.Currency, key.Network, key.CustomerID)
+	case wallet.Refund != nil:
+		return StaticWallet{}, fmt.Errorf("cryptomus: %s %s wallet of customer %q has already been refunded", key.Currency, key.Network, key.CustomerID)
+	}
+
+	result, err := m.sdk.RefundPaymentOnBlockedAddressWithContext(ctx, &RefundPaymentOnBlockedAddressRequest{UUID: wallet.UUID, Address: address})
+	if err != nil {
+		return StaticWallet{}, err
+	}
+
+	now := time.Now().UTC()
+	wallet.Refund = &StaticWalletRefund{
+		Address:    address,
+		Amount:     result.Result.Amount,
+		Commission: result.Result.Commission,
+		CreatedAt:  now,
+	}
+	wallet.UpdatedAt = now
+	if err := m.opts.Store.Put(ctx, wallet); err != nil {
+		return StaticWallet{}, err
+	}
+
+	return wallet, nil
+}
+
+// OnWallet 返回用于 WebhookHandlerOptions.OnWallet 的回调，按 order_id 找到钱包后调用 fn。
+//
+// 不是由该 StaticWalletManager 创建的钱包交给 StaticWalletManagerOptions.OnUnknownWallet，没有设置时忽略。
+//
+// 示例：
+//
+//	http.Handle("/webhook/cryptomus", sdk.WebhookHandler(cryptomus.WebhookHandlerOptions{
+//		OnWallet: wallets.OnWallet(func(ctx context.Context, wallet cryptomus.StaticWallet, webhook *cryptomus.WalletWebhook) error {
+//			return accounts.Deposit(ctx, wallet.CustomerID, webhook)
+//		}),
+//	}))
+func (m *StaticWalletManager) OnWallet(fn func(ctx context.Context, wallet StaticWallet, webhook *WalletWebhook) error) func(ctx context.Context, webhook *WalletWebhook) error {
+	return func(ctx context.Context, webhook *WalletWebhook) error {
+		wallet, ok, err := m.opts.Store.GetByOrderID(ctx, webhook.OrderID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			if m.opts.OnUnknownWallet != nil {
+				return m.opts.OnUnknownWallet(ctx, webhook)
+			}
+			return nil
+		}
+
+		return fn(ctx, wallet, webhook)
+	}
+}
+
+// lock 锁定 key，返回的函数解除锁定。同一个 key 的 Ensure、Block 与 Refund 依次执行。
+func (m *StaticWalletManager) lock(key StaticWalletKey) func() {
+	m.mu.Lock()
+	l := m.locks[key]
+	if l == nil {
+		l = &walletLock{}
+		m.locks[key] = l
+	}
+	l.refs++
+	m.mu.Unlock()
+
+	l.mu.Lock()
+
+	return func() {
+		l.mu.Unlock()
+
+		m.mu.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(m.locks, key)
+		}
+		m.mu.Unlock()
+	}
+}
@@ -0,0 +1,205 @@
+package cryptomus_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	cryptomus "github.com/difyz9/cryptomus-sdk-go"
+	"github.com/difyz9/cryptomus-sdk-go/cryptomustest"
+)
+
+// countEndpoint 返回统计 endpoint 请求数的中间件。
+func countEndpoint(endpoint cryptomus.Endpoint, count *atomic.Int32) cryptomus.Middleware {
+	return func(next cryptomus.Handler) cryptomus.Handler {
+		return func(ctx context.Context, req *cryptomus.Request) (*cryptomus.Response, error) {
+			if req.Endpoint == endpoint {
+				count.Add(1)
+			}
+			return next(ctx, req)
+		}
+	}
+}
+
+func TestStaticWalletManagerEnsure(t *testing.T) {
+	srv := cryptomustest.NewServer()
+	defer srv.Close()
+
+	var creates atomic.Int32
+	sdk := srv.Client(cryptomus.WithMiddleware(countEndpoint(cryptomus.CreateStaticWalletEndpoint, &creates)))
+	path := filepath.Join(t.TempDir(), "wallets.json")
+	wallets := sdk.StaticWalletManager(cryptomus.StaticWalletManagerOptions{Store: cryptomus.NewFileStaticWalletStore(path)})
+	ctx := context.Background()
+
+	// 同一个 key 的并发调用只创建一个钱包，币种与网络的大小写不影响 key。
+	var wg sync.WaitGroup
+	addresses := make([]string, 8)
+	for i := range addresses {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			wallet, err := wallets.Ensure(ctx, cryptomus.StaticWalletKey{CustomerID: "user-1", Currency: "usdt", Network: "TRON"})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			addresses[i] = wallet.Address
+		}()
+	}
+	wg.Wait()
+
+	if creates.Load() != 1 {
+		t.Errorf("CreateStaticWallet called %d times, want 1", creates.Load())
+	}
+	for _, address := range addresses {
+		if address == "" || address != addresses[0] {
+			t.Fatalf("addresses = %v, want one wallet", addresses)
+		}
+	}
+
+	// 重新打开文件后返回同一个钱包，不再创建。
+	reopened := sdk.StaticWalletManager(cryptomus.StaticWalletManagerOptions{Store: cryptomus.NewFileStaticWalletStore(path)})
+	wallet, err := reopened.Ensure(ctx, cryptomus.StaticWalletKey{CustomerID: "user-1", Currency: "USDT", Network: "tron"})
+	if err != nil || wallet.Address != addresses[0] || wallet.OrderID != "user-1_USDT_tron" {
+		t.Errorf("wallet = %+v, err = %v", wallet, err)
+	}
+	if creates.Load() != 1 {
+		t.Errorf("CreateStaticWallet called %d times after reopening, want 1", creates.Load())
+	}
+
+	if _, err := wallets.Ensure(ctx, cryptomus.StaticWalletKey{CustomerID: "user-1", Currency: "USDT"}); err == nil {
+		t.Error("Ensure without network succeeded")
+	}
+	if _, err := wallets.Get(ctx, cryptomus.StaticWalletKey{CustomerID: "user-2", Currency: "USDT", Network: "tron"}); !errors.Is(err, cryptomus.ErrWalletNotFound) {
+		t.Errorf("Get error = %v, want ErrWalletNotFound", err)
+	}
+}
+
+func TestStaticWalletManagerBlockAndRefund(t *testing.T) {
+	srv := cryptomustest.NewServer()
+	defer srv.Close()
+
+	var blocks atomic.Int32
+	sdk := srv.Client(cryptomus.WithMiddleware(countEndpoint(cryptomus.BlockStaticWalletEndpoint, &blocks)))
+	wallets := sdk.StaticWalletManager(cryptomus.StaticWalletManagerOptions{})
+	ctx := context.Background()
+
+	usdt := cryptomus.StaticWalletKey{CustomerID: "user-1", Currency: "USDT", Network: "tron"}
+	btc := cryptomus.StaticWalletKey{CustomerID: "user-1", Currency: "BTC", Network: "btc"}
+	for _, key := range []cryptomus.StaticWalletKey{usdt, btc} {
+		if _, err := wallets.Ensure(ctx, key); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := wallets.Refund(ctx, usdt, "TRefund"); err == nil {
+		t.Error("Refund of an active wallet succeeded")
+	}
+
+	wallet, err := wallets.Block(ctx, usdt, false)
+	if err != nil || wallet.Status != cryptomus.BlockStaticWalletStatusBlocked {
+		t.Fatalf("Block = %+v, %v", wallet, err)
+	}
+
+	// 已封锁的钱包不再请求 Cryptomus，Ensure 原样返回封锁的钱包。
+	blocked, err := wallets.BlockCustomer(ctx, "user-1", false)
+	if err != nil || len(blocked) != 2 {
+		t.Fatalf("BlockCustomer = %+v, %v", blocked, err)
+	}
+	for _, wallet := range blocked {
+		if wallet.Status != cryptomus.BlockStaticWalletStatusBlocked {
+			t.Errorf("%s wallet status = %s", wallet.Currency, wallet.Status)
+		}
+	}
+	if blocks.Load() != 2 {
+		t.Errorf("BlockStaticWallet called %d times, want 2", blocks.Load())
+	}
+	if wallet, err := wallets.Ensure(ctx, usdt); err != nil || wallet.Status != cryptomus.BlockStaticWalletStatusBlocked {
+		t.Errorf("Ensure after Block = %+v, %v", wallet, err)
+	}
+
+	wallet, err = wallets.Refund(ctx, usdt, "TRefund")
+	if err != nil || wallet.Refund == nil || wallet.Refund.Address != "TRefund" {
+		t.Fatalf("Refund = %+v, %v", wallet, err)
+	}
+	if _, err := wallets.Refund(ctx, usdt, "TRefund"); err == nil {
+		t.Error("second Refund succeeded")
+	}
+
+	missing := cryptomus.StaticWalletKey{CustomerID: "user-2", Currency: "USDT", Network: "tron"}
+	if _, err := wallets.Block(ctx, missing, false); !errors.Is(err, cryptomus.ErrWalletNotFound) {
+		t.Errorf("Block of a missing wallet: %v, want ErrWalletNotFound", err)
+	}
+}
+
+func TestStaticWalletManagerOnWallet(t *testing.T) {
+	srv := cryptomustest.NewServer()
+	defer srv.Close()
+
+	sdk := srv.Client()
+	ctx := context.Background()
+
+	// handler 在创建钱包之前启动，钱包的 url_callback 指向它。
+	var handler http.Handler
+	callback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r)
+	}))
+	defer callback.Close()
+
+	var unknown []string
+	wallets := sdk.StaticWalletManager(cryptomus.StaticWalletManagerOptions{URLCallback: callback.URL})
+
+	var deposits []string
+	onDeposit := func(ctx context.Context, wallet cryptomus.StaticWallet, webhook *cryptomus.WalletWebhook) error {
+		deposits = append(deposits, wallet.CustomerID+" "+webhook.Amount.String())
+		return nil
+	}
+	handler = sdk.WebhookHandler(cryptomus.WebhookHandlerOptions{OnWallet: wallets.OnWallet(onDeposit)})
+
+	if _, err := wallets.Ensure(ctx, cryptomus.StaticWalletKey{CustomerID: "user-1", Currency: "USDT", Network: "tron"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.SendWalletPayment("user-1_USDT_tron", cryptomus.MustParseAmount("25")); err != nil {
+		t.Fatalf("SendWalletPayment: %v", err)
+	}
+	if len(deposits) != 1 || deposits[0] != "user-1 25" {
+		t.Errorf("deposits = %v", deposits)
+	}
+
+	// 在商户后台创建的钱包不属于任何客户，默认响应 200，Cryptomus 不会重试。
+	if _, err := sdk.CreateStaticWallet(&cryptomus.CreateStaticWalletRequest{
+		Currency:    "USDT",
+		Network:     "tron",
+		OrderID:     "manual",
+		URLCallback: callback.URL,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.SendWalletPayment("manual", cryptomus.MustParseAmount("5")); err != nil {
+		t.Errorf("unknown wallet webhook was not acknowledged: %v", err)
+	}
+	if len(deposits) != 1 {
+		t.Errorf("deposits = %v, want the unknown wallet ignored", deposits)
+	}
+
+	errUnknown := errors.New("unknown wallet")
+	strict := sdk.StaticWalletManager(cryptomus.StaticWalletManagerOptions{
+		OnUnknownWallet: func(ctx context.Context, webhook *cryptomus.WalletWebhook) error {
+			unknown = append(unknown, webhook.OrderID)
+			return errUnknown
+		},
+	})
+	handler = sdk.WebhookHandler(cryptomus.WebhookHandlerOptions{OnWallet: strict.OnWallet(onDeposit)})
+
+	if err := srv.SendWalletPayment("manual", cryptomus.MustParseAmount("5")); err == nil {
+		t.Error("webhook acknowledged although OnUnknownWallet failed")
+	}
+	if len(unknown) != 1 || unknown[0] != "manual" || len(deposits) != 1 {
+		t.Errorf("unknown = %v, deposits = %v", unknown, deposits)
+	}
+}
@@ -0,0 +1,213 @@
+package cryptomus
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrInvalidTransition 表示状态变化不可能发生，例如发票从 paid 变回 check。
+// 通常意味着 Webhook 乱序到达或重复处理了旧的事件。
+var ErrInvalidTransition = errors.New("cryptomus: invalid status transition")
+
+// TransitionError 描述一次不可能的状态变化，可以通过 errors.Is(err, ErrInvalidTransition) 判断。
+type TransitionError struct {
+	// Kind 是状态所属的对象，payment 或 payout。
+	Kind string
+	From string
+	To   string
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("cryptomus: invalid %s status transition from %q to %q", e.Kind, e.From, e.To)
+}
+
+func (e *TransitionError) Is(target error) bool {
+	return target == ErrInvalidTransition
+}
+
+// paymentFinalStatuses 是发票支付阶段的最终状态。
+var paymentFinalStatuses = []PaymentStatus{
+	PaymentStatusPaid,
+	PaymentStatusPaidOver,
+	PaymentStatusWrongAmount,
+	PaymentStatusFail,
+	PaymentStatusCancel,
+	PaymentStatusSystemFail,
+}
+
+// paymentTransitions 是发票状态允许的变化。
+var paymentTransitions = map[PaymentStatus][]PaymentStatus{
+	PaymentStatusProcess: append([]PaymentStatus{
+		PaymentStatusCheck, PaymentStatusConfirmCheck, PaymentStatusWrongAmountWaiting, PaymentStatusLocked,
+	}, paymentFinalStatuses...),
+	PaymentStatusCheck: append([]PaymentStatus{
+		PaymentStatusConfirmCheck, PaymentStatusWrongAmountWaiting, PaymentStatusLocked,
+	}, paymentFinalStatuses...),
+	PaymentStatusConfirmCheck: append([]PaymentStatus{
+		PaymentStatusWrongAmountWaiting, PaymentStatusLocked,
+	}, paymentFinalStatuses...),
+	PaymentStatusWrongAmountWaiting: append([]PaymentStatus{
+		PaymentStatusCheck, PaymentStatusConfirmCheck, PaymentStatusLocked,
+	}, paymentFinalStatuses...),
+	PaymentStatusLocked: append([]PaymentStatus{
+		PaymentStatusRefundProcess, PaymentStatusRefundPaid, PaymentStatusRefundFail,
+	}, paymentFinalStatuses...),
+	PaymentStatusPaid:          {PaymentStatusRefundProcess, PaymentStatusRefundPaid, PaymentStatusRefundFail},
+	PaymentStatusPaidOver:      {PaymentStatusRefundProcess, PaymentStatusRefundPaid, PaymentStatusRefundFail},
+	PaymentStatusWrongAmount:   {PaymentStatusRefundProcess, PaymentStatusRefundPaid, PaymentStatusRefundFail},
+	PaymentStatusRefundProcess: {PaymentStatusRefundPaid, PaymentStatusRefundFail},
+	PaymentStatusRefundFail:    {PaymentStatusRefundProcess, PaymentStatusRefundPaid},
+	PaymentStatusFail:          {},
+	PaymentStatusCancel:        {},
+	PaymentStatusSystemFail:    {},
+	PaymentStatusRefundPaid:    {},
+}
+
+// IsKnown 判断状态是否为 SDK 已知的状态。
+func (s PaymentStatus) IsKnown() bool {
+	_, ok := paymentTransitions[s]
+	return ok
+}
+
+// IsFinal 判断发票是否已经结束：支付成功、金额不足、失败、取消，或退款已经完成（refund_paid）。
+//
+// 支付阶段的最终状态之后仍然可能发起退款，因此 paid 之后还可能出现 refund_* 状态；
+// refund_fail 之后可以重新退款（见 CanTransitionTo），因此不是最终状态。
+func (s PaymentStatus) IsFinal() bool {
+	return slices.Contains(paymentFinalStatuses, s) || s == PaymentStatusRefundPaid
+}
+
+// IsSuccess 判断客户是否已经足额支付（paid 或 paid_over）。
+func (s PaymentStatus) IsSuccess() bool {
+	return s == PaymentStatusPaid || s == PaymentStatusPaidOver
+}
+
+// IsFailed 判断发票是否以未支付结束：失败、取消、系统错误或金额不足（wrong_amount）。
+func (s PaymentStatus) IsFailed() bool {
+	switch s {
+	case PaymentStatusFail, PaymentStatusCancel, PaymentStatusSystemFail, PaymentStatusWrongAmount:
+		return true
+	}
+
+	return false
+}
+
+// IsRefund 判断发票是否处于退款阶段（refund_process、refund_fail 或 refund_paid）。
+func (s PaymentStatus) IsRefund() bool {
+	switch s {
+	case PaymentStatusRefundProcess, PaymentStatusRefundFail, PaymentStatusRefundPaid:
+		return true
+	}
+
+	return false
+}
+
+// IsPending 判断发票是否仍在等待支付或确认，包括等待补款（wrong_amount_waiting）与 AML 锁定（locked）。
+func (s PaymentStatus) IsPending() bool {
+	switch s {
+	case PaymentStatusProcess,
+		PaymentStatusCheck,
+		PaymentStatusConfirmCheck,
+		PaymentStatusWrongAmountWaiting,
+		PaymentStatusLocked:
+		return true
+	}
+
+	return false
+}
+
+// NeedsTopUp 判断客户是否支付不足且仍可以补款（wrong_amount_waiting）。
+func (s PaymentStatus) NeedsTopUp() bool {
+	return s == PaymentStatusWrongAmountWaiting
+}
+
+// CanResendWebhook 判断是否可以通过 ResendWebhook 重发 Webhook，Cryptomus 只为已结束的发票重发，
+// 规则与 IsFinal 相同。
+func (s PaymentStatus) CanResendWebhook() bool {
+	return s.IsFinal()
+}
+
+// CanTransitionTo 判断发票状态能否从 s 变为 next。相同状态（重复的 Webhook）与未知状态始终返回 true。
+func (s PaymentStatus) CanTransitionTo(next PaymentStatus) bool {
+	if s == next || !s.IsKnown() || !next.IsKnown() {
+		return true
+	}
+
+	return slices.Contains(paymentTransitions[s], next)
+}
+
+// ValidatePaymentTransition 在发票状态不可能从 from 变为 to 时返回 *TransitionError。
+//
+// 示例：
+//
+//	if err := cryptomus.ValidatePaymentTransition(order.Status, webhook.Status); err != nil {
+//		// Webhook 乱序到达，忽略旧的事件
+//		return nil
+//	}
+func ValidatePaymentTransition(from, to PaymentStatus) error {
+	if from.CanTransitionTo(to) {
+		return nil
+	}
+
+	return &TransitionError{Kind: "payment", From: string(from), To: string(to)}
+}
+
+// payoutTransitions 是提现状态允许的变化，最终状态没有后续状态。
+var payoutTransitions = map[PayoutStatus][]PayoutStatus{
+	PayoutStatusProcess:    {PayoutStatusCheck, PayoutStatusPaid, PayoutStatusFail, PayoutStatusCancel, PayoutStatusSystemFail},
+	PayoutStatusCheck:      {PayoutStatusProcess, PayoutStatusPaid, PayoutStatusFail, PayoutStatusCancel, PayoutStatusSystemFail},
+	PayoutStatusPaid:       {},
+	PayoutStatusFail:       {},
+	PayoutStatusCancel:     {},
+	PayoutStatusSystemFail: {},
+}
+
+// IsKnown 判断状态是否为 SDK 已知的状态。
+func (s PayoutStatus) IsKnown() bool {
+	_, ok := payoutTransitions[s]
+	return ok
+}
+
+// IsFinal 判断提现是否已经结束（paid、fail、cancel 或 system_fail）。
+func (s PayoutStatus) IsFinal() bool {
+	return s.IsSuccess() || s.IsFailed()
+}
+
+// IsSuccess 判断提现是否成功（paid）。
+func (s PayoutStatus) IsSuccess() bool {
+	return s == PayoutStatusPaid
+}
+
+// IsFailed 判断提现是否失败（fail、cancel 或 system_fail），失败的提现金额会退回余额。
+func (s PayoutStatus) IsFailed() bool {
+	switch s {
+	case PayoutStatusFail, PayoutStatusCancel, PayoutStatusSystemFail:
+		return true
+	}
+
+	return false
+}
+
+// IsPending 判断提现是否仍在处理中（process 或 check）。
+func (s PayoutStatus) IsPending() bool {
+	return s == PayoutStatusProcess || s == PayoutStatusCheck
+}
+
+// CanTransitionTo 判断提现状态能否从 s 变为 next。相同状态与未知状态始终返回 true。
+func (s PayoutStatus) CanTransitionTo(next PayoutStatus) bool {
+	if s == next || !s.IsKnown() || !next.IsKnown() {
+		return true
+	}
+
+	return slices.Contains(payoutTransitions[s], next)
+}
+
+// ValidatePayoutTransition 在提现状态不可能从 from 变为 to 时返回 *TransitionError。
+func ValidatePayoutTransition(from, to PayoutStatus) error {
+	if from.CanTransitionTo(to) {
+		return nil
+	}
+
+	return &TransitionError{Kind: "payout", From: string(from), To: string(to)}
+}
@@ -0,0 +1,128 @@
+package cryptomus
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestPaymentStatusPredicates(t *testing.T) {
+	tests := []struct {
+		status                                  PaymentStatus
+		final, success, failed, refund, pending bool
+	}{
+		{status: PaymentStatusProcess, pending: true},
+		{status: PaymentStatusCheck, pending: true},
+		{status: PaymentStatusConfirmCheck, pending: true},
+		{status: PaymentStatusWrongAmountWaiting, pending: true},
+		{status: PaymentStatusLocked, pending: true},
+		{status: PaymentStatusPaid, final: true, success: true},
+		{status: PaymentStatusPaidOver, final: true, success: true},
+		{status: PaymentStatusWrongAmount, final: true, failed: true},
+		{status: PaymentStatusFail, final: true, failed: true},
+		{status: PaymentStatusCancel, final: true, failed: true},
+		{status: PaymentStatusSystemFail, final: true, failed: true},
+		{status: PaymentStatusRefundProcess, refund: true},
+		{status: PaymentStatusRefundFail, refund: true},
+		{status: PaymentStatusRefundPaid, final: true, refund: true},
+		{status: "unknown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			s := tt.status
+			if s.IsFinal() != tt.final || s.IsSuccess() != tt.success || s.IsFailed() != tt.failed ||
+				s.IsRefund() != tt.refund || s.IsPending() != tt.pending {
+				t.Errorf("final=%t success=%t failed=%t refund=%t pending=%t, want %+v",
+					s.IsFinal(), s.IsSuccess(), s.IsFailed(), s.IsRefund(), s.IsPending(), tt)
+			}
+			if s.CanResendWebhook() != s.IsFinal() {
+				t.Errorf("CanResendWebhook() = %t, want IsFinal() = %t", s.CanResendWebhook(), s.IsFinal())
+			}
+			if s.IsKnown() == (s == "unknown") {
+				t.Errorf("IsKnown() = %t", s.IsKnown())
+			}
+		})
+	}
+}
+
+// 最终状态之后只能进入退款阶段，退款阶段的最终状态之后不能再变化。
+func TestPaymentFinalStatusesAgreeWithTransitions(t *testing.T) {
+	for from, next := range paymentTransitions {
+		if !from.IsFinal() {
+			if len(next) == 0 {
+				t.Errorf("%s has no transitions but is not final", from)
+			}
+			continue
+		}
+
+		for _, to := range next {
+			if from.IsRefund() || !to.IsRefund() {
+				t.Errorf("final status %s can transition to %s", from, to)
+			}
+		}
+	}
+}
+
+func TestPaymentTransitions(t *testing.T) {
+	tests := []struct {
+		from, to PaymentStatus
+		ok       bool
+	}{
+		{from: PaymentStatusCheck, to: PaymentStatusPaid, ok: true},
+		{from: PaymentStatusCheck, to: PaymentStatusCheck, ok: true},
+		{from: PaymentStatusWrongAmountWaiting, to: PaymentStatusCheck, ok: true},
+		{from: PaymentStatusPaid, to: PaymentStatusRefundProcess, ok: true},
+		{from: PaymentStatusRefundFail, to: PaymentStatusRefundProcess, ok: true},
+		{from: "unknown", to: PaymentStatusCheck, ok: true},
+		{from: PaymentStatusPaid, to: PaymentStatusCheck},
+		{from: PaymentStatusCancel, to: PaymentStatusPaid},
+		{from: PaymentStatusRefundPaid, to: PaymentStatusRefundFail},
+		{from: PaymentStatusConfirmCheck, to: PaymentStatusProcess},
+	}
+
+	for _, tt := range tests {
+		err := ValidatePaymentTransition(tt.from, tt.to)
+		if tt.ok {
+			if err != nil {
+				t.Errorf("%s -> %s: unexpected error %v", tt.from, tt.to, err)
+			}
+			continue
+		}
+
+		var transitionErr *TransitionError
+		if !errors.Is(err, ErrInvalidTransition) || !errors.As(err, &transitionErr) ||
+			transitionErr.From != string(tt.from) || transitionErr.To != string(tt.to) {
+			t.Errorf("%s -> %s: error = %v, want *TransitionError", tt.from, tt.to, err)
+		}
+	}
+}
+
+func TestPayoutStatus(t *testing.T) {
+	for from, next := range payoutTransitions {
+		if from.IsFinal() != (len(next) == 0) {
+			t.Errorf("%s: IsFinal() = %t with %d transitions", from, from.IsFinal(), len(next))
+		}
+		if from.IsFinal() == from.IsPending() {
+			t.Errorf("%s: IsFinal() and IsPending() both %t", from, from.IsFinal())
+		}
+	}
+
+	if err := ValidatePayoutTransition(PayoutStatusCheck, PayoutStatusPaid); err != nil {
+		t.Errorf("check -> paid: %v", err)
+	}
+	if err := ValidatePayoutTransition(PayoutStatusPaid, PayoutStatusProcess); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("paid -> process: error = %v, want ErrInvalidTransition", err)
+	}
+}
+
+func TestCreateInvoiceDataStatus(t *testing.T) {
+	var data CreateInvoiceData
+	if err := json.Unmarshal([]byte(`{"payment_status":"refund_fail","status":"refund_fail"}`), &data); err != nil {
+		t.Fatal(err)
+	}
+
+	if data.PaymentStatus != PaymentStatusRefundFail || data.Status != PaymentStatusRefundFail || !data.PaymentStatus.IsRefund() {
+		t.Errorf("data = %+v", data)
+	}
+}
@@ -0,0 +1,166 @@
+package cryptomus
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ValidationError 是请求在发送前未通过本地校验时返回的错误，Errors 的格式与 Cryptomus 返回的字段错误相同。
+//
+// 示例：
+//
+//	_, err := sdk.CreateInvoice(payload)
+//	var validationErr *cryptomus.ValidationError
+//	if errors.As(err, &validationErr) {
+//	    for field, messages := range validationErr.Errors {
+//	        log.Printf("%s: %v", field, messages)
+//	    }
+//	}
+type ValidationError struct {
+	Errors Errors
+}
+
+func (e *ValidationError) Error() string {
+	return "cryptomus: invalid request: " + e.Errors.String()
+}
+
+// Validator 由所有请求类型实现，Validate 在请求签名之前自动调用。
+type Validator interface {
+	Validate() error
+}
+
+// WithValidation 设置是否在发送请求前调用 Validate，默认启用。
+//
+// 当本地规则比 Cryptomus 更严格而导致合法请求被拒绝时，可以使用 WithValidation(false) 关闭校验。
+func WithValidation(enabled bool) Option {
+	return func(c *Cryptomus) {
+		c.skipValidation = !enabled
+	}
+}
+
+// validateRequest 校验请求体，请求体为 nil 指针时返回错误。
+func validateRequest(payload any) error {
+	if v := reflect.ValueOf(payload); v.Kind() == reflect.Pointer && v.IsNil() {
+		return errors.New("cryptomus: request is nil")
+	}
+
+	if validator, ok := payload.(Validator); ok {
+		return validator.Validate()
+	}
+
+	return nil
+}
+
+const (
+	// MaxOrderIDLength 是 order_id 的最大长度。
+	MaxOrderIDLength = 128
+	// MinInvoiceLifetime 与 MaxInvoiceLifetime 是发票有效期（秒）的取值范围。
+	MinInvoiceLifetime = 300
+	MaxInvoiceLifetime = 43200
+	// MaxAccuracyPaymentPercent 是 accuracy_payment_percent 的最大值。
+	MaxAccuracyPaymentPercent = 5
+	// MinDiscountPercent 与 MaxDiscountPercent 是折扣百分比的取值范围。
+	MinDiscountPercent = -99
+	MaxDiscountPercent = 100
+)
+
+// validation 收集字段错误。
+type validation struct {
+	errs Errors
+}
+
+func (v *validation) add(field, format string, args ...any) {
+	if v.errs == nil {
+		v.errs = Errors{}
+	}
+
+	v.errs[field] = append(v.errs[field], fmt.Sprintf(format, args...))
+}
+
+// label 返回字段在错误信息中的名称，例如 order_id 显示为 order id。
+func label(field string) string {
+	return strings.ReplaceAll(field, "_", " ")
+}
+
+func (v *validation) err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+
+	return &ValidationError{Errors: v.errs}
+}
+
+func (v *validation) required(field, value string) {
+	if value == "" {
+		v.add(field, "The %s field is required.", label(field))
+	}
+}
+
+func (v *validation) maxLength(field, value string, max int) {
+	if utf8.RuneCountInString(value) > max {
+		v.add(field, "The %s must not be greater than %d characters.", label(field), max)
+	}
+}
+
+func (v *validation) between(field string, value, min, max int) {
+	if value < min || value > max {
+		v.add(field, "The %s must be between %d and %d.", label(field), min, max)
+	}
+}
+
+func (v *validation) positiveAmount(field string, amount Amount) {
+	if amount.Sign() <= 0 {
+		v.add(field, "The %s must be greater than 0.", label(field))
+	}
+}
+
+func (v *validation) nonNegativeAmount(field string, amount Amount) {
+	if amount.Sign() < 0 {
+		v.add(field, "The %s must be at least 0.", label(field))
+	}
+}
+
+// orderID 校验 order_id：长度不超过 MaxOrderIDLength，只能包含字母、数字、短横线与下划线。
+func (v *validation) orderID(field, value string, required bool) {
+	if value == "" {
+		if required {
+			v.required(field, value)
+		}
+		return
+	}
+
+	if len(value) > MaxOrderIDLength {
+		v.add(field, "The %s must not be greater than %d characters.", label(field), MaxOrderIDLength)
+	}
+
+	for _, r := range value {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			v.add(field, "The %s may only contain letters, numbers, dashes and underscores.", label(field))
+			break
+		}
+	}
+}
+
+// uuidOrOrderID 校验 info、refund、block 等接口中 uuid 与 order_id 至少需要一个的规则。
+func (v *validation) uuidOrOrderID(uuid, orderID string) {
+	if uuid == "" && orderID == "" {
+		v.add("uuid", "The uuid field is required when order id is not present.")
+		return
+	}
+
+	v.orderID("order_id", orderID, false)
+}
+
+func (v *validation) historyDate(field, value string) {
+	if value == "" {
+		return
+	}
+
+	if _, err := time.Parse(historyDateLayout, value); err != nil {
+		v.add(field, "The %s does not match the format Y-m-d H:i:s.", label(field))
+	}
+}
@@ -0,0 +1,130 @@
+package cryptomus
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCreateInvoiceRequestValidate(t *testing.T) {
+	valid := func() *CreateInvoiceRequest {
+		return &CreateInvoiceRequest{Amount: MustParseAmount("10"), Currency: "USDT", OrderID: "order-1"}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(r *CreateInvoiceRequest)
+		fields []string
+	}{
+		{name: "valid"},
+		{name: "lifetime unset", modify: func(r *CreateInvoiceRequest) { r.Lifetime = 0 }},
+		{name: "lifetime minimum", modify: func(r *CreateInvoiceRequest) { r.Lifetime = MinInvoiceLifetime }},
+		{name: "lifetime maximum", modify: func(r *CreateInvoiceRequest) { r.Lifetime = MaxInvoiceLifetime }},
+		{name: "lifetime too short", modify: func(r *CreateInvoiceRequest) { r.Lifetime = MinInvoiceLifetime - 1 }, fields: []string{"lifetime"}},
+		{name: "lifetime too long", modify: func(r *CreateInvoiceRequest) { r.Lifetime = MaxInvoiceLifetime + 1 }, fields: []string{"lifetime"}},
+		{name: "accuracy maximum", modify: func(r *CreateInvoiceRequest) { r.AccuracyPaymentPercent = MaxAccuracyPaymentPercent }},
+		{name: "accuracy negative", modify: func(r *CreateInvoiceRequest) { r.AccuracyPaymentPercent = -1 }, fields: []string{"accuracy_payment_percent"}},
+		{name: "accuracy too high", modify: func(r *CreateInvoiceRequest) { r.AccuracyPaymentPercent = MaxAccuracyPaymentPercent + 1 }, fields: []string{"accuracy_payment_percent"}},
+		{name: "order_id missing", modify: func(r *CreateInvoiceRequest) { r.OrderID = "" }, fields: []string{"order_id"}},
+		{name: "order_id maximum length", modify: func(r *CreateInvoiceRequest) { r.OrderID = strings.Repeat("a", MaxOrderIDLength) }},
+		{name: "order_id too long", modify: func(r *CreateInvoiceRequest) { r.OrderID = strings.Repeat("a", MaxOrderIDLength+1) }, fields: []string{"order_id"}},
+		{name: "order_id charset", modify: func(r *CreateInvoiceRequest) { r.OrderID = "Order_1-a" }},
+		{name: "order_id with space", modify: func(r *CreateInvoiceRequest) { r.OrderID = "order 1" }, fields: []string{"order_id"}},
+		{name: "order_id with unicode", modify: func(r *CreateInvoiceRequest) { r.OrderID = "订单1" }, fields: []string{"order_id"}},
+		{name: "amount zero", modify: func(r *CreateInvoiceRequest) { r.Amount = Amount{} }, fields: []string{"amount"}},
+		{
+			name:   "several fields",
+			modify: func(r *CreateInvoiceRequest) { r.Currency, r.Lifetime = "", 60 },
+			fields: []string{"currency", "lifetime"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := valid()
+			if tt.modify != nil {
+				tt.modify(r)
+			}
+			checkValidation(t, r.Validate(), tt.fields)
+		})
+	}
+}
+
+func TestUUIDOrOrderIDValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		request Validator
+		fields  []string
+	}{
+		{name: "uuid", request: &PaymentInformationRequest{UUID: "8b03432e-385b-4670-8d06-064591096795"}},
+		{name: "order_id", request: &PaymentInformationRequest{OrderID: "order-1"}},
+		{name: "neither", request: &PaymentInformationRequest{}, fields: []string{"uuid"}},
+		{name: "invalid order_id", request: &PaymentInformationRequest{OrderID: "order#1"}, fields: []string{"order_id"}},
+		{name: "uuid with invalid order_id", request: &PaymentInformationRequest{UUID: "u1", OrderID: "order#1"}, fields: []string{"order_id"}},
+		{name: "cancel recurring", request: CancelRecurringPaymentRequest{OrderID: "r1"}},
+		{name: "cancel recurring without ids", request: CancelRecurringPaymentRequest{}, fields: []string{"uuid"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkValidation(t, tt.request.Validate(), tt.fields)
+		})
+	}
+}
+
+func TestValidateRequest(t *testing.T) {
+	if err := validateRequest((*CreateInvoiceRequest)(nil)); err == nil {
+		t.Error("nil request accepted")
+	}
+	if err := validateRequest(map[string]string{}); err != nil {
+		t.Errorf("request without Validate: %v", err)
+	}
+}
+
+func TestListRecurringPaymentsRequest(t *testing.T) {
+	var sent *Request
+	sdk := New(WithMerchant("merchant"), WithPaymentToken("payment"), WithMiddleware(func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) (*Response, error) {
+			sent = req
+			return NewResponse(http.StatusOK, []byte(`{"state":0,"result":{"items":[]}}`)), nil
+		}
+	}))
+
+	if _, err := sdk.ListRecurringPaymentsWithContext(context.Background(), ListRecurringPaymentsRequest{Cursor: "c2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 游标只通过查询参数发送，不出现在请求体中。
+	if sent.Payload == nil || string(sent.Body) != "{}" || sent.Query["cursor"] != "c2" {
+		t.Errorf("payload = %v, body = %s, query = %v", sent.Payload, sent.Body, sent.Query)
+	}
+}
+
+// checkValidation 检查 err 是否为只包含 fields 字段错误的 ValidationError，fields 为空时 err 应为 nil。
+func checkValidation(t *testing.T, err error, fields []string) {
+	t.Helper()
+
+	if len(fields) == 0 {
+		if err != nil {
+			t.Errorf("err = %v, want nil", err)
+		}
+		return
+	}
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("err = %v, want *ValidationError", err)
+	}
+
+	var got []string
+	for field := range validationErr.Errors {
+		got = append(got, field)
+	}
+	slices.Sort(got)
+	if !slices.Equal(got, fields) {
+		t.Errorf("fields = %v, want %v", got, fields)
+	}
+}
@@ -0,0 +1,486 @@
+package cryptomus
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// WatchOptions 配置 WatchPayment、WaitForPayment、WatchPayout 与 WaitForPayout 的轮询间隔。
+//
+// 发票的轮询间隔根据 ExpiredAt 自适应：距离过期越近轮询越频繁，交易确认中（confirm_check）
+// 使用最短间隔；提现的轮询间隔在状态没有变化时从 MinInterval 逐渐增加到 MaxInterval。
+//
+// 如果同一个 SDK 实例的 WebhookHandler 收到了匹配的已验证 Webhook，直接使用 Webhook 中的状态，
+// 不必等待下一次轮询，也不会再查询 API，因此在 API 不可用时同样有效。
+type WatchOptions struct {
+	// MinInterval 是最短轮询间隔，默认为 DefaultWatchMinInterval。
+	MinInterval time.Duration
+	// MaxInterval 是最长轮询间隔，默认为 DefaultWatchMaxInterval。
+	MaxInterval time.Duration
+}
+
+const (
+	DefaultWatchMinInterval = 2 * time.Second
+	DefaultWatchMaxInterval = 30 * time.Second
+)
+
+// PaymentUpdate 是 WatchPayment 发送的发票状态变化。Err 不为 nil 时表示查询失败，之后通道会被关闭。
+type PaymentUpdate struct {
+	Payment *CreateInvoiceData
+	Err     error
+}
+
+// PayoutUpdate 是 WatchPayout 发送的提现状态变化。Err 不为 nil 时表示查询失败，之后通道会被关闭。
+type PayoutUpdate struct {
+	Payout *PayoutData
+	Err    error
+}
+
+// WatchPayment 监听发票状态，每次状态变化时向返回的通道发送一次 PaymentUpdate，第一次发送的是当前状态。
+//
+// uuidOrOrderID 可以是发票的 uuid 或 order_id。发票进入最终状态（见 PaymentStatus.IsFinal）、ctx 结束，
+// 或查询返回不可重试的错误时，通道会被关闭。网络错误、HTTP 429 与 5xx 会在下一次轮询时重试。
+//
+// 示例：
+//
+//	for update := range sdk.WatchPayment(ctx, orderID, cryptomus.WatchOptions{}) {
+//		if update.Err != nil {
+//			log.Fatal(update.Err)
+//		}
+//		log.Printf("发票状态: %s", update.Payment.PaymentStatus)
+//	}
+func (sdk *Cryptomus) WatchPayment(ctx context.Context, uuidOrOrderID string, opts WatchOptions) <-chan PaymentUpdate {
+	opts = opts.withDefaults()
+	updates := make(chan PaymentUpdate, 1)
+
+	lookup := &infoLookup{id: uuidOrOrderID}
+	w := watcher[*CreateInvoiceData, PaymentUpdate]{
+		sdk: sdk,
+		match: func(webhook Webhook) bool {
+			payment, ok := webhook.(*PaymentWebhook)
+			return ok && (payment.UUID == uuidOrOrderID || payment.OrderID == uuidOrOrderID)
+		},
+		fromWebhook: func(last *CreateInvoiceData, webhook Webhook) *CreateInvoiceData {
+			return paymentFromWebhook(last, webhook.(*PaymentWebhook))
+		},
+		fetch: func(ctx context.Context) (*CreateInvoiceData, error) {
+			return lookupInfo(lookup, func(uuid, orderID string) (*CreateInvoiceData, error) {
+				resp, err := sdk.PaymentInformationWithContext(ctx, &PaymentInformationRequest{UUID: uuid, OrderID: orderID})
+				if err != nil {
+					return nil, err
+				}
+				if resp.Result.CreateInvoiceData == nil {
+					return nil, errors.New("cryptomus: empty payment information")
+				}
+				return resp.Result.CreateInvoiceData, nil
+			})
+		},
+		status: func(payment *CreateInvoiceData) string {
+			return string(payment.PaymentStatus)
+		},
+		final: func(payment *CreateInvoiceData) bool {
+			return payment.IsFinal || payment.PaymentStatus.IsFinal()
+		},
+		interval: func(payment *CreateInvoiceData, _ int) time.Duration {
+			return opts.paymentInterval(payment)
+		},
+		send: func(payment *CreateInvoiceData, err error) PaymentUpdate {
+			return PaymentUpdate{Payment: payment, Err: err}
+		},
+		retry: opts.MinInterval,
+	}
+
+	go w.run(ctx, updates)
+
+	return updates
+}
+
+// WaitForPayment 等待发票进入最终状态并返回最终的发票信息。
+//
+// 轮询与 Webhook 的规则见 WatchPayment。ctx 结束时返回 ctx.Err()。
+//
+// 示例：
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+//	defer cancel()
+//
+//	payment, err := sdk.WaitForPayment(ctx, orderID, cryptomus.WatchOptions{})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//
+//	if payment.PaymentStatus.IsSuccess() {
+//		log.Println("支付成功")
+//	}
+func (sdk *Cryptomus) WaitForPayment(ctx context.Context, uuidOrOrderID string, opts WatchOptions) (*CreateInvoiceData, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var last *CreateInvoiceData
+	for update := range sdk.WatchPayment(ctx, uuidOrOrderID, opts) {
+		if update.Err != nil {
+			return nil, update.Err
+		}
+		last = update.Payment
+	}
+
+	if last == nil || !(last.IsFinal || last.PaymentStatus.IsFinal()) {
+		return nil, ctx.Err()
+	}
+
+	return last, nil
+}
+
+// WatchPayout 监听提现状态，规则与 WatchPayment 相同，提现进入最终状态（见 PayoutStatus.IsFinal）时关闭通道。
+func (sdk *Cryptomus) WatchPayout(ctx context.Context, uuidOrOrderID string, opts WatchOptions) <-chan PayoutUpdate {
+	opts = opts.withDefaults()
+	updates := make(chan PayoutUpdate, 1)
+
+	lookup := &infoLookup{id: uuidOrOrderID}
+	w := watcher[*PayoutData, PayoutUpdate]{
+		sdk: sdk,
+		match: func(webhook Webhook) bool {
+			payout, ok := webhook.(*PayoutWebhook)
+			return ok && (payout.UUID == uuidOrOrderID || payout.OrderID == uuidOrOrderID)
+		},
+		fromWebhook: func(last *PayoutData, webhook Webhook) *PayoutData {
+			return payoutFromWebhook(last, webhook.(*PayoutWebhook))
+		},
+		fetch: func(ctx context.Context) (*PayoutData, error) {
+			return lookupInfo(lookup, func(uuid, orderID string) (*PayoutData, error) {
+				resp, err := sdk.PayoutInformationWithContext(ctx, &PayoutInformationRequest{UUID: uuid, OrderID: orderID})
+				if err != nil {
+					return nil, err
+				}
+				if resp.Result == nil {
+					return nil, errors.New("cryptomus: empty payout information")
+				}
+				return resp.Result, nil
+			})
+		},
+		status: func(payout *PayoutData) string {
+			return string(payout.Status)
+		},
+		final: func(payout *PayoutData) bool {
+			return payout.IsFinal || payout.Status.IsFinal()
+		},
+		interval: func(_ *PayoutData, unchanged int) time.Duration {
+			return opts.backoffInterval(unchanged)
+		},
+		send: func(payout *PayoutData, err error) PayoutUpdate {
+			return PayoutUpdate{Payout: payout, Err: err}
+		},
+		retry: opts.MinInterval,
+	}
+
+	go w.run(ctx, updates)
+
+	return updates
+}
+
+// WaitForPayout 等待提现进入最终状态并返回最终的提现信息，规则与 WaitForPayment 相同。
+func (sdk *Cryptomus) WaitForPayout(ctx context.Context, uuidOrOrderID string, opts WatchOptions) (*PayoutData, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var last *PayoutData
+	for update := range sdk.WatchPayout(ctx, uuidOrOrderID, opts) {
+		if update.Err != nil {
+			return nil, update.Err
+		}
+		last = update.Payout
+	}
+
+	if last == nil || !(last.IsFinal || last.Status.IsFinal()) {
+		return nil, ctx.Err()
+	}
+
+	return last, nil
+}
+
+// paymentFromWebhook 用 Webhook 中的字段更新最近一次查询到的发票，Webhook 不包含的字段（例如 ExpiredAt）保持不变。
+func paymentFromWebhook(last *CreateInvoiceData, webhook *PaymentWebhook) *CreateInvoiceData {
+	var payment CreateInvoiceData
+	if last != nil {
+		payment = *last
+	}
+
+	payment.UUID = webhook.UUID
+	payment.OrderID = webhook.OrderID
+	payment.Amount = webhook.Amount
+	payment.PaymentAmount = webhook.PaymentAmount
+	payment.MerchantAmount = webhook.MerchantAmount
+	payment.PaymentStatus = webhook.Status
+	payment.Status = webhook.Status
+	payment.IsFinal = webhook.IsFinal
+	payment.From = webhook.From
+	payment.Txid = webhook.Txid
+	payment.Network = webhook.Network
+	payment.Currency = webhook.Currency
+	payment.PayerCurrency = webhook.PayerCurrency
+	payment.AdditionalData = webhook.AdditionalData
+
+	return &payment
+}
+
+// payoutFromWebhook 用 Webhook 中的字段更新最近一次查询到的提现，Webhook 不包含的字段（例如 Address）保持不变。
+func payoutFromWebhook(last *PayoutData, webhook *PayoutWebhook) *PayoutData {
+	var payout PayoutData
+	if last != nil {
+		payout = *last
+	}
+
+	payout.UUID = webhook.UUID
+	payout.Amount = webhook.Amount
+	payout.Currency = webhook.Currency
+	payout.Network = webhook.Network
+	payout.TxID = webhook.Txid
+	payout.Status = webhook.Status
+	payout.IsFinal = webhook.IsFinal
+	payout.PayerCurrency = webhook.PayerCurrency
+	payout.PayerAmount = webhook.PayerAmount
+
+	return &payout
+}
+
+func (o WatchOptions) withDefaults() WatchOptions {
+	if o.MinInterval <= 0 {
+		o.MinInterval = DefaultWatchMinInterval
+	}
+	if o.MaxInterval <= 0 {
+		o.MaxInterval = DefaultWatchMaxInterval
+	}
+	if o.MaxInterval < o.MinInterval {
+		o.MaxInterval = o.MinInterval
+	}
+
+	return o
+}
+
+func (o WatchOptions) clamp(d time.Duration) time.Duration {
+	return min(max(d, o.MinInterval), o.MaxInterval)
+}
+
+// paymentInterval 根据发票的过期时间与状态计算下一次轮询的间隔。
+func (o WatchOptions) paymentInterval(payment *CreateInvoiceData) time.Duration {
+	if payment.PaymentStatus == PaymentStatusConfirmCheck {
+		// 已经看到交易，正在等待确认，最终状态很快就会出现。
+		return o.MinInterval
+	}
+
+	if payment.ExpiredAt <= 0 {
+		return o.MaxInterval
+	}
+
+	remaining := time.Until(time.Unix(int64(payment.ExpiredAt), 0))
+	if remaining <= 0 {
+		// 已经过期，等待 Cryptomus 将发票标记为 cancel。
+		return o.MinInterval
+	}
+
+	return o.clamp(remaining / 20)
+}
+
+// backoffInterval 在状态连续 unchanged 次没有变化后，将间隔从 MinInterval 按 1.5 倍增加到 MaxInterval。
+func (o WatchOptions) backoffInterval(unchanged int) time.Duration {
+	d := o.MinInterval
+	for range unchanged {
+		d = d * 3 / 2
+		if d >= o.MaxInterval {
+			return o.MaxInterval
+		}
+	}
+
+	return d
+}
+
+// infoLookup 记住 uuidOrOrderID 是 uuid 还是 order_id：先按 uuid 查询，
+// 不存在或 Cryptomus 认为它不是合法的 uuid 时改为按 order_id 查询。
+type infoLookup struct {
+	id      string
+	orderID bool
+}
+
+func lookupInfo[T any](l *infoLookup, fetch func(uuid, orderID string) (T, error)) (T, error) {
+	if l.orderID {
+		return fetch("", l.id)
+	}
+
+	result, err := fetch(l.id, "")
+	if IsNotFound(err) || invalidUUID(err) {
+		l.orderID = true
+		return fetch("", l.id)
+	}
+
+	return result, err
+}
+
+// invalidUUID 判断 err 是否为 uuid 字段的校验错误，其他字段的校验错误不会因为改用 order_id 而消失。
+func invalidUUID(err error) bool {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return len(validationErr.Errors["uuid"]) > 0
+	}
+
+	apiErr, ok := AsAPIError(err)
+	return ok && len(apiErr.Errors["uuid"]) > 0
+}
+
+// watcher 轮询 fetch 并发送状态变化，直到进入最终状态。U 是发送到通道中的更新类型。
+type watcher[T, U any] struct {
+	sdk   *Cryptomus
+	match func(Webhook) bool
+	// fromWebhook 用匹配的 Webhook 更新最近一次的结果，last 在还没有查询到结果时为零值。
+	fromWebhook func(last T, webhook Webhook) T
+	fetch       func(ctx context.Context) (T, error)
+	status      func(T) string
+	final       func(T) bool
+	interval    func(current T, unchanged int) time.Duration
+	send        func(T, error) U
+	// retry 是还没有查询到结果时暂时性错误的重试间隔。
+	retry time.Duration
+}
+
+func (w watcher[T, U]) run(ctx context.Context, updates chan<- U) {
+	defer close(updates)
+
+	webhooks := w.sdk.webhookHub.subscribe(w.match)
+	defer w.sdk.webhookHub.unsubscribe(webhooks)
+
+	var (
+		last      T
+		status    string
+		seen      bool
+		unchanged int
+		wait      time.Duration
+	)
+
+	// update 处理一次查询或 Webhook 的结果，返回 false 表示应该停止监听。
+	update := func(result T) bool {
+		last = result
+		if !seen || w.status(result) != status {
+			seen, status, unchanged = true, w.status(result), 0
+			if !emit(ctx, updates, w.send(result, nil)) {
+				return false
+			}
+		} else {
+			unchanged++
+		}
+		if w.final(result) {
+			return false
+		}
+		wait = w.interval(result, unchanged)
+
+		return true
+	}
+
+	for {
+		result, err := w.fetch(ctx)
+		switch {
+		case err == nil:
+			if !update(result) {
+				return
+			}
+
+		case ctx.Err() != nil:
+			return
+
+		case !transientError(err):
+			var zero T
+			emit(ctx, updates, w.send(zero, err))
+			return
+
+		default:
+			// 暂时性错误，保持原来的间隔重试；还没有查询到结果时使用最短间隔。
+			if !seen {
+				wait = w.retry
+			}
+		}
+
+		for polled := false; !polled; {
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case webhook := <-webhooks:
+				timer.Stop()
+				// Webhook 已经验证过签名，直接使用其中的状态，不再查询 API。
+				if !update(w.fromWebhook(last, webhook)) {
+					return
+				}
+			case <-timer.C:
+				polled = true
+			}
+		}
+	}
+}
+
+func emit[U any](ctx context.Context, updates chan<- U, update U) bool {
+	select {
+	case updates <- update:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// transientError 判断查询错误是否可以在下一次轮询时重试：网络错误、HTTP 429 与 5xx。
+func transientError(err error) bool {
+	apiErr, ok := AsAPIError(err)
+	if !ok {
+		var validationErr *ValidationError
+		return !errors.As(err, &validationErr)
+	}
+
+	return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500
+}
+
+// webhookHub 将 WebhookHandler 收到的已验证 Webhook 发送给正在等待的 watcher。
+type webhookHub struct {
+	mu   sync.Mutex
+	subs map[chan Webhook]func(Webhook) bool
+}
+
+// subscribe 返回接收匹配 Webhook 的通道。通道只保留最新的一个 Webhook，watcher 来不及接收时旧的会被丢弃。
+func (h *webhookHub) subscribe(match func(Webhook) bool) chan Webhook {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.subs == nil {
+		h.subs = map[chan Webhook]func(Webhook) bool{}
+	}
+
+	ch := make(chan Webhook, 1)
+	h.subs[ch] = match
+
+	return ch
+}
+
+func (h *webhookHub) unsubscribe(ch chan Webhook) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.subs, ch)
+}
+
+func (h *webhookHub) publish(webhook Webhook) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch, match := range h.subs {
+		if !match(webhook) {
+			continue
+		}
+
+		// 只有 publish 向通道发送，并且在持有锁时进行，清空后发送不会阻塞。
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- webhook
+	}
+}
@@ -0,0 +1,258 @@
+package cryptomus
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakePaymentAPI 用中间件模拟 PaymentInformation 接口，依次返回 responses 中的响应，之后重复最后一个。
+type fakePaymentAPI struct {
+	mu        sync.Mutex
+	responses []string
+	calls     int
+}
+
+func (f *fakePaymentAPI) middleware(next Handler) Handler {
+	return func(ctx context.Context, req *Request) (*Response, error) {
+		if req.Endpoint != PaymentInformationEndpoint {
+			return next(ctx, req)
+		}
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		body := f.responses[min(f.calls, len(f.responses)-1)]
+		f.calls++
+		if body == "" {
+			return NewResponse(http.StatusInternalServerError, []byte(`{"state":1,"message":"unavailable"}`)), nil
+		}
+
+		return NewResponse(http.StatusOK, []byte(body)), nil
+	}
+}
+
+func (f *fakePaymentAPI) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.calls
+}
+
+func paymentInfo(status string, final bool) string {
+	isFinal := "false"
+	if final {
+		isFinal = "true"
+	}
+
+	return `{"state":0,"result":{"uuid":"u1","order_id":"order-1","payment_status":"` + status + `","is_final":` + isFinal + `,"expired_at":4102444800}}`
+}
+
+// collect 读取通道直到关闭，超时则测试失败。
+func collect(t *testing.T, updates <-chan PaymentUpdate) []PaymentUpdate {
+	t.Helper()
+
+	var got []PaymentUpdate
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				return got
+			}
+			got = append(got, update)
+		case <-timeout:
+			t.Fatalf("channel not closed, got %d updates", len(got))
+		}
+	}
+}
+
+func newWatchSDK(api *fakePaymentAPI) *Cryptomus {
+	return New(WithMerchant("merchant"), WithPaymentToken("token"), WithMiddleware(api.middleware))
+}
+
+func TestWatchPaymentClosesOnFinalStatus(t *testing.T) {
+	api := &fakePaymentAPI{responses: []string{
+		paymentInfo("check", false),
+		"", // 暂时性错误，下一次轮询时重试
+		paymentInfo("check", false),
+		paymentInfo("confirm_check", false),
+		paymentInfo("paid", true),
+	}}
+	sdk := newWatchSDK(api)
+
+	got := collect(t, sdk.WatchPayment(context.Background(), "order-1", WatchOptions{MinInterval: time.Millisecond, MaxInterval: time.Millisecond}))
+
+	var statuses []string
+	for _, update := range got {
+		if update.Err != nil {
+			t.Fatalf("unexpected error: %v", update.Err)
+		}
+		statuses = append(statuses, string(update.Payment.PaymentStatus))
+	}
+	if want := []string{"check", "confirm_check", "paid"}; !slices.Equal(statuses, want) {
+		t.Errorf("statuses = %v, want %v", statuses, want)
+	}
+	if api.count() != 5 {
+		t.Errorf("PaymentInformation called %d times, want 5", api.count())
+	}
+}
+
+func TestWatchPaymentReportsPermanentError(t *testing.T) {
+	api := &fakePaymentAPI{responses: []string{`{"state":1,"message":"forbidden"}`}}
+	sdk := newWatchSDK(api)
+
+	got := collect(t, sdk.WatchPayment(context.Background(), "u1", WatchOptions{MinInterval: time.Millisecond}))
+	if len(got) != 1 || got[0].Err == nil {
+		t.Fatalf("updates = %+v, want one error", got)
+	}
+}
+
+func TestWatchPaymentClosesOnContextCancel(t *testing.T) {
+	api := &fakePaymentAPI{responses: []string{paymentInfo("check", false)}}
+	sdk := newWatchSDK(api)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	updates := sdk.WatchPayment(ctx, "order-1", WatchOptions{MinInterval: time.Hour, MaxInterval: time.Hour})
+	if update := <-updates; update.Err != nil || update.Payment.PaymentStatus != "check" {
+		t.Fatalf("first update = %+v", update)
+	}
+
+	cancel()
+	if got := collect(t, updates); len(got) != 0 {
+		t.Errorf("updates after cancel = %+v", got)
+	}
+
+	if _, err := sdk.WaitForPayment(ctx, "order-1", WatchOptions{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("WaitForPayment error = %v, want context.Canceled", err)
+	}
+}
+
+func TestWatchPaymentUsesWebhookStatus(t *testing.T) {
+	// 第一次查询之后 API 不可用，最终状态只能来自 Webhook。
+	api := &fakePaymentAPI{responses: []string{paymentInfo("check", false), ""}}
+	sdk := newWatchSDK(api)
+
+	updates := sdk.WatchPayment(context.Background(), "order-1", WatchOptions{MinInterval: time.Hour, MaxInterval: time.Hour})
+	if update := <-updates; update.Err != nil || update.Payment.PaymentStatus != "check" {
+		t.Fatalf("first update = %+v", update)
+	}
+
+	sdk.webhookHub.publish(&PaymentWebhook{UUID: "u2", OrderID: "order-2", Status: PaymentStatusPaid, IsFinal: true})
+	sdk.webhookHub.publish(&PayoutWebhook{UUID: "u1", OrderID: "order-1", Status: PayoutStatusPaid, IsFinal: true})
+	sdk.webhookHub.publish(&PaymentWebhook{UUID: "u1", OrderID: "order-1", Status: PaymentStatusPaid, IsFinal: true, Txid: "tx"})
+
+	got := collect(t, updates)
+	if len(got) != 1 {
+		t.Fatalf("updates = %+v, want one", got)
+	}
+	payment := got[0].Payment
+	if payment.PaymentStatus != "paid" || !payment.IsFinal || payment.Txid != "tx" {
+		t.Errorf("payment = %+v, want paid from webhook", payment)
+	}
+	if payment.ExpiredAt != 4102444800 {
+		t.Errorf("ExpiredAt = %d, want the value from the last query", payment.ExpiredAt)
+	}
+	if api.count() != 1 {
+		t.Errorf("PaymentInformation called %d times, want 1", api.count())
+	}
+}
+
+func TestWebhookHub(t *testing.T) {
+	var hub webhookHub
+
+	isPayment := func(webhook Webhook) bool {
+		_, ok := webhook.(*PaymentWebhook)
+		return ok
+	}
+	payments := hub.subscribe(isPayment)
+	payouts := hub.subscribe(func(webhook Webhook) bool {
+		_, ok := webhook.(*PayoutWebhook)
+		return ok
+	})
+
+	// 没有及时接收时只保留最新的 Webhook。
+	hub.publish(&PaymentWebhook{UUID: "old"})
+	hub.publish(&PaymentWebhook{UUID: "new"})
+
+	select {
+	case webhook := <-payments:
+		if webhook.(*PaymentWebhook).UUID != "new" {
+			t.Errorf("received %+v, want the latest webhook", webhook)
+		}
+	default:
+		t.Fatal("payment subscriber not notified")
+	}
+	select {
+	case webhook := <-payouts:
+		t.Fatalf("payout subscriber received %+v", webhook)
+	default:
+	}
+
+	hub.unsubscribe(payments)
+	hub.publish(&PaymentWebhook{UUID: "after"})
+	select {
+	case webhook := <-payments:
+		t.Fatalf("unsubscribed channel received %+v", webhook)
+	default:
+	}
+
+	hub.unsubscribe(payouts)
+	if len(hub.subs) != 0 {
+		t.Errorf("subs = %d, want 0", len(hub.subs))
+	}
+}
+
+func TestLookupInfo(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		fallback bool
+	}{
+		{name: "found"},
+		{name: "not found", err: &APIError{StatusCode: http.StatusNotFound}, fallback: true},
+		{name: "invalid uuid", err: &APIError{StatusCode: http.StatusUnprocessableEntity, Errors: Errors{"uuid": {"The uuid must be a valid UUID."}}}, fallback: true},
+		{name: "other field", err: &APIError{StatusCode: http.StatusUnprocessableEntity, Errors: Errors{"merchant": {"invalid"}}}},
+		{name: "local validation", err: &ValidationError{Errors: Errors{"order_id": {"invalid"}}}},
+		{name: "server error", err: &APIError{StatusCode: http.StatusBadGateway}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lookup := &infoLookup{id: "order-1"}
+			var calls []string
+			fetch := func(uuid, orderID string) (string, error) {
+				if uuid != "" {
+					calls = append(calls, "uuid")
+					return "", tt.err
+				}
+				calls = append(calls, "order_id")
+				return "result", nil
+			}
+
+			_, err := lookupInfo(lookup, fetch)
+			if tt.fallback {
+				if err != nil || !slices.Equal(calls, []string{"uuid", "order_id"}) {
+					t.Fatalf("calls = %v, err = %v, want fallback to order_id", calls, err)
+				}
+
+				// 之后直接按 order_id 查询。
+				calls = nil
+				if _, err := lookupInfo(lookup, fetch); err != nil || !slices.Equal(calls, []string{"order_id"}) {
+					t.Errorf("second lookup calls = %v, err = %v", calls, err)
+				}
+				return
+			}
+
+			if !errors.Is(err, tt.err) || !slices.Equal(calls, []string{"uuid"}) {
+				t.Errorf("calls = %v, err = %v, want %v without fallback", calls, err, tt.err)
+			}
+		})
+	}
+}
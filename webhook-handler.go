@@ -0,0 +1,249 @@
+package cryptomus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// CryptomusWebhookIP 是 Cryptomus 发送 Webhook 使用的 IP 地址。
+//
+// 详情：https://doc.cryptomus.com/business/payments/webhook
+const CryptomusWebhookIP = "91.227.144.54"
+
+// DefaultWebhookMaxBodySize 是 WebhookHandler 默认允许的最大请求体大小。
+const DefaultWebhookMaxBodySize int64 = 1 << 20
+
+// WebhookHandlerOptions 配置 WebhookHandler。
+//
+// 回调返回 nil 时响应 200；返回错误时响应 500，Cryptomus 会稍后重试。
+// 没有设置对应回调的 Webhook 类型会直接响应 200。
+// 回调的 ctx 中包含接收该 Webhook 的 SDK 实例，可以通过 MerchantFromContext 获取。
+type WebhookHandlerOptions struct {
+	OnPayment   func(ctx context.Context, webhook *PaymentWebhook) error
+	OnPayout    func(ctx context.Context, webhook *PayoutWebhook) error
+	OnWallet    func(ctx context.Context, webhook *WalletWebhook) error
+	OnRecurring func(ctx context.Context, webhook *RecurringWebhook) error
+
+	// MaxBodySize 限制请求体大小，默认为 DefaultWebhookMaxBodySize。
+	MaxBodySize int64
+
+	// AllowedIPs 是允许发送 Webhook 的 IP 或 CIDR 列表，为空时不限制。
+	// 通常设置为 []string{cryptomus.CryptomusWebhookIP}。
+	AllowedIPs []string
+
+	// ClientIPHeader 用于在反向代理之后获取客户端 IP，例如 "X-Forwarded-For" 或 "X-Real-IP"。
+	// 为空时使用 http.Request.RemoteAddr。
+	//
+	// 列表中靠左的地址由客户端提供，可以被伪造，因此按 TrustedProxies 从右向左选择地址。
+	// 只有在请求一定经过这些代理、且代理会追加或覆盖该请求头时才应设置 ClientIPHeader。
+	ClientIPHeader string
+
+	// TrustedProxies 是 ClientIPHeader 中由可信代理追加的地址数，客户端 IP 是从右数第 TrustedProxies 个地址，
+	// 默认为 1，即最右边的地址（只有一层代理）。例如 CDN 之后还有一层 nginx 时设置为 2。
+	// 请求头中的地址少于 TrustedProxies 时拒绝请求。
+	TrustedProxies int
+
+	// OnError 在请求被拒绝或回调返回错误时调用，可用于记录日志。
+	OnError func(r *http.Request, status int, err error)
+}
+
+type webhookHandler struct {
+	// parse 校验并解析请求体，返回 Webhook 所属的 SDK 实例。
+	parse   func(body []byte) (*Cryptomus, Webhook, error)
+	opts    WebhookHandlerOptions
+	allowed []netip.Prefix
+}
+
+// WebhookHandler 返回一个接收 Cryptomus Webhook 的 http.Handler。
+//
+// 处理器会校验来源 IP（可选）与签名，将请求体解析为对应类型并调用回调：
+//
+//   - 405：请求方法不是 POST
+//   - 403：来源 IP 不在 AllowedIPs 中
+//   - 413：请求体超过 MaxBodySize
+//   - 400：请求体不是合法的 Webhook
+//   - 401：签名缺失或无效，或 Webhook 所属的商户未注册（MerchantRegistry）
+//   - 500：回调返回错误或 CredentialsProvider 返回错误，Cryptomus 会重试
+//   - 200：处理成功
+//
+// AllowedIPs 中包含无效地址时会 panic。
+//
+// 示例：
+//
+//	http.Handle("/webhook/cryptomus", sdk.WebhookHandler(cryptomus.WebhookHandlerOptions{
+//		AllowedIPs: []string{cryptomus.CryptomusWebhookIP},
+//		OnPayment: func(ctx context.Context, webhook *cryptomus.PaymentWebhook) error {
+//			return orders.MarkPaid(ctx, webhook.OrderID, webhook.Status)
+//		},
+//	}))
+func (sdk *Cryptomus) WebhookHandler(opts WebhookHandlerOptions) http.Handler {
+	return newWebhookHandler(opts, func(body []byte) (*Cryptomus, Webhook, error) {
+		webhook, err := sdk.ParseWebhook(body)
+		return sdk, webhook, err
+	})
+}
+
+func newWebhookHandler(opts WebhookHandlerOptions, parse func(body []byte) (*Cryptomus, Webhook, error)) *webhookHandler {
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = DefaultWebhookMaxBodySize
+	}
+
+	allowed := make([]netip.Prefix, 0, len(opts.AllowedIPs))
+	for _, ip := range opts.AllowedIPs {
+		prefix, err := parseIPPrefix(ip)
+		if err != nil {
+			panic(fmt.Sprintf("cryptomus: invalid webhook allowed IP %q: %v", ip, err))
+		}
+		allowed = append(allowed, prefix)
+	}
+
+	return &webhookHandler{
+		parse:   parse,
+		opts:    opts,
+		allowed: allowed,
+	}
+}
+
+func (h *webhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		h.fail(w, r, http.StatusMethodNotAllowed, errors.New("cryptomus: webhook method must be POST"))
+		return
+	}
+
+	if !h.allowIP(r) {
+		h.fail(w, r, http.StatusForbidden, fmt.Errorf("cryptomus: webhook from disallowed IP %q", h.clientIP(r)))
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, h.opts.MaxBodySize))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			h.fail(w, r, http.StatusRequestEntityTooLarge, err)
+			return
+		}
+		h.fail(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	sdk, webhook, err := h.parse(body)
+	if err != nil {
+		if errors.Is(err, errLoadCredentials) {
+			h.fail(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		if errors.Is(err, ErrWebhookSignatureMissing) || errors.Is(err, ErrWebhookSignatureInvalid) || errors.Is(err, ErrUnknownMerchant) {
+			h.fail(w, r, http.StatusUnauthorized, err)
+			return
+		}
+		h.fail(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	// 将 Webhook 发送给等待该发票或提现的 WatchPayment、WatchPayout，它们直接使用其中的状态。
+	sdk.webhookHub.publish(webhook)
+
+	if err := h.dispatch(withMerchant(r.Context(), sdk), webhook); err != nil {
+		h.fail(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *webhookHandler) dispatch(ctx context.Context, webhook Webhook) error {
+	switch webhook := webhook.(type) {
+	case *PaymentWebhook:
+		if h.opts.OnPayment != nil {
+			return h.opts.OnPayment(ctx, webhook)
+		}
+	case *WalletWebhook:
+		if h.opts.OnWallet != nil {
+			return h.opts.OnWallet(ctx, webhook)
+		}
+	case *PayoutWebhook:
+		if h.opts.OnPayout != nil {
+			return h.opts.OnPayout(ctx, webhook)
+		}
+	case *RecurringWebhook:
+		if h.opts.OnRecurring != nil {
+			return h.opts.OnRecurring(ctx, webhook)
+		}
+	}
+
+	return nil
+}
+
+func (h *webhookHandler) fail(w http.ResponseWriter, r *http.Request, status int, err error) {
+	if h.opts.OnError != nil {
+		h.opts.OnError(r, status, err)
+	}
+
+	http.Error(w, http.StatusText(status), status)
+}
+
+func (h *webhookHandler) allowIP(r *http.Request) bool {
+	if len(h.allowed) == 0 {
+		return true
+	}
+
+	addr, err := netip.ParseAddr(h.clientIP(r))
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+
+	for _, prefix := range h.allowed {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (h *webhookHandler) clientIP(r *http.Request) string {
+	if h.opts.ClientIPHeader != "" {
+		if values := r.Header.Values(h.opts.ClientIPHeader); len(values) > 0 {
+			// 多个同名请求头按顺序合并，与逗号分隔的列表等价。
+			ips := strings.Split(strings.Join(values, ","), ",")
+			n := max(h.opts.TrustedProxies, 1)
+			if n > len(ips) {
+				return ""
+			}
+			return strings.TrimSpace(ips[len(ips)-n])
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+func parseIPPrefix(value string) (netip.Prefix, error) {
+	if strings.Contains(value, "/") {
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return prefix.Masked(), nil
+	}
+
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
@@ -0,0 +1,151 @@
+package cryptomus_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	cryptomus "github.com/difyz9/cryptomus-sdk-go"
+)
+
+const testPaymentToken = "test-payment-token"
+
+func signedPaymentWebhook(t *testing.T, key string) string {
+	t.Helper()
+
+	body, err := cryptomus.SignWebhook([]byte(`{"type":"payment","uuid":"a1","order_id":"order-1","amount":"15.00","status":"paid","is_final":true}`), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(body)
+}
+
+func TestWebhookHandler(t *testing.T) {
+	sdk := cryptomus.New(cryptomus.WithMerchant("merchant"), cryptomus.WithPaymentToken(testPaymentToken))
+
+	valid := signedPaymentWebhook(t, testPaymentToken)
+	forged := signedPaymentWebhook(t, "wrong-key")
+
+	tests := []struct {
+		name    string
+		opts    cryptomus.WebhookHandlerOptions
+		method  string
+		body    string
+		remote  string
+		headers map[string][]string
+		want    int
+		called  bool
+	}{
+		{name: "method not allowed", method: http.MethodGet, want: http.StatusMethodNotAllowed},
+		{
+			name: "disallowed remote address",
+			opts: cryptomus.WebhookHandlerOptions{AllowedIPs: []string{cryptomus.CryptomusWebhookIP}},
+			body: valid,
+			want: http.StatusForbidden,
+		},
+		{
+			name:   "allowed remote address",
+			opts:   cryptomus.WebhookHandlerOptions{AllowedIPs: []string{cryptomus.CryptomusWebhookIP}},
+			body:   valid,
+			remote: cryptomus.CryptomusWebhookIP + ":443",
+			want:   http.StatusOK,
+			called: true,
+		},
+		{
+			name:    "spoofed leftmost forwarded address",
+			opts:    cryptomus.WebhookHandlerOptions{AllowedIPs: []string{cryptomus.CryptomusWebhookIP}, ClientIPHeader: "X-Forwarded-For"},
+			body:    valid,
+			headers: map[string][]string{"X-Forwarded-For": {cryptomus.CryptomusWebhookIP + ", 203.0.113.9"}},
+			want:    http.StatusForbidden,
+		},
+		{
+			name:    "spoofed forwarded header line",
+			opts:    cryptomus.WebhookHandlerOptions{AllowedIPs: []string{cryptomus.CryptomusWebhookIP}, ClientIPHeader: "X-Forwarded-For"},
+			body:    valid,
+			headers: map[string][]string{"X-Forwarded-For": {cryptomus.CryptomusWebhookIP, "203.0.113.9"}},
+			want:    http.StatusForbidden,
+		},
+		{
+			name:    "rightmost forwarded address",
+			opts:    cryptomus.WebhookHandlerOptions{AllowedIPs: []string{cryptomus.CryptomusWebhookIP}, ClientIPHeader: "X-Forwarded-For"},
+			body:    valid,
+			headers: map[string][]string{"X-Forwarded-For": {"203.0.113.9, " + cryptomus.CryptomusWebhookIP}},
+			want:    http.StatusOK,
+			called:  true,
+		},
+		{
+			name: "two trusted proxies",
+			opts: cryptomus.WebhookHandlerOptions{
+				AllowedIPs:     []string{cryptomus.CryptomusWebhookIP},
+				ClientIPHeader: "X-Forwarded-For",
+				TrustedProxies: 2,
+			},
+			body:    valid,
+			headers: map[string][]string{"X-Forwarded-For": {"203.0.113.9, " + cryptomus.CryptomusWebhookIP + ", 10.0.0.1"}},
+			want:    http.StatusOK,
+			called:  true,
+		},
+		{
+			name: "fewer forwarded addresses than trusted proxies",
+			opts: cryptomus.WebhookHandlerOptions{
+				AllowedIPs:     []string{cryptomus.CryptomusWebhookIP},
+				ClientIPHeader: "X-Forwarded-For",
+				TrustedProxies: 2,
+			},
+			body:    valid,
+			headers: map[string][]string{"X-Forwarded-For": {cryptomus.CryptomusWebhookIP}},
+			want:    http.StatusForbidden,
+		},
+		{
+			name: "body too large",
+			opts: cryptomus.WebhookHandlerOptions{MaxBodySize: 16},
+			body: valid,
+			want: http.StatusRequestEntityTooLarge,
+		},
+		{name: "invalid body", body: `{"type":`, want: http.StatusBadRequest},
+		{name: "missing sign", body: `{"type":"payment","order_id":"order-1"}`, want: http.StatusUnauthorized},
+		{name: "invalid sign", body: forged, want: http.StatusUnauthorized},
+		{name: "valid", body: valid, want: http.StatusOK, called: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			opts := tt.opts
+			opts.OnPayment = func(ctx context.Context, webhook *cryptomus.PaymentWebhook) error {
+				called = true
+				if webhook.OrderID != "order-1" || webhook.Status != cryptomus.PaymentStatusPaid {
+					t.Errorf("webhook = %+v", webhook)
+				}
+				return nil
+			}
+
+			method := tt.method
+			if method == "" {
+				method = http.MethodPost
+			}
+			req := httptest.NewRequest(method, "/webhook", strings.NewReader(tt.body))
+			if tt.remote != "" {
+				req.RemoteAddr = tt.remote
+			}
+			for name, values := range tt.headers {
+				for _, value := range values {
+					req.Header.Add(name, value)
+				}
+			}
+
+			rec := httptest.NewRecorder()
+			sdk.WebhookHandler(opts).ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if called != tt.called {
+				t.Errorf("OnPayment called = %v, want %v", called, tt.called)
+			}
+		})
+	}
+}
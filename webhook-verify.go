@@ -0,0 +1,362 @@
+package cryptomus
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrWebhookSignatureMissing = errors.New("cryptomus: webhook sign is missing")
+	ErrWebhookSignatureInvalid = errors.New("cryptomus: webhook sign is invalid")
+)
+
+// WebhookType 是 Webhook 请求体中 type 字段的取值。
+type WebhookType string
+
+const (
+	WebhookTypePayment   WebhookType = "payment"   // 发票支付
+	WebhookTypeWallet    WebhookType = "wallet"    // 静态钱包充值
+	WebhookTypePayout    WebhookType = "payout"    // 提现
+	WebhookTypeRecurrent WebhookType = "recurrent" // 循环支付
+)
+
+// Webhook 是 ParseWebhook 返回的 Webhook，具体类型为 *PaymentWebhook、*WalletWebhook、*PayoutWebhook 或 *RecurringWebhook。
+type Webhook interface {
+	WebhookType() WebhookType
+}
+
+type WebhookConvert struct {
+	ToCurrency string `json:"to_currency"`
+	Commission Amount `json:"commission"`
+	Rate       Amount `json:"rate"`
+	Amount     Amount `json:"amount"`
+}
+
+// UnmarshalJSON 兼容 PHP 将空的 convert 编码为 [] 的情况。
+func (c *WebhookConvert) UnmarshalJSON(data []byte) error {
+	if string(bytes.TrimSpace(data)) == "[]" {
+		*c = WebhookConvert{}
+		return nil
+	}
+
+	type plain WebhookConvert
+	return json.Unmarshal(data, (*plain)(c))
+}
+
+// PaymentWebhook 是发票状态变化时发送的 Webhook。
+//
+// 详情：https://doc.cryptomus.com/business/payments/webhook
+type PaymentWebhook struct {
+	Type              WebhookType     `json:"type"`
+	UUID              string          `json:"uuid"`
+	OrderID           string          `json:"order_id"`
+	Amount            Amount          `json:"amount"`
+	PaymentAmount     Amount          `json:"payment_amount"`
+	PaymentAmountUSD  Amount          `json:"payment_amount_usd"`
+	MerchantAmount    Amount          `json:"merchant_amount"`
+	Commission        Amount          `json:"commission"`
+	IsFinal           bool            `json:"is_final"`
+	Status            PaymentStatus   `json:"status"`
+	From              string          `json:"from"`
+	WalletAddressUUID string          `json:"wallet_address_uuid"`
+	Network           string          `json:"network"`
+	Currency          string          `json:"currency"`
+	PayerCurrency     string          `json:"payer_currency"`
+	AdditionalData    string          `json:"additional_data"`
+	Convert           *WebhookConvert `json:"convert"`
+	Txid              string          `json:"txid"`
+	MerchantID        string          `json:"merchant_id,omitempty"`
+	Sign              string          `json:"sign"`
+}
+
+func (w *PaymentWebhook) WebhookType() WebhookType {
+	return WebhookTypePayment
+}
+
+// WalletWebhook 是静态钱包收到充值时发送的 Webhook，字段与 PaymentWebhook 相同。
+type WalletWebhook struct {
+	PaymentWebhook
+}
+
+func (w *WalletWebhook) WebhookType() WebhookType {
+	return WebhookTypeWallet
+}
+
+// PayoutWebhook 是提现状态变化时发送的 Webhook。
+//
+// 详情：https://doc.cryptomus.com/business/payouts/webhook
+type PayoutWebhook struct {
+	Type           WebhookType  `json:"type"`
+	UUID           string       `json:"uuid"`
+	OrderID        string       `json:"order_id"`
+	Amount         Amount       `json:"amount"`
+	MerchantAmount Amount       `json:"merchant_amount"`
+	Commission     Amount       `json:"commission"`
+	IsFinal        bool         `json:"is_final"`
+	Status         PayoutStatus `json:"status"`
+	Txid           string       `json:"txid"`
+	Currency       string       `json:"currency"`
+	Network        string       `json:"network"`
+	PayerCurrency  string       `json:"payer_currency"`
+	PayerAmount    Amount       `json:"payer_amount"`
+	MerchantID     string       `json:"merchant_id,omitempty"`
+	Sign           string       `json:"sign"`
+}
+
+func (w *PayoutWebhook) WebhookType() WebhookType {
+	return WebhookTypePayout
+}
+
+// RecurringWebhook 是循环支付状态变化时发送的 Webhook，使用支付密钥签名。
+//
+// 详情：https://doc.cryptomus.com/business/recurring/creating
+type RecurringWebhook struct {
+	Type          WebhookType `json:"type"`
+	UUID          string      `json:"uuid"`
+	OrderID       string      `json:"order_id"`
+	Name          string      `json:"name"`
+	Amount        Amount      `json:"amount"`
+	Currency      string      `json:"currency"`
+	PayerCurrency string      `json:"payer_currency"`
+	PayerAmount   Amount      `json:"payer_amount"`
+	Period        string      `json:"period"`
+	Status        string      `json:"status"`
+	Txid          string      `json:"txid"`
+	MerchantID    string      `json:"merchant_id,omitempty"`
+	Sign          string      `json:"sign"`
+}
+
+func (w *RecurringWebhook) WebhookType() WebhookType {
+	return WebhookTypeRecurrent
+}
+
+// VerifyWebhook 校验 Webhook 请求体中的 sign 字段。
+//
+// 详情：https://doc.cryptomus.com/business/payments/webhook
+//
+// 签名的计算方式与 Cryptomus 一致：从请求体中取出 sign，按 PHP json_encode 的规则（包括 \/ 转义）
+// 重新序列化剩余数据，然后计算 md5(base64(data) + key)。支付与静态钱包 Webhook 使用支付密钥，提现 Webhook 使用提现密钥。
+//
+// 示例：
+//
+//	body, _ := io.ReadAll(r.Body)
+//	if err := cryptomus.VerifyWebhook(body, paymentToken); err != nil {
+//	    http.Error(w, err.Error(), http.StatusUnauthorized)
+//	    return
+//	}
+func VerifyWebhook(body []byte, key string) error {
+	data, sign, err := splitWebhookSign(body)
+	if err != nil {
+		return err
+	}
+
+	if !validWebhookSign(data, sign, key) {
+		return ErrWebhookSignatureInvalid
+	}
+
+	return nil
+}
+
+// ParseWebhook 校验 Webhook 签名并将请求体解析为对应类型的结构体。
+//
+// 示例：
+//
+//	webhook, err := cryptomus.ParseWebhook(body, paymentToken)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//
+//	switch w := webhook.(type) {
+//	case *cryptomus.PaymentWebhook:
+//	    log.Printf("invoice %s: %s", w.OrderID, w.Status)
+//	case *cryptomus.WalletWebhook:
+//	    log.Printf("wallet %s: %s", w.OrderID, w.Status)
+//	}
+func ParseWebhook(body []byte, key string) (Webhook, error) {
+	if err := VerifyWebhook(body, key); err != nil {
+		return nil, err
+	}
+
+	return decodeWebhook(body)
+}
+
+// VerifyWebhook 使用 SDK 实例的密钥校验 Webhook 签名：提现 Webhook 使用 PayoutToken，其余使用 PaymentToken。
+//
+// 使用 WithCredentialsProvider 轮换密钥后，宽限期内使用旧密钥签名的 Webhook 同样会通过校验。
+//
+// 示例：
+//
+//	if err := sdk.VerifyWebhook(body); err != nil {
+//	    http.Error(w, err.Error(), http.StatusUnauthorized)
+//	    return
+//	}
+func (sdk *Cryptomus) VerifyWebhook(body []byte) error {
+	webhookType, err := peekWebhookType(body)
+	if err != nil {
+		return err
+	}
+
+	data, sign, err := splitWebhookSign(body)
+	if err != nil {
+		return err
+	}
+
+	ok, err := sdk.validWebhookSign(webhookType, data, sign)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrWebhookSignatureInvalid
+	}
+
+	return nil
+}
+
+// ParseWebhook 使用 SDK 实例的密钥校验 Webhook 签名并解析请求体。
+//
+// 示例：
+//
+//	webhook, err := sdk.ParseWebhook(body)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+func (sdk *Cryptomus) ParseWebhook(body []byte) (Webhook, error) {
+	if err := sdk.VerifyWebhook(body); err != nil {
+		return nil, err
+	}
+
+	return decodeWebhook(body)
+}
+
+// SignWebhook 按 Cryptomus 的方式为 Webhook 请求体签名，返回追加了 sign 字段的请求体。
+//
+// 请求体中已有的 sign 字段会被替换。主要用于测试，例如模拟 Cryptomus 向回调地址发送 Webhook。
+//
+// 示例：
+//
+//	body, err := cryptomus.SignWebhook([]byte(`{"type":"payment","order_id":"1","status":"paid"}`), paymentToken)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+func SignWebhook(body []byte, key string) ([]byte, error) {
+	data, _, err := phpJSON{assoc: true}.encode(body, "sign")
+	if err != nil {
+		return nil, fmt.Errorf("cryptomus: invalid webhook body: %w", err)
+	}
+
+	if len(data) < 2 || data[0] != '{' {
+		return nil, errors.New("cryptomus: webhook body must be a JSON object")
+	}
+
+	hash := md5.Sum([]byte(base64.StdEncoding.EncodeToString(data) + key))
+
+	signed := bytes.NewBuffer(make([]byte, 0, len(data)+48))
+	signed.Write(data[:len(data)-1])
+	if len(data) > 2 {
+		signed.WriteByte(',')
+	}
+	signed.WriteString(`"sign":"`)
+	signed.WriteString(hex.EncodeToString(hash[:]))
+	signed.WriteString(`"}`)
+
+	return signed.Bytes(), nil
+}
+
+// validWebhookSign 使用当前密钥，以及宽限期内轮换前的密钥校验 Webhook 签名。
+func (sdk *Cryptomus) validWebhookSign(webhookType WebhookType, data []byte, sign string) (bool, error) {
+	key := TokenPayment
+	if webhookType == WebhookTypePayout {
+		key = TokenPayout
+	}
+
+	creds, err := sdk.credentials(context.Background())
+	if err != nil {
+		return false, err
+	}
+
+	if validWebhookSign(data, sign, creds.token(key)) {
+		return true, nil
+	}
+
+	for _, token := range sdk.keyring.accepted(key, time.Now()) {
+		if validWebhookSign(data, sign, token) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func splitWebhookSign(body []byte) ([]byte, string, error) {
+	data, rawSign, err := phpJSON{assoc: true}.encode(body, "sign")
+	if err != nil {
+		return nil, "", fmt.Errorf("cryptomus: invalid webhook body: %w", err)
+	}
+
+	var sign string
+	if rawSign != nil {
+		if err := json.Unmarshal(rawSign, &sign); err != nil {
+			return nil, "", ErrWebhookSignatureInvalid
+		}
+	}
+
+	if sign == "" {
+		return nil, "", ErrWebhookSignatureMissing
+	}
+
+	return data, sign, nil
+}
+
+func validWebhookSign(data []byte, sign, key string) bool {
+	hash := md5.Sum([]byte(base64.StdEncoding.EncodeToString(data) + key))
+	expected := hex.EncodeToString(hash[:])
+
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(sign)) == 1
+}
+
+func peekWebhookType(body []byte) (WebhookType, error) {
+	var envelope struct {
+		Type WebhookType `json:"type"`
+	}
+
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return "", fmt.Errorf("cryptomus: invalid webhook body: %w", err)
+	}
+
+	return envelope.Type, nil
+}
+
+func decodeWebhook(body []byte) (Webhook, error) {
+	webhookType, err := peekWebhookType(body)
+	if err != nil {
+		return nil, err
+	}
+
+	var webhook Webhook
+	switch webhookType {
+	case WebhookTypePayment:
+		webhook = &PaymentWebhook{}
+	case WebhookTypeWallet:
+		webhook = &WalletWebhook{}
+	case WebhookTypePayout:
+		webhook = &PayoutWebhook{}
+	case WebhookTypeRecurrent:
+		webhook = &RecurringWebhook{}
+	default:
+		return nil, fmt.Errorf("cryptomus: unknown webhook type %q", webhookType)
+	}
+
+	if err := json.Unmarshal(body, webhook); err != nil {
+		return nil, fmt.Errorf("cryptomus: invalid webhook body: %w", err)
+	}
+
+	return webhook, nil
+}
@@ -1,12 +1,24 @@
 package cryptomus
 
-import "context"
+import (
+	"context"
+	"net/http"
+)
 
 type ResendWebhookRequest struct {
 	UUID    string `json:"uuid,omitempty"`
 	OrderID string `json:"order_id,omitempty"`
 }
 
+// Validate 校验 ResendWebhook 的请求参数。
+func (r *ResendWebhookRequest) Validate() error {
+	var v validation
+
+	v.uuidOrOrderID(r.UUID, r.OrderID)
+
+	return v.err()
+}
+
 type ResendWebhookData struct{}
 
 type ResendWebhookResponse struct {
@@ -53,15 +65,12 @@ func (sdk *Cryptomus) ResendWebhook(payload *ResendWebhookRequest) (*ResendWebho
 func (sdk *Cryptomus) ResendWebhookWithContext(ctx context.Context, payload *ResendWebhookRequest) (*ResendWebhookResponse, error) {
 	var result ResendWebhookResponse
 
-	req := sdk.HttpClient.NewRequest().
-		SetContext(ctx).
-		SetHeader("merchant", sdk.Merchant).
-		SetHeader("sign", Sign(sdk.PaymentToken, payload)).
-		SetBody(payload).
-		SetSuccessResult(&result).
-		SetErrorResult(&result)
-
-	if _, err := req.Post(ResendWebhookEndpoint.URL()); err != nil {
+	if err := sdk.send(ctx, &Request{
+		Method:   http.MethodPost,
+		Endpoint: ResendWebhookEndpoint,
+		Token:    TokenPayment,
+		Payload:  payload,
+	}, &result); err != nil {
 		return nil, err
 	}
 
@@ -77,6 +86,11 @@ type TestingWebhookPaymentRequest struct {
 	Status      PaymentStatus `json:"status"`
 }
 
+// Validate 校验 TestingWebhookPayment 的请求参数。
+func (r *TestingWebhookPaymentRequest) Validate() error {
+	return validateTestingWebhook(r.URLCallback, r.Currency, r.Network, r.OrderID, string(r.Status))
+}
+
 type TestingWebhookPaymentData struct{}
 
 type TestingWebhookPaymentResponse struct {
@@ -141,15 +155,12 @@ func (sdk *Cryptomus) TestingWebhookPayment(payload *TestingWebhookPaymentReques
 func (sdk *Cryptomus) TestingWebhookPaymentWithContext(ctx context.Context, payload *TestingWebhookPaymentRequest) (*TestingWebhookPaymentResponse, error) {
 	var result TestingWebhookPaymentResponse
 
-	req := sdk.HttpClient.NewRequest().
-		SetContext(ctx).
-		SetHeader("merchant", sdk.Merchant).
-		SetHeader("sign", Sign(sdk.PaymentToken, payload)).
-		SetBody(payload).
-		SetSuccessResult(&result).
-		SetErrorResult(&result)
-
-	if _, err := req.Post(TestingWebhookPaymentEndpoint.URL()); err != nil {
+	if err := sdk.send(ctx, &Request{
+		Method:   http.MethodPost,
+		Endpoint: TestingWebhookPaymentEndpoint,
+		Token:    TokenPayment,
+		Payload:  payload,
+	}, &result); err != nil {
 		return nil, err
 	}
 
@@ -165,6 +176,11 @@ type TestingWebhookPayoutRequest struct {
 	Status      PayoutStatus `json:"status"`
 }
 
+// Validate 校验 TestingWebhookPayout 的请求参数。
+func (r *TestingWebhookPayoutRequest) Validate() error {
+	return validateTestingWebhook(r.URLCallback, r.Currency, r.Network, r.OrderID, string(r.Status))
+}
+
 type TestingWebhookPayoutData struct{}
 
 type TestingWebhookPayoutResponse struct {
@@ -219,15 +235,12 @@ func (sdk *Cryptomus) TestingWebhookPayout(payload *TestingWebhookPayoutRequest)
 func (sdk *Cryptomus) TestingWebhookPayoutWithContext(ctx context.Context, payload *TestingWebhookPayoutRequest) (*TestingWebhookPayoutResponse, error) {
 	var result TestingWebhookPayoutResponse
 
-	req := sdk.HttpClient.NewRequest().
-		SetContext(ctx).
-		SetHeader("merchant", sdk.Merchant).
-		SetHeader("sign", Sign(sdk.PaymentToken, payload)).
-		SetBody(payload).
-		SetSuccessResult(&result).
-		SetErrorResult(&result)
-
-	if _, err := req.Post(TestingWebhookPayoutEndpoint.URL()); err != nil {
+	if err := sdk.send(ctx, &Request{
+		Method:   http.MethodPost,
+		Endpoint: TestingWebhookPayoutEndpoint,
+		Token:    TokenPayment,
+		Payload:  payload,
+	}, &result); err != nil {
 		return nil, err
 	}
 
@@ -243,6 +256,11 @@ type TestingWebhookWalletRequest struct {
 	Status      PaymentStatus `json:"status"`
 }
 
+// Validate 校验 TestingWebhookWallet 的请求参数。
+func (r *TestingWebhookWalletRequest) Validate() error {
+	return validateTestingWebhook(r.URLCallback, r.Currency, r.Network, r.OrderID, string(r.Status))
+}
+
 type TestingWebhookWalletData struct{}
 
 type TestingWebhookWalletResponse struct {
@@ -293,17 +311,26 @@ func (sdk *Cryptomus) TestingWebhookWallet(payload *TestingWebhookWalletRequest)
 func (sdk *Cryptomus) TestingWebhookWalletWithContext(ctx context.Context, payload *TestingWebhookWalletRequest) (*TestingWebhookWalletResponse, error) {
 	var result TestingWebhookWalletResponse
 
-	req := sdk.HttpClient.NewRequest().
-		SetContext(ctx).
-		SetHeader("merchant", sdk.Merchant).
-		SetHeader("sign", Sign(sdk.PaymentToken, payload)).
-		SetBody(payload).
-		SetSuccessResult(&result).
-		SetErrorResult(&result)
-
-	if _, err := req.Post(TestingWebhookWalletEndpoint.URL()); err != nil {
+	if err := sdk.send(ctx, &Request{
+		Method:   http.MethodPost,
+		Endpoint: TestingWebhookWalletEndpoint,
+		Token:    TokenPayment,
+		Payload:  payload,
+	}, &result); err != nil {
 		return nil, err
 	}
 
 	return &result, nil
 }
+
+func validateTestingWebhook(urlCallback, currency, network, orderID, status string) error {
+	var v validation
+
+	v.required("url_callback", urlCallback)
+	v.required("currency", currency)
+	v.required("network", network)
+	v.required("status", status)
+	v.orderID("order_id", orderID, false)
+
+	return v.err()
+}